@@ -0,0 +1,127 @@
+package treemux
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// AccessLogEntry describes a single request served by the router.
+type AccessLogEntry struct {
+	Time       time.Time
+	Method     string
+	Route      string
+	RouteName  string
+	Path       string
+	Params     Params
+	StatusCode int
+	Bytes      int64
+	Duration   time.Duration
+	// Err is the error returned by the handler, if any.
+	Err error
+
+	ctx context.Context
+}
+
+// SamplingRule selects which fraction of requests is logged.
+type SamplingRule struct {
+	// Route is the route template the rule applies to, e.g. "/users/:id".
+	// Empty string matches any route.
+	Route string
+	// StatusClass is the status class the rule applies to, e.g. 2 for 2xx or 5 for 5xx.
+	// Zero matches any status.
+	StatusClass int
+	// Rate is the fraction of matching requests that are logged, from 0 to 1.
+	Rate float64
+}
+
+func (r *SamplingRule) match(route string, statusClass int) bool {
+	return (r.Route == "" || r.Route == route) &&
+		(r.StatusClass == 0 || r.StatusClass == statusClass)
+}
+
+// AccessLogger is a middleware that logs served requests.
+//
+// Requests are logged according to the sampling rules, which can be changed at runtime
+// with SetSampling. The first matching rule decides the sampling rate and requests
+// that don't match any rule are always logged. For example, to log all 5xx responses
+// but only 1% of successful responses on a hot route:
+//
+//	logger.SetSampling(
+//		treemux.SamplingRule{StatusClass: 5, Rate: 1},
+//		treemux.SamplingRule{Route: "/api/feed", StatusClass: 2, Rate: 0.01},
+//	)
+type AccessLogger struct {
+	log   func(*AccessLogEntry)
+	rules atomic.Value // []SamplingRule
+}
+
+// NewAccessLogger returns an AccessLogger that passes sampled entries to log.
+func NewAccessLogger(log func(*AccessLogEntry)) *AccessLogger {
+	l := &AccessLogger{log: log}
+	l.rules.Store([]SamplingRule(nil))
+	return l
+}
+
+// SetSampling replaces the sampling rules. It is safe to call while serving requests.
+func (l *AccessLogger) SetSampling(rules ...SamplingRule) {
+	l.rules.Store(append([]SamplingRule(nil), rules...))
+}
+
+// Sampling returns a copy of the current sampling rules.
+func (l *AccessLogger) Sampling() []SamplingRule {
+	return append([]SamplingRule(nil), l.rules.Load().([]SamplingRule)...)
+}
+
+func (l *AccessLogger) sampled(route string, statusCode int) bool {
+	rules := l.rules.Load().([]SamplingRule)
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.match(route, statusCode/100) {
+			continue
+		}
+		if rule.Rate >= 1 {
+			return true
+		}
+		if rule.Rate <= 0 {
+			return false
+		}
+		return rand.Float64() < rule.Rate
+	}
+	return true
+}
+
+// Middleware logs requests served by the next handler.
+//
+// If the handler returns an error without writing a response, the middleware serves it
+// with the ErrorHandler of the route, so the logged status is the one sent to the client.
+func (l *AccessLogger) Middleware(next HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, req Request) error {
+		start := time.Now()
+		rw := NewResponseWriter(w)
+
+		err := next(rw, req)
+		served := serveError(rw, req, err)
+
+		statusCode := responseStatus(rw, served)
+		if l.sampled(req.Route(), statusCode) {
+			l.log(&AccessLogEntry{
+				Time:       start,
+				Method:     req.Method,
+				Route:      req.Route(),
+				RouteName:  req.RouteName(),
+				Path:       req.URL.Path,
+				Params:     req.Params,
+				StatusCode: statusCode,
+				Bytes:      rw.Written(),
+				Duration:   time.Since(start),
+				Err:        err,
+				ctx:        req.Context(),
+			})
+		}
+
+		return served
+	}
+}
@@ -0,0 +1,105 @@
+//go:build go1.21
+// +build go1.21
+
+package treemux
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// SlogAccessLogConfig configures the access logger returned by NewSlogAccessLogger.
+type SlogAccessLogConfig struct {
+	// Message is the log message. The default is "request".
+	Message string
+	// Level returns the level of the entry. The default logs 5xx responses
+	// with slog.LevelError and other responses with slog.LevelInfo.
+	Level func(entry *AccessLogEntry) slog.Level
+	// Filter is called with every attribute before it is logged, including the route
+	// params inside the "params" group. It can replace the attribute, e.g. to mask
+	// a param, or drop it by returning false.
+	Filter func(attr slog.Attr) (slog.Attr, bool)
+	// Sampling are the initial sampling rules. See AccessLogger.SetSampling.
+	Sampling []SamplingRule
+}
+
+// NewSlogAccessLogger returns an AccessLogger that logs the entries with the slog logger.
+// The entries are logged with the attributes "method", "route", "route_name",
+// "path", "params", "status", "bytes", "duration", and "error":
+//
+//	logger := treemux.NewSlogAccessLogger(slog.Default(), treemux.SlogAccessLogConfig{
+//		Filter: func(attr slog.Attr) (slog.Attr, bool) {
+//			return attr, attr.Key != "route_name"
+//		},
+//		Sampling: []treemux.SamplingRule{{StatusClass: 2, Rate: 0.1}},
+//	})
+//	router.Use(logger.Middleware)
+func NewSlogAccessLogger(logger *slog.Logger, cfg SlogAccessLogConfig) *AccessLogger {
+	if cfg.Message == "" {
+		cfg.Message = "request"
+	}
+	if cfg.Level == nil {
+		cfg.Level = slogAccessLevel
+	}
+
+	l := NewAccessLogger(func(entry *AccessLogEntry) {
+		level := cfg.Level(entry)
+		if !logger.Enabled(entry.ctx, level) {
+			return
+		}
+		logger.LogAttrs(entry.ctx, level, cfg.Message, slogAccessAttrs(entry, cfg.Filter)...)
+	})
+	l.SetSampling(cfg.Sampling...)
+	return l
+}
+
+func slogAccessLevel(entry *AccessLogEntry) slog.Level {
+	if entry.StatusCode >= http.StatusInternalServerError {
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+func slogAccessAttrs(
+	entry *AccessLogEntry, filter func(attr slog.Attr) (slog.Attr, bool),
+) []slog.Attr {
+	attrs := make([]slog.Attr, 0, 9)
+	add := func(attr slog.Attr) {
+		if filter != nil {
+			var ok bool
+			if attr, ok = filter(attr); !ok {
+				return
+			}
+		}
+		attrs = append(attrs, attr)
+	}
+
+	add(slog.String("method", entry.Method))
+	add(slog.String("route", entry.Route))
+	if entry.RouteName != "" {
+		add(slog.String("route_name", entry.RouteName))
+	}
+	add(slog.String("path", entry.Path))
+	if len(entry.Params) > 0 {
+		params := make([]slog.Attr, 0, len(entry.Params))
+		for i := range entry.Params {
+			param := entry.Params.At(i)
+			attr := slog.String(param.Name, param.Value)
+			if filter != nil {
+				var ok bool
+				if attr, ok = filter(attr); !ok {
+					continue
+				}
+			}
+			params = append(params, attr)
+		}
+		add(slog.Attr{Key: "params", Value: slog.GroupValue(params...)})
+	}
+	add(slog.Int("status", entry.StatusCode))
+	add(slog.Int64("bytes", entry.Bytes))
+	add(slog.Duration("duration", entry.Duration))
+	if entry.Err != nil {
+		add(slog.String("error", entry.Err.Error()))
+	}
+	return attrs
+}
@@ -0,0 +1,69 @@
+//go:build go1.21
+// +build go1.21
+
+package treemux
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSlogAccessLogger(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey || attr.Key == "duration" {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	})
+	logger := NewSlogAccessLogger(slog.New(handler), SlogAccessLogConfig{
+		Filter: func(attr slog.Attr) (slog.Attr, bool) {
+			if attr.Key == "token" {
+				return slog.String(attr.Key, "***"), true
+			}
+			return attr, attr.Key != "path"
+		},
+		Sampling: []SamplingRule{{Route: "/hot", Rate: 0}},
+	})
+
+	router := New()
+	router.ErrorHandler = func(w http.ResponseWriter, req Request, err error) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	router.Use(logger.Middleware)
+	router.GET("/users/:id/tokens/:token", func(w http.ResponseWriter, req Request) error {
+		_, err := w.Write([]byte("user"))
+		return err
+	}).Name("user-token")
+	router.GET("/hot", simpleHandler)
+	router.GET("/fail", func(w http.ResponseWriter, req Request) error {
+		return errors.New("failed")
+	})
+
+	for _, path := range []string{"/users/1/tokens/secret", "/hot", "/fail"} {
+		r, _ := newRequest("GET", path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	wanted := []string{
+		`level=INFO msg=request method=GET route=/users/:id/tokens/:token route_name=user-token ` +
+			`params.id=1 params.token=*** status=200 bytes=4`,
+		`level=ERROR msg=request method=GET route=/fail status=500 bytes=0 error=failed`,
+	}
+	if len(lines) != len(wanted) {
+		t.Fatalf("got lines %q", lines)
+	}
+	for i := range wanted {
+		if lines[i] != wanted[i] {
+			t.Errorf("line %d: got %q, wanted %q", i, lines[i], wanted[i])
+		}
+	}
+}
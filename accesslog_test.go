@@ -0,0 +1,72 @@
+package treemux
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestAccessLogger(t *testing.T) {
+	var entries []*AccessLogEntry
+	logger := NewAccessLogger(func(entry *AccessLogEntry) {
+		entries = append(entries, entry)
+	})
+
+	router := New()
+	router.ErrorHandler = func(w http.ResponseWriter, req Request, err error) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	router.Use(logger.Middleware)
+	router.GET("/users/:id", func(w http.ResponseWriter, req Request) error {
+		_, err := w.Write([]byte("user"))
+		return err
+	})
+	router.GET("/hot", func(w http.ResponseWriter, req Request) error {
+		return nil
+	})
+	router.GET("/fail", func(w http.ResponseWriter, req Request) error {
+		return errors.New("failed")
+	})
+
+	serveRoutes := func() {
+		entries = nil
+		for _, path := range []string{"/users/1", "/hot", "/fail"} {
+			r, _ := newRequest("GET", path, nil)
+			router.ServeHTTP(httptest.NewRecorder(), r)
+		}
+	}
+
+	serveRoutes()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, wanted 3", len(entries))
+	}
+	entry := entries[0]
+	if entry.Route != "/users/:id" || entry.Path != "/users/1" ||
+		entry.StatusCode != http.StatusOK || entry.Bytes != 4 ||
+		entry.Params.Text("id") != "1" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if entries[2].StatusCode != http.StatusServiceUnavailable || entries[2].Err == nil {
+		t.Errorf("unexpected error entry %+v", entries[2])
+	}
+
+	rules := []SamplingRule{
+		{StatusClass: 5, Rate: 1},
+		{Route: "/hot", StatusClass: 2, Rate: 0},
+	}
+	logger.SetSampling(rules...)
+	if got := logger.Sampling(); !reflect.DeepEqual(got, rules) {
+		t.Errorf("got rules %v, wanted %v", got, rules)
+	}
+
+	serveRoutes()
+	var routes []string
+	for _, entry := range entries {
+		routes = append(routes, entry.Route)
+	}
+	if wanted := []string{"/users/:id", "/fail"}; !reflect.DeepEqual(routes, wanted) {
+		t.Errorf("got routes %v, wanted %v", routes, wanted)
+	}
+}
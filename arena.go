@@ -0,0 +1,27 @@
+package treemux
+
+// nodeArena allocates tree nodes in slabs, so bulk registration of large tables makes
+// one allocation per slab instead of one per node and the nodes of a tree are stored
+// close together. A slab is freed when none of its nodes is referenced, e.g. after
+// TreeMux.Reload replaced the tree.
+type nodeArena struct {
+	slab []node
+	size int
+}
+
+func newNodeArena(size int) *nodeArena {
+	return &nodeArena{size: size}
+}
+
+// newNode returns a zero node. It allocates the node on the heap if a is nil.
+func (a *nodeArena) newNode() *node {
+	if a == nil {
+		return new(node)
+	}
+	if len(a.slab) == 0 {
+		a.slab = make([]node, a.size)
+	}
+	n := &a.slab[0]
+	a.slab = a.slab[1:]
+	return n
+}
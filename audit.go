@@ -0,0 +1,97 @@
+package treemux
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// MetaAudit is the route metadata key that controls whether requests to the route
+// are audited. The value must be a bool. By default only POST, PUT, PATCH, and DELETE
+// requests are audited.
+const MetaAudit = "audit"
+
+// AuditRecord describes an audited request.
+type AuditRecord struct {
+	Time      time.Time
+	Method    string
+	Route     string
+	Params    Params
+	Principal string
+	// StatusCode is the response status. It is 500 when the handler returned an error
+	// without writing a response.
+	StatusCode int
+	// Err is the error returned by the handler, if any.
+	Err error
+}
+
+// AuditSink receives audit records.
+type AuditSink interface {
+	Audit(ctx context.Context, rec *AuditRecord)
+}
+
+// AuditSinkFunc is an adapter to allow the use of ordinary functions as audit sinks.
+type AuditSinkFunc func(ctx context.Context, rec *AuditRecord)
+
+func (fn AuditSinkFunc) Audit(ctx context.Context, rec *AuditRecord) {
+	fn(ctx, rec)
+}
+
+// AuditConfig configures the Audit middleware.
+type AuditConfig struct {
+	Sink AuditSink
+	// Principal returns the identity of the user making the request, if any.
+	Principal func(req Request) string
+}
+
+// Audit returns a middleware that records mutating requests into the audit sink.
+// Use the MetaAudit route metadata to include or exclude individual routes:
+//
+//	router.Use(treemux.Audit(cfg))
+//	router.POST("/login", login).Meta(treemux.MetaAudit, false)
+//	router.GET("/secrets/:id", showSecret).Meta(treemux.MetaAudit, true)
+func Audit(cfg AuditConfig) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			if !audited(req) {
+				return next(w, req)
+			}
+
+			rec := &AuditRecord{
+				Time:   time.Now(),
+				Method: req.Method,
+				Route:  req.Route(),
+				Params: req.Params,
+			}
+			if cfg.Principal != nil {
+				rec.Principal = cfg.Principal(req)
+			}
+
+			rw := NewResponseWriter(w)
+			err := next(rw, req)
+			served := serveError(rw, req, err)
+
+			rec.StatusCode = responseStatus(rw, served)
+			rec.Err = err
+			cfg.Sink.Audit(req.Context(), rec)
+
+			return served
+		}
+	}
+}
+
+func audited(req Request) bool {
+	if audit, ok := req.RouteInfo().Bool(MetaAudit); ok {
+		return audit
+	}
+	return isMutatingMethod(req.Method)
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,88 @@
+package treemux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	var records []*AuditRecord
+	router := New()
+	router.Use(Audit(AuditConfig{
+		Sink: AuditSinkFunc(func(ctx context.Context, rec *AuditRecord) {
+			records = append(records, rec)
+		}),
+		Principal: func(req Request) string {
+			return req.Header.Get("X-User")
+		},
+	}))
+
+	router.GET("/users/:id", simpleHandler)
+	router.PUT("/users/:id", func(w http.ResponseWriter, req Request) error {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+	router.POST("/login", simpleHandler).Meta(MetaAudit, false)
+	router.GET("/secrets/:id", simpleHandler).Meta(MetaAudit, true)
+
+	for _, req := range []struct{ method, path string }{
+		{"GET", "/users/1"},
+		{"PUT", "/users/1"},
+		{"POST", "/login"},
+		{"GET", "/secrets/2"},
+	} {
+		r, _ := newRequest(req.method, req.path, nil)
+		r.Header.Set("X-User", "alice")
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	var got []string
+	for _, rec := range records {
+		got = append(got, rec.Method+" "+rec.Route)
+		if rec.Principal != "alice" {
+			t.Errorf("got principal %q, wanted alice", rec.Principal)
+		}
+	}
+	wanted := []string{"PUT /users/:id", "GET /secrets/:id"}
+	if !reflect.DeepEqual(got, wanted) {
+		t.Fatalf("got %v, wanted %v", got, wanted)
+	}
+	if records[0].StatusCode != http.StatusNoContent || records[0].Params.Text("id") != "1" {
+		t.Errorf("unexpected record %+v", records[0])
+	}
+}
+
+func TestAuditErrorStatus(t *testing.T) {
+	errConflict := errors.New("conflict")
+	var rec *AuditRecord
+	handled := 0
+	router := New()
+	router.ErrorHandler = func(w http.ResponseWriter, req Request, err error) {
+		handled++
+		w.WriteHeader(http.StatusConflict)
+	}
+	router.Use(NewAccessLogger(func(*AccessLogEntry) {}).Middleware)
+	router.Use(Audit(AuditConfig{
+		Sink: AuditSinkFunc(func(ctx context.Context, r *AuditRecord) {
+			rec = r
+		}),
+	}))
+	router.POST("/users", func(w http.ResponseWriter, req Request) error {
+		return errConflict
+	})
+
+	r, _ := newRequest("POST", "/users", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusConflict || handled != 1 {
+		t.Fatalf("got %d after %d ErrorHandler calls", w.Code, handled)
+	}
+	if rec == nil || rec.StatusCode != http.StatusConflict || rec.Err != errConflict {
+		t.Errorf("unexpected record %+v", rec)
+	}
+}
@@ -0,0 +1,86 @@
+package treemux
+
+import "fmt"
+
+// Batch collects routes and adds them to the router at once with Commit. Loading
+// large generated or config-driven tables with a batch is faster than calling Handle
+// for every route: the router is locked once, and the static children of the tree
+// nodes are sorted by priority once per commit instead of once per route.
+//
+//	batch := router.BeginBatch()
+//	for _, r := range config.Routes {
+//		batch.AddRoute(r.Method, r.Path, handlers[r.Handler])
+//	}
+//	if err := batch.Commit(); err != nil {
+//		log.Fatal(err)
+//	}
+type Batch struct {
+	group  *Group
+	routes []batchRoute
+}
+
+type batchRoute struct {
+	method      string
+	path        string
+	handler     HandlerFunc
+	middlewares []MiddlewareFunc
+}
+
+// BeginBatch returns a batch that adds routes to the group.
+func (g *Group) BeginBatch() *Batch {
+	return &Batch{group: g}
+}
+
+// AddRoute adds the route to the batch. It is validated and added to the router
+// by Commit.
+func (b *Batch) AddRoute(method, path string, handler HandlerFunc, middlewares ...MiddlewareFunc) {
+	b.routes = append(b.routes, batchRoute{
+		method:      method,
+		path:        path,
+		handler:     handler,
+		middlewares: middlewares,
+	})
+}
+
+// Len returns the number of routes added since the last commit.
+func (b *Batch) Len() int {
+	return len(b.routes)
+}
+
+// Commit adds the routes of the batch to the router in order and empties the batch.
+// Requests are blocked until it returns. If a route is invalid, e.g. because
+// it conflicts with another route, Commit returns the error; the routes before it
+// stay registered and the routes after it are discarded.
+func (b *Batch) Commit() (err error) {
+	g := b.group
+	g.mux.mutex.Lock()
+	defer g.mux.mutex.Unlock()
+
+	routes := b.routes
+	b.routes = nil
+
+	g.mux.batching = true
+	defer func() {
+		g.mux.batching = false
+		for _, root := range g.mux.trees() {
+			root.sortTree()
+		}
+	}()
+
+	var r *batchRoute
+	defer func() {
+		if v := recover(); v != nil {
+			if e, ok := v.(error); ok {
+				err = fmt.Errorf("treemux: %s %s: %w", r.method, r.path, e)
+			} else {
+				err = fmt.Errorf("treemux: %s %s: %v", r.method, r.path, v)
+			}
+		}
+	}()
+
+	for i := range routes {
+		r = &routes[i]
+		g.handle(r.method, r.path, r.handler, r.middlewares)
+	}
+	return nil
+}
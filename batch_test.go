@@ -0,0 +1,140 @@
+package treemux
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	router := New()
+	router.GET("/users/:id", simpleHandler)
+
+	batch := router.NewGroup("/api").BeginBatch()
+	for _, path := range []string{"/b/:id", "/a", "/b/edit", "/b/:id/posts", "/*path"} {
+		batch.AddRoute("GET", path, simpleHandler)
+	}
+	batch.AddRoute("GET", "/b/edit", simpleHandler)
+	batch.AddRoute("GET", "/c", simpleHandler)
+	if batch.Len() != 7 {
+		t.Fatalf("got %d routes in the batch", batch.Len())
+	}
+
+	r, _ := newRequest("GET", "/api/a", nil)
+	if _, found := router.Lookup(nil, r); found {
+		t.Fatal("route was added before Commit")
+	}
+
+	err := batch.Commit()
+	if err == nil || !strings.Contains(err.Error(), "GET /b/edit") {
+		t.Fatalf("got error %v, wanted a conflict for GET /b/edit", err)
+	}
+	if batch.Len() != 0 {
+		t.Error("Commit did not empty the batch")
+	}
+
+	tests := []struct {
+		path  string
+		route string
+	}{
+		{"/users/1", "/users/:id"},
+		{"/api/a", "/api/a"},
+		{"/api/b/1", "/api/b/:id"},
+		{"/api/b/edit", "/api/b/edit"},
+		{"/api/b/1/posts", "/api/b/:id/posts"},
+		{"/api/x/y", "/api/*path"},
+		{"/api/c", "/api/*path"},
+	}
+	for _, test := range tests {
+		r, _ := newRequest("GET", test.path, nil)
+		lr, found := router.Lookup(nil, r)
+		if !found || lr.RouteInfo().Route != test.route {
+			t.Errorf("%s: got %q, wanted %q", test.path, lookupRoute(lr), test.route)
+		}
+	}
+}
+
+func TestBatchSortsTree(t *testing.T) {
+	paths := []string{"/a", "/b/1", "/b/2", "/c", "/b/3", "/c/1", "/d"}
+
+	router := New()
+	for _, path := range paths {
+		router.GET(path, simpleHandler)
+	}
+
+	batched := New()
+	batch := batched.BeginBatch()
+	for _, path := range paths {
+		batch.AddRoute("GET", path, simpleHandler)
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(batched.Snapshot(), router.Snapshot()) {
+		t.Errorf("got tree\n%s\nwanted\n%s", batched.Dump(), router.Dump())
+	}
+}
+
+func BenchmarkBatch(b *testing.B) {
+	paths := make([]string, 10000)
+	for i := range paths {
+		paths[i] = "/api/v1/resource" + strconv.Itoa(i) + "/:id"
+	}
+
+	// The router serves requests while the routes are added, so every Handle call
+	// waits for the in-flight lookups to release the lock.
+	serving := func(b *testing.B, load func(router *TreeMux)) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			router := New()
+			router.SafeAddRoutesWhileRunning = true
+			router.GET("/", simpleHandler)
+
+			done := make(chan struct{})
+			var wg sync.WaitGroup
+			for j := 0; j < 4; j++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					r, _ := newRequest("GET", "/", nil)
+					w := new(mockResponseWriter)
+					for {
+						select {
+						case <-done:
+							return
+						default:
+							router.ServeHTTP(w, r)
+						}
+					}
+				}()
+			}
+
+			load(router)
+			close(done)
+			wg.Wait()
+		}
+	}
+
+	b.Run("handle", func(b *testing.B) {
+		serving(b, func(router *TreeMux) {
+			for _, path := range paths {
+				router.GET(path, dummyHandler)
+			}
+		})
+	})
+	b.Run("batch", func(b *testing.B) {
+		serving(b, func(router *TreeMux) {
+			batch := router.BeginBatch()
+			for _, path := range paths {
+				batch.AddRoute(http.MethodGet, path, dummyHandler)
+			}
+			if err := batch.Commit(); err != nil {
+				b.Fatal(err)
+			}
+		})
+	})
+}
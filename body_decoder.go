@@ -0,0 +1,164 @@
+package treemux
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// BodyDecoder decodes a request body into dst.
+type BodyDecoder func(r io.Reader, dst interface{}) error
+
+// UnsupportedMediaTypeError is returned by Request.Decode when there is no decoder
+// for the request content type.
+type UnsupportedMediaTypeError struct {
+	ContentType string
+}
+
+func (e *UnsupportedMediaTypeError) Error() string {
+	return fmt.Sprintf("treemux: unsupported media type %q", e.ContentType)
+}
+
+// StatusCode returns 415 Unsupported Media Type.
+func (e *UnsupportedMediaTypeError) StatusCode() int {
+	return http.StatusUnsupportedMediaType
+}
+
+var bodyDecoders = struct {
+	sync.RWMutex
+	m map[string]BodyDecoder
+}{
+	m: map[string]BodyDecoder{
+		"application/json":                  decodeJSON,
+		"application/xml":                   decodeXML,
+		"text/xml":                          decodeXML,
+		"application/x-www-form-urlencoded": decodeForm,
+	},
+}
+
+// RegisterBodyDecoder registers the decoder for the content type, e.g. "application/msgpack",
+// replacing the existing decoder if any. Decoders are shared by the whole application.
+func RegisterBodyDecoder(contentType string, dec BodyDecoder) {
+	bodyDecoders.Lock()
+	bodyDecoders.m[strings.ToLower(contentType)] = dec
+	bodyDecoders.Unlock()
+}
+
+func bodyDecoder(contentType string) BodyDecoder {
+	bodyDecoders.RLock()
+	defer bodyDecoders.RUnlock()
+
+	if dec, ok := bodyDecoders.m[contentType]; ok {
+		return dec
+	}
+	// Structured syntax suffixes, e.g. application/problem+json.
+	if i := strings.LastIndexByte(contentType, '+'); i >= 0 {
+		if dec, ok := bodyDecoders.m["application/"+contentType[i+1:]]; ok {
+			return dec
+		}
+	}
+	return nil
+}
+
+// Decode decodes the request body into dst using the decoder registered for the request
+// content type. JSON, XML, and URL-encoded forms are supported out of the box and other
+// formats can be added with RegisterBodyDecoder. Requests without a content type are
+// decoded as JSON.
+func (req Request) Decode(dst interface{}) error {
+	contentType := "application/json"
+	if s := req.Header.Get("Content-Type"); s != "" {
+		mediaType, _, err := mime.ParseMediaType(s)
+		if err != nil {
+			return &UnsupportedMediaTypeError{ContentType: s}
+		}
+		contentType = mediaType
+	}
+
+	dec := bodyDecoder(contentType)
+	if dec == nil {
+		return &UnsupportedMediaTypeError{ContentType: contentType}
+	}
+	if req.Body == nil {
+		return io.EOF
+	}
+	return dec(req.Body, dst)
+}
+
+func decodeJSON(r io.Reader, dst interface{}) error {
+	return json.NewDecoder(r).Decode(dst)
+}
+
+func decodeXML(r io.Reader, dst interface{}) error {
+	return xml.NewDecoder(r).Decode(dst)
+}
+
+// decodeForm decodes URL-encoded forms into *url.Values, *map[string]string, or
+// structs with fields tagged with `form:"name"`.
+func decodeForm(r io.Reader, dst interface{}) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	values, err := url.ParseQuery(string(b))
+	if err != nil {
+		return err
+	}
+
+	switch dst := dst.(type) {
+	case *url.Values:
+		*dst = values
+		return nil
+	case *map[string]string:
+		m := make(map[string]string, len(values))
+		for k := range values {
+			m[k] = values.Get(k)
+		}
+		*dst = m
+		return nil
+	}
+
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errors.New("treemux: form can only be decoded into a struct pointer or url.Values")
+	}
+	v = v.Elem()
+
+	st := v.Type()
+	for i := 0; i < st.NumField(); i++ {
+		name := st.Field(i).Tag.Get("form")
+		if name == "" || name == "-" {
+			continue
+		}
+		vals, ok := values[name]
+		if !ok {
+			continue
+		}
+		if err := decodeFormField(name, vals, v.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeFormField(name string, vals []string, v reflect.Value) error {
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() == reflect.Uint8 {
+		return decodeParam(nil, name, vals[0], v)
+	}
+	slice := reflect.MakeSlice(v.Type(), len(vals), len(vals))
+	for i, s := range vals {
+		if err := decodeParam(nil, name, s, slice.Index(i)); err != nil {
+			return err
+		}
+	}
+	v.Set(slice)
+	return nil
+}
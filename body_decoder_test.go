@@ -0,0 +1,80 @@
+package treemux
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type decodeTestUser struct {
+	Name string   `json:"name" xml:"name" form:"name"`
+	Age  int      `json:"age" xml:"age" form:"age"`
+	Tags []string `json:"tags" xml:"tag" form:"tag"`
+}
+
+func TestRequestDecode(t *testing.T) {
+	RegisterBodyDecoder("text/x-test", func(r io.Reader, dst interface{}) error {
+		line, _ := bufio.NewReader(r).ReadString('\n')
+		dst.(*decodeTestUser).Name = strings.TrimSpace(line)
+		return nil
+	})
+
+	var user decodeTestUser
+	var decodeErr error
+	router := New()
+	router.POST("/users", func(w http.ResponseWriter, req Request) error {
+		user = decodeTestUser{}
+		decodeErr = req.Decode(&user)
+		return nil
+	})
+
+	tests := []struct {
+		contentType string
+		body        string
+		wanted      decodeTestUser
+	}{
+		{"application/json", `{"name":"bob","age":30,"tags":["a"]}`, decodeTestUser{"bob", 30, []string{"a"}}},
+		{"", `{"name":"bob"}`, decodeTestUser{Name: "bob"}},
+		{"application/vnd.api+json; charset=utf-8", `{"age":1}`, decodeTestUser{Age: 1}},
+		{"application/xml", `<user><name>bob</name><age>30</age><tag>a</tag><tag>b</tag></user>`,
+			decodeTestUser{"bob", 30, []string{"a", "b"}}},
+		{"application/x-www-form-urlencoded", `name=bob&age=30&tag=a&tag=b`,
+			decodeTestUser{"bob", 30, []string{"a", "b"}}},
+		{"text/x-test", "bob\nignored", decodeTestUser{Name: "bob"}},
+	}
+	for _, test := range tests {
+		r, _ := newRequest("POST", "/users", strings.NewReader(test.body))
+		if test.contentType != "" {
+			r.Header.Set("Content-Type", test.contentType)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), r)
+		if decodeErr != nil {
+			t.Errorf("%s: %v", test.contentType, decodeErr)
+			continue
+		}
+		if user.Name != test.wanted.Name || user.Age != test.wanted.Age ||
+			strings.Join(user.Tags, ",") != strings.Join(test.wanted.Tags, ",") {
+			t.Errorf("%s: got %+v, wanted %+v", test.contentType, user, test.wanted)
+		}
+	}
+
+	r, _ := newRequest("POST", "/users", strings.NewReader("x"))
+	r.Header.Set("Content-Type", "application/unknown")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+	var mediaErr *UnsupportedMediaTypeError
+	if !errors.As(decodeErr, &mediaErr) || mediaErr.StatusCode() != http.StatusUnsupportedMediaType {
+		t.Errorf("got %v", decodeErr)
+	}
+
+	r, _ = newRequest("POST", "/users", strings.NewReader("age=old"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+	var paramErr *ParamError
+	if !errors.As(decodeErr, &paramErr) || paramErr.Param != "age" {
+		t.Errorf("got %v", decodeErr)
+	}
+}
@@ -0,0 +1,132 @@
+package treemux
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MetaCacheKey is the route metadata key that declares how CDNs should cache the route.
+// The value must be a CacheKey.
+const MetaCacheKey = "cache_key"
+
+// CacheKeyHeader is the response header that carries the cache key built for the request.
+const CacheKeyHeader = "X-Cache-Key"
+
+// CacheKey declares the parts of a request that identify a cached response.
+//
+//	router.GET("/users/:id/posts", listPosts).Meta(treemux.MetaCacheKey, treemux.CacheKey{
+//		Query:         []string{"page"},
+//		Headers:       []string{"Accept-Language"},
+//		SurrogateKeys: []string{"user-{id}", "posts"},
+//	})
+type CacheKey struct {
+	// Params are the names of the route params included in the key.
+	// Nil means all params.
+	Params []string
+	// Query are the names of the query arguments included in the key.
+	Query []string
+	// Headers are the names of the request headers included in the key.
+	// They are also added to the Vary response header.
+	Headers []string
+	// SurrogateKeys are templates of the surrogate keys used to purge the cached responses.
+	// Route params are substituted using the "{name}" syntax, e.g. "user-{id}". Whitespace,
+	// control, non-ASCII, comma, and percent characters of the values are percent-encoded,
+	// so a value can't split a key.
+	SurrogateKeys []string
+	// Template replaces the method, route, and Params parts of the key with a template
+	// resolved by Request.ExpandTemplate, e.g. "tenant={tenant}:{req.method}".
+	// The Query and Headers values are still appended to the key, so responses that
+	// vary by the headers are never cached under the same key.
+	Template string
+}
+
+// Build returns the cache key for the request, e.g. "GET /users/:id/posts id=1&q.page=2".
+// If the Template is set, the key starts with the expanded template instead, e.g.
+// "tenant=acme:GET h.accept-language=en".
+func (k *CacheKey) Build(req Request) string {
+	values := make(url.Values)
+	switch {
+	case k.Template != "":
+		// The template references the params.
+	case k.Params == nil:
+		for _, param := range req.Params {
+			values.Set(param.Name, param.Value)
+		}
+	default:
+		for _, name := range k.Params {
+			values.Set(name, req.Params.Text(name))
+		}
+	}
+
+	if len(k.Query) > 0 {
+		query := req.URL.Query()
+		for _, name := range k.Query {
+			values["q."+name] = query[name]
+		}
+	}
+	for _, name := range k.Headers {
+		values["h."+strings.ToLower(name)] = req.Header.Values(name)
+	}
+
+	var key string
+	if k.Template != "" {
+		key = req.ExpandTemplate(k.Template)
+	} else {
+		key = req.Method + " " + req.Route()
+	}
+	if len(values) > 0 {
+		key += " " + values.Encode()
+	}
+	return key
+}
+
+// Surrogates returns the surrogate keys for the request.
+func (k *CacheKey) Surrogates(req Request) []string {
+	return expandKeys(k.SurrogateKeys, req.Params)
+}
+
+// SurrogateKeyConfig configures the SurrogateKeys middleware.
+type SurrogateKeyConfig struct {
+	// Header is the response header that lists the surrogate keys.
+	// The default is "Surrogate-Key" used by Fastly; Cloudflare uses "Cache-Tag".
+	Header string
+	// Separator separates the surrogate keys. The default is a space.
+	Separator string
+	// CacheKey enables the X-Cache-Key response header.
+	CacheKey bool
+}
+
+// SurrogateKeys returns a middleware that emits the surrogate keys and the Vary header
+// declared with the MetaCacheKey route metadata, so CDNs in front of the router can be
+// configured from the route table.
+func SurrogateKeys(cfg SurrogateKeyConfig) MiddlewareFunc {
+	if cfg.Header == "" {
+		cfg.Header = "Surrogate-Key"
+	}
+	if cfg.Separator == "" {
+		cfg.Separator = " "
+	}
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			v, _ := req.RouteInfo().Value(MetaCacheKey)
+			key, ok := v.(CacheKey)
+			if !ok {
+				return next(w, req)
+			}
+
+			h := w.Header()
+			if keys := key.Surrogates(req); len(keys) > 0 {
+				h.Set(cfg.Header, strings.Join(keys, cfg.Separator))
+			}
+			for _, name := range key.Headers {
+				h.Add("Vary", name)
+			}
+			if cfg.CacheKey {
+				h.Set(CacheKeyHeader, key.Build(req))
+			}
+
+			return next(w, req)
+		}
+	}
+}
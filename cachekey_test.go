@@ -0,0 +1,74 @@
+package treemux
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSurrogateKeys(t *testing.T) {
+	router := New()
+	router.Use(SurrogateKeys(SurrogateKeyConfig{CacheKey: true}))
+	router.GET("/users/:id/posts/:post", simpleHandler).Meta(MetaCacheKey, CacheKey{
+		Params:        []string{"id"},
+		Query:         []string{"page"},
+		Headers:       []string{"Accept-Language"},
+		SurrogateKeys: []string{"user-{id}", "post-{id}-{post}", "posts"},
+	})
+	router.GET("/plain", simpleHandler)
+
+	r, _ := newRequest("GET", "/users/1/posts/2?page=3&utm=x", nil)
+	r.Header.Set("Accept-Language", "en")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	if got := w.Header().Get("Surrogate-Key"); got != "user-1 post-1-2 posts" {
+		t.Errorf("got Surrogate-Key %q", got)
+	}
+	if got := w.Header().Get("Vary"); got != "Accept-Language" {
+		t.Errorf("got Vary %q", got)
+	}
+	wanted := "GET /users/:id/posts/:post h.accept-language=en&id=1&q.page=3"
+	if got := w.Header().Get(CacheKeyHeader); got != wanted {
+		t.Errorf("got cache key %q, wanted %q", got, wanted)
+	}
+
+	r, _ = newRequest("GET", "/plain", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if len(w.Header()) != 0 {
+		t.Errorf("unexpected headers %v", w.Header())
+	}
+}
+
+func TestCacheKeyTemplate(t *testing.T) {
+	router := New()
+	router.Use(SurrogateKeys(SurrogateKeyConfig{CacheKey: true}))
+	router.GET("/:tenant/posts", simpleHandler).Meta(MetaCacheKey, CacheKey{
+		Query:    []string{"page"},
+		Headers:  []string{"Accept-Language"},
+		Template: "tenant={tenant}:{req.method}",
+	})
+
+	r, _ := newRequest("GET", "/acme/posts?page=2", nil)
+	r.Header.Set("Accept-Language", "en")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	if got := w.Header().Get(CacheKeyHeader); got != "tenant=acme:GET h.accept-language=en&q.page=2" {
+		t.Errorf("got cache key %q", got)
+	}
+	if got := w.Header().Get("Vary"); got != "Accept-Language" {
+		t.Errorf("got Vary %q", got)
+	}
+}
+
+func TestExpandKeys(t *testing.T) {
+	params := Params{{"id", "1"}, {"name", "a b,c%\n\x7fé"}}
+	keys := expandKeys([]string{"user-{id}", "{missing}x", "open{", "all", "tag-{name}"}, params)
+	wanted := []string{"user-1", "x", "open{", "all", "tag-a%20b%2Cc%25%0A%7F%C3%A9"}
+	for i := range wanted {
+		if keys[i] != wanted[i] {
+			t.Errorf("key %d: got %q, wanted %q", i, keys[i], wanted[i])
+		}
+	}
+}
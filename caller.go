@@ -0,0 +1,61 @@
+package treemux
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// registrationCaller returns the file:line of the code that registered a route,
+// skipping the Group and TreeMux methods.
+func registrationCaller() string {
+	var pcs [16]uintptr
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !isRouterMethod(frame.Function) {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+var routerMethodPrefixes = []string{
+	"(*Group).",
+	"(*LockedGroup).",
+	"(*TreeMux).",
+	"registrationCaller",
+}
+
+func isRouterMethod(fn string) bool {
+	const pkg = "github.com/vmihailenco/treemux."
+	if !strings.HasPrefix(fn, pkg) {
+		return false
+	}
+	fn = fn[len(pkg):]
+	for _, prefix := range routerMethodPrefixes {
+		if strings.HasPrefix(fn, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// registeredAt describes where the route was registered, if known.
+func registeredAt(info *RouteInfo) string {
+	if info == nil || info.Caller == "" {
+		return ""
+	}
+	return " (registered at " + info.Caller + ")"
+}
+
+// annotateConflict adds the registration site to the registration panics.
+func annotateConflict(info *RouteInfo) {
+	if r := recover(); r != nil {
+		panic(fmt.Sprintf("%v%s", r, registeredAt(info)))
+	}
+}
@@ -0,0 +1,35 @@
+package treemux
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRecordCallers(t *testing.T) {
+	router := New()
+	router.RecordCallers = true
+
+	route := router.GET("/users/:id", simpleHandler)
+	if caller := route.Info().Caller; !strings.Contains(caller, "caller_test.go:") {
+		t.Fatalf("got caller %q", caller)
+	}
+
+	api := router.NewGroup("/api")
+	api.GET("/users", simpleHandler)
+
+	defer func() {
+		msg := fmt.Sprint(recover())
+		if !strings.Contains(msg, "already handles GET") || strings.Count(msg, "caller_test.go:") != 2 {
+			t.Errorf("got panic %q", msg)
+		}
+	}()
+	api.GET("/users", simpleHandler)
+}
+
+func TestRecordCallersDisabled(t *testing.T) {
+	router := New()
+	if caller := router.GET("/", simpleHandler).Info().Caller; caller != "" {
+		t.Errorf("got caller %q", caller)
+	}
+}
@@ -0,0 +1,113 @@
+package treemux
+
+import (
+	"context"
+	"net/http"
+)
+
+// Exchange is a recorded request and response pair.
+type Exchange struct {
+	Method     string      `json:"method"`
+	Route      string      `json:"route"`
+	RequestURI string      `json:"request_uri"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+
+	StatusCode     int         `json:"status_code"`
+	ResponseHeader http.Header `json:"response_header,omitempty"`
+	ResponseBody   []byte      `json:"response_body,omitempty"`
+}
+
+// CaptureStore saves recorded exchanges.
+type CaptureStore interface {
+	Save(ctx context.Context, ex *Exchange) error
+}
+
+// CaptureStoreFunc is an adapter to allow the use of ordinary functions as capture stores.
+type CaptureStoreFunc func(ctx context.Context, ex *Exchange) error
+
+func (fn CaptureStoreFunc) Save(ctx context.Context, ex *Exchange) error {
+	return fn(ctx, ex)
+}
+
+// CaptureConfig configures the Capture middleware.
+type CaptureConfig struct {
+	Store CaptureStore
+	// MaxBodySize limits the number of request and response body bytes that are recorded.
+	// Exchanges with larger bodies are not recorded.
+	MaxBodySize int64
+	// Sanitize is called before the exchange is saved and can be used to remove sensitive
+	// data. Returning false drops the exchange. Authorization and cookie headers
+	// are always removed.
+	Sanitize func(ex *Exchange) bool
+	// OnError is called when the store fails to save an exchange.
+	OnError func(err error)
+}
+
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Capture returns a middleware that records request and response pairs into the store.
+// The recorded exchanges can be replayed against the router in tests using
+// treemuxtest.Replay, which makes it possible to build regression suites
+// from production traffic.
+func Capture(cfg CaptureConfig) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			body, ok := bufferBody(&req, cfg.MaxBodySize)
+			if !ok {
+				return next(w, req)
+			}
+
+			cw := &captureWriter{ResponseWriter: NewResponseWriter(w), limit: cfg.MaxBodySize}
+			err := next(cw, req)
+			if err != nil || cw.truncated {
+				return err
+			}
+
+			ex := &Exchange{
+				Method:         req.Method,
+				Route:          req.Route(),
+				RequestURI:     req.RequestURI,
+				Header:         req.Header.Clone(),
+				Body:           body,
+				StatusCode:     responseStatus(cw.ResponseWriter, nil),
+				ResponseHeader: w.Header().Clone(),
+				ResponseBody:   cw.body,
+			}
+			if ex.RequestURI == "" {
+				ex.RequestURI = req.URL.RequestURI()
+			}
+			for _, name := range sensitiveHeaders {
+				ex.Header.Del(name)
+				ex.ResponseHeader.Del(name)
+			}
+			if cfg.Sanitize != nil && !cfg.Sanitize(ex) {
+				return nil
+			}
+
+			if err := cfg.Store.Save(req.Context(), ex); err != nil && cfg.OnError != nil {
+				cfg.OnError(err)
+			}
+			return nil
+		}
+	}
+}
+
+type captureWriter struct {
+	*ResponseWriter
+	limit     int64
+	body      []byte
+	truncated bool
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	if !w.truncated {
+		if int64(len(w.body)+len(b)) > w.limit {
+			w.truncated = true
+			w.body = nil
+		} else {
+			w.body = append(w.body, b...)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,53 @@
+package treemux
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCapture(t *testing.T) {
+	var exchanges []*Exchange
+	router := New()
+	router.Use(Capture(CaptureConfig{
+		Store: CaptureStoreFunc(func(ctx context.Context, ex *Exchange) error {
+			exchanges = append(exchanges, ex)
+			return nil
+		}),
+		MaxBodySize: 32,
+		Sanitize: func(ex *Exchange) bool {
+			return ex.Route != "/private"
+		},
+	}))
+	router.POST("/echo/:id", func(w http.ResponseWriter, req Request) error {
+		b, _ := ioutil.ReadAll(req.Body)
+		w.WriteHeader(http.StatusCreated)
+		_, err := w.Write(append([]byte(req.Param("id")+":"), b...))
+		return err
+	})
+	router.POST("/private", simpleHandler)
+
+	for _, path := range []string{"/echo/1?x=y", "/private"} {
+		r, _ := newRequest("POST", path, strings.NewReader("hello"))
+		r.Header.Set("Authorization", "secret")
+		r.Header.Set("X-Test", "1")
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	if len(exchanges) != 1 {
+		t.Fatalf("got %d exchanges, wanted 1", len(exchanges))
+	}
+	ex := exchanges[0]
+	if ex.Route != "/echo/:id" || ex.RequestURI != "/echo/1?x=y" || string(ex.Body) != "hello" {
+		t.Errorf("unexpected request %+v", ex)
+	}
+	if ex.Header.Get("Authorization") != "" || ex.Header.Get("X-Test") != "1" {
+		t.Errorf("headers were not sanitized: %v", ex.Header)
+	}
+	if ex.StatusCode != http.StatusCreated || string(ex.ResponseBody) != "1:hello" {
+		t.Errorf("unexpected response %d %q", ex.StatusCode, ex.ResponseBody)
+	}
+}
@@ -0,0 +1,144 @@
+package treemux
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// foldString returns the case-folded form of s used by TreeMux.CaseInsensitive.
+// Unlike ASCII lower casing, it folds all Unicode letters, so "STRASSE" and "strasse",
+// or "ΣΟΦΙΑ" and "σοφια", compare equal. Folding maps every letter to the lower case
+// of its upper case form, which also merges special forms like the final sigma or
+// the Kelvin sign with their common counterparts.
+func foldString(s string) string {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= utf8.RuneSelf || 'A' <= c && c <= 'Z' {
+			return strings.Map(foldRune, s)
+		}
+	}
+	return s
+}
+
+func foldRune(r rune) rune {
+	return unicode.ToLower(unicode.ToUpper(r))
+}
+
+// foldPath folds the path as foldString does, but folds the characters of
+// percent-encoded sequences as well, since clients send non-ASCII characters encoded.
+// Folded characters are encoded again using upper case hex digits, so "/%ce%91" and
+// "/%CE%B1" both become "/%CE%B1".
+func foldPath(path string) string {
+	if strings.IndexByte(path, '%') == -1 {
+		return foldString(path)
+	}
+
+	var b strings.Builder
+	b.Grow(len(path))
+	var buf [utf8.UTFMax]byte
+	for i := 0; i < len(path); {
+		// Decode the next rune, which can mix literal and encoded bytes.
+		n, j, encoded := 0, i, false
+		for n < len(buf) && j < len(path) {
+			c := path[j]
+			if c == '%' && j+2 < len(path) && ishex(path[j+1]) && ishex(path[j+2]) {
+				c = unhex(path[j+1])<<4 | unhex(path[j+2])
+				j += 3
+				encoded = true
+			} else {
+				j++
+			}
+			buf[n] = c
+			n++
+			if utf8.FullRune(buf[:n]) {
+				break
+			}
+		}
+
+		r, size := utf8.DecodeRune(buf[:n])
+		if r == utf8.RuneError && size <= 1 {
+			// Invalid UTF-8: copy the first byte as is and retry from the next one.
+			if path[i] == '%' && i+2 < len(path) && ishex(path[i+1]) && ishex(path[i+2]) {
+				writeEscaped(&b, buf[0])
+				i += 3
+			} else {
+				b.WriteByte(path[i])
+				i++
+			}
+			continue
+		}
+		i = j
+
+		r = foldRune(r)
+		if !encoded {
+			b.WriteRune(r)
+			continue
+		}
+		n = utf8.EncodeRune(buf[:], r)
+		for _, c := range buf[:n] {
+			writeEscaped(&b, c)
+		}
+	}
+	return b.String()
+}
+
+// foldRoute folds the static segments of the route template.
+func foldRoute(route string) string {
+	segments := strings.Split(route, "/")
+	for i, seg := range segments {
+		if seg == "" || seg[0] == ':' || seg[0] == '*' {
+			continue
+		}
+		segments[i] = foldPath(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+// caseParams returns the param values for the route matched by the folded path.
+// Since the search ran on the folded path, the values are taken from the original
+// path and folded only if fold is set.
+func caseParams(route, path string, params Params, fold bool) Params {
+	if len(params) == 0 {
+		return params
+	}
+
+	routeSegments := strings.Split(route, "/")
+	pathSegments := strings.Split(path, "/")
+
+	values := make(map[string][]string, len(params))
+	for i, seg := range routeSegments {
+		if seg == "" || i >= len(pathSegments) {
+			continue
+		}
+		var value string
+		switch seg[0] {
+		case ':':
+			value = pathSegments[i]
+		case '*':
+			value = strings.Join(pathSegments[i:], "/")
+		default:
+			continue
+		}
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			value = unescaped
+		}
+		if fold {
+			value = foldString(value)
+		}
+		values[seg[1:]] = append(values[seg[1:]], value)
+	}
+
+	// Params are stored from the last path segment to the first.
+	out := make(Params, len(params))
+	for i := len(params) - 1; i >= 0; i-- {
+		p := params[i]
+		if vs := values[p.Name]; len(vs) > 0 {
+			p.Value = vs[0]
+			values[p.Name] = vs[1:]
+		}
+		out[i] = p
+	}
+	return out
+}
@@ -0,0 +1,117 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFoldString(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"users", "users"},
+		{"Users", "users"},
+		{"ÉMILE", "émile"},
+		{"ΣΟΦΙΑΣ", "σοφιασ"},
+		{"σοφιας", "σοφιασ"},
+		{"K", "k"}, // Kelvin sign
+	}
+	for _, test := range tests {
+		if got := foldString(test.s); got != test.want {
+			t.Errorf("foldString(%q) = %q, wanted %q", test.s, got, test.want)
+		}
+	}
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	var route, name, path string
+	handler := func(w http.ResponseWriter, req Request) error {
+		route = req.Route()
+		name = req.Param("name")
+		path = req.Param("path")
+		return nil
+	}
+
+	for _, fold := range []bool{false, true} {
+		router := New()
+		router.CaseInsensitive = true
+		router.FoldParams = fold
+		router.GET("/Users/:name/Files/*path", handler)
+		router.GET("/ΑΡΧΕΙΑ/:name", handler)
+
+		tests := []struct {
+			path  string
+			route string
+			name  string
+			file  string
+		}{
+			{"/users/Émile/files/A/B", "/users/:name/files/*path", "Émile", "A/B"},
+			{"/USERS/%C3%89mile/FILES/x", "/users/:name/files/*path", "Émile", "x"},
+			{"/αρχεια/ΣΟΦΙΑ", "/αρχεια/:name", "ΣΟΦΙΑ", ""},
+		}
+		for _, test := range tests {
+			route, name, path = "", "", ""
+			r, _ := newRequest("GET", test.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			wantName, wantFile := test.name, test.file
+			if fold {
+				wantName, wantFile = foldString(wantName), foldString(wantFile)
+			}
+			if w.Code != http.StatusOK || route != test.route || name != wantName || path != wantFile {
+				t.Errorf("fold %v %s: got code %d, route %q, name %q, path %q",
+					fold, test.path, w.Code, route, name, path)
+			}
+		}
+	}
+}
+
+func TestFoldPath(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"/Users", "/users"},
+		{"/%CE%91%CE%A1", "/%CE%B1%CF%81"},
+		{"/%ce%b1%cf%81", "/%CE%B1%CF%81"},
+		{"/a%2Fb", "/a%2Fb"},
+		{"/%C3%89mile", "/%C3%A9mile"},
+		{"/%ff%zz", "/%FF%zz"},
+	}
+	for _, test := range tests {
+		if got := foldPath(test.s); got != test.want {
+			t.Errorf("foldPath(%q) = %q, wanted %q", test.s, got, test.want)
+		}
+	}
+}
+
+func TestCaseInsensitiveEncoded(t *testing.T) {
+	var name string
+	handler := func(w http.ResponseWriter, req Request) error {
+		name = req.Param("name")
+		return nil
+	}
+
+	for _, canonical := range []bool{false, true} {
+		router := New()
+		router.CaseInsensitive = true
+		router.CanonicalPaths = canonical
+		router.EscapeAddedRoutes = !canonical
+		router.GET("/αρχεια/:name", handler)
+
+		for _, path := range []string{
+			"/%CE%91%CE%A1%CE%A7%CE%95%CE%99%CE%91/x",
+			"/%ce%b1%cf%81%cf%87%ce%b5%ce%b9%ce%b1/x",
+			"/%CE%B1%CF%81%CF%87%CE%B5%CE%B9%CE%B1/x",
+		} {
+			name = ""
+			r, _ := newRequest("GET", path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			if w.Code != http.StatusOK || name != "x" {
+				t.Errorf("canonical %v %s: got code %d, name %q", canonical, path, w.Code, name)
+			}
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package treemux
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ChaosRule describes the faults injected into the requests of a route.
+type ChaosRule struct {
+	// Method is the request method the rule applies to. Empty string matches any method.
+	Method string
+	// Route is the route template, e.g. "/users/:id".
+	Route string
+	// Latency is added before the handler is called.
+	Latency time.Duration
+	// ErrorRate is the fraction of requests, from 0 to 1, that fail with Status
+	// without calling the handler.
+	ErrorRate float64
+	// Status is the status code of failed requests. The default is 500.
+	Status int
+}
+
+func (r *ChaosRule) match(method, route string) bool {
+	return r.Route == route && (r.Method == "" || r.Method == method)
+}
+
+// Chaos is a middleware that injects faults into selected routes for resilience
+// testing. No faults are injected until rules are added with SetRules or the Setting,
+// which allows to target endpoints at runtime using the settings admin endpoint:
+//
+//	chaos := treemux.NewChaos()
+//	router.Use(chaos.Middleware)
+//	router.Settings.Register("chaos", chaos.Setting())
+//
+//	// POST /admin/settings {"chaos": "GET /users/:id latency=200ms error=0.1 status=503"}
+type Chaos struct {
+	rules atomic.Value // []ChaosRule
+}
+
+func NewChaos() *Chaos {
+	c := new(Chaos)
+	c.rules.Store([]ChaosRule(nil))
+	return c
+}
+
+// SetRules replaces the rules. It is safe to call while serving requests.
+func (c *Chaos) SetRules(rules ...ChaosRule) {
+	c.rules.Store(append([]ChaosRule(nil), rules...))
+}
+
+// Rules returns a copy of the current rules.
+func (c *Chaos) Rules() []ChaosRule {
+	return append([]ChaosRule(nil), c.rules.Load().([]ChaosRule)...)
+}
+
+func (c *Chaos) rule(method, route string) *ChaosRule {
+	rules := c.rules.Load().([]ChaosRule)
+	for i := range rules {
+		if rules[i].match(method, route) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// Middleware injects the faults described by the first rule matching the route.
+func (c *Chaos) Middleware(next HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, req Request) error {
+		rule := c.rule(req.Method, req.Route())
+		if rule == nil {
+			return next(w, req)
+		}
+
+		if rule.Latency > 0 {
+			timer := time.NewTimer(rule.Latency)
+			select {
+			case <-timer.C:
+			case <-req.Context().Done():
+				timer.Stop()
+				return req.Context().Err()
+			}
+		}
+
+		if rule.ErrorRate > 0 && rand.Float64() < rule.ErrorRate {
+			status := rule.Status
+			if status == 0 {
+				status = http.StatusInternalServerError
+			}
+			http.Error(w, "chaos: injected failure", status)
+			return nil
+		}
+
+		return next(w, req)
+	}
+}
+
+// Setting returns a Setting that controls the chaos rules. Rules are separated by
+// semicolons and have the format "[method] route [latency=duration] [error=rate]
+// [status=code]", e.g. "GET /users/:id latency=200ms; /checkout error=0.5 status=503".
+// An empty value removes all rules.
+func (c *Chaos) Setting() Setting {
+	return chaosSetting{c}
+}
+
+type chaosSetting struct {
+	c *Chaos
+}
+
+func (s chaosSetting) String() string {
+	rules := s.c.Rules()
+	parts := make([]string, len(rules))
+	for i, rule := range rules {
+		fields := []string{rule.Route}
+		if rule.Method != "" {
+			fields = append([]string{rule.Method}, fields...)
+		}
+		if rule.Latency > 0 {
+			fields = append(fields, "latency="+rule.Latency.String())
+		}
+		if rule.ErrorRate > 0 {
+			fields = append(fields, "error="+strconv.FormatFloat(rule.ErrorRate, 'g', -1, 64))
+		}
+		if rule.Status != 0 {
+			fields = append(fields, "status="+strconv.Itoa(rule.Status))
+		}
+		parts[i] = strings.Join(fields, " ")
+	}
+	return strings.Join(parts, "; ")
+}
+
+var errInvalidChaosOption = errors.New("invalid chaos option")
+
+// Set parses the rules. Latency must not be negative, the error rate must be
+// between 0 and 1, and the status must be an error status between 400 and 599.
+func (s chaosSetting) Set(value string) error {
+	var rules []ChaosRule
+	for _, part := range strings.Split(value, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var rule ChaosRule
+		var names []string
+		for _, field := range fields {
+			i := strings.IndexByte(field, '=')
+			if i == -1 {
+				names = append(names, field)
+				continue
+			}
+
+			key, val := field[:i], field[i+1:]
+			var err error
+			switch key {
+			case "latency":
+				rule.Latency, err = time.ParseDuration(val)
+				if err == nil && rule.Latency < 0 {
+					err = errInvalidChaosOption
+				}
+			case "error":
+				rule.ErrorRate, err = strconv.ParseFloat(val, 64)
+				if err == nil && !(rule.ErrorRate >= 0 && rule.ErrorRate <= 1) {
+					err = errInvalidChaosOption
+				}
+			case "status":
+				rule.Status, err = strconv.Atoi(val)
+				if err == nil && (rule.Status < 400 || rule.Status > 599) {
+					err = errInvalidChaosOption
+				}
+			default:
+				return fmt.Errorf("treemux: unknown chaos option %q", key)
+			}
+			if err != nil {
+				return fmt.Errorf("treemux: invalid chaos option %q", field)
+			}
+		}
+
+		switch len(names) {
+		case 1:
+			rule.Route = names[0]
+		case 2:
+			rule.Method, rule.Route = names[0], names[1]
+		default:
+			return fmt.Errorf("treemux: invalid chaos rule %q", strings.TrimSpace(part))
+		}
+		rules = append(rules, rule)
+	}
+
+	s.c.SetRules(rules...)
+	return nil
+}
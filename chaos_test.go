@@ -0,0 +1,68 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChaos(t *testing.T) {
+	chaos := NewChaos()
+	router := New()
+	router.Use(chaos.Middleware)
+	router.GET("/users/:id", simpleHandler)
+	router.POST("/users/:id", simpleHandler)
+	router.GET("/checkout", simpleHandler)
+	router.PUT("/settings", router.Settings.Handler(func(Request) error { return nil }))
+	router.Settings.Register("chaos", chaos.Setting())
+
+	serve := func(method, path, body string) (*httptest.ResponseRecorder, time.Duration) {
+		r, _ := newRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		start := time.Now()
+		router.ServeHTTP(w, r)
+		return w, time.Since(start)
+	}
+
+	if w, _ := serve("GET", "/checkout", ""); w.Code != http.StatusOK {
+		t.Fatalf("got code %d without rules", w.Code)
+	}
+
+	w, _ := serve("PUT", "/settings", `{"chaos": "GET /users/:id latency=20ms; /checkout error=1 status=503"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got code %d: %s", w.Code, w.Body)
+	}
+	want := []ChaosRule{
+		{Method: "GET", Route: "/users/:id", Latency: 20 * time.Millisecond},
+		{Route: "/checkout", ErrorRate: 1, Status: 503},
+	}
+	if rules := chaos.Rules(); !reflect.DeepEqual(rules, want) {
+		t.Errorf("got rules %+v", rules)
+	}
+	if got := chaos.Setting().String(); got != "GET /users/:id latency=20ms; /checkout error=1 status=503" {
+		t.Errorf("got setting %q", got)
+	}
+
+	if w, d := serve("GET", "/users/1", ""); w.Code != http.StatusOK || d < 20*time.Millisecond {
+		t.Errorf("got code %d after %s, wanted added latency", w.Code, d)
+	}
+	if w, d := serve("POST", "/users/1", ""); w.Code != http.StatusOK || d >= 20*time.Millisecond {
+		t.Errorf("got code %d after %s for another method", w.Code, d)
+	}
+	if w, _ := serve("GET", "/checkout", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("got code %d, wanted an injected failure", w.Code)
+	}
+
+	for _, value := range []string{
+		"/a latency=fast", "/a retries=3", "GET POST /a",
+		"/a status=42", "/a status=200", "/a status=1000",
+		"/a error=5", "/a error=-0.1", "/a error=NaN", "/a latency=-1s",
+	} {
+		if err := chaos.Setting().Set(value); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
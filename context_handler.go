@@ -0,0 +1,26 @@
+package treemux
+
+import (
+	"context"
+	"net/http"
+)
+
+// ContextHandlerFunc is a handler that receives the request context as the first
+// argument, for codebases that standardize on context-first signatures.
+type ContextHandlerFunc func(ctx context.Context, req Request, w http.ResponseWriter) error
+
+// ContextHandler adapts fn to a HandlerFunc. The context is the request context, so it
+// carries the values and deadlines set by the middlewares and is canceled when the
+// client disconnects. A request whose context is already done is not passed to fn,
+// and the context error is returned instead.
+//
+//	router.GET("/users/:id", treemux.ContextHandler(getUser))
+func ContextHandler(fn ContextHandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, req Request) error {
+		ctx := req.Context()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fn(ctx, req, w)
+	}
+}
@@ -0,0 +1,49 @@
+package treemux
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type contextKey struct{}
+
+func TestContextHandler(t *testing.T) {
+	var handled error
+	router := New()
+	router.ErrorHandler = func(w http.ResponseWriter, req Request, err error) {
+		handled = err
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	router.Use(func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			ctx := context.WithValue(req.Context(), contextKey{}, "value")
+			if req.URL.Query().Get("cancel") != "" {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				cancel()
+			}
+			return next(w, req.WithContext(ctx))
+		}
+	})
+	router.GET("/users/:id", ContextHandler(func(ctx context.Context, req Request, w http.ResponseWriter) error {
+		v, _ := ctx.Value(contextKey{}).(string)
+		_, err := w.Write([]byte(req.Param("id") + " " + v))
+		return err
+	}))
+
+	r, _ := newRequest("GET", "/users/1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Body.String() != "1 value" {
+		t.Errorf("got body %q", w.Body.String())
+	}
+
+	r, _ = newRequest("GET", "/users/1?cancel=1", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if handled != context.Canceled || w.Body.Len() != 0 {
+		t.Errorf("got error %v and body %q for a canceled request", handled, w.Body.String())
+	}
+}
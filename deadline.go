@@ -0,0 +1,112 @@
+package treemux
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetaMaxTimeout is the route metadata key that overrides DeadlineConfig.MaxTimeout
+// for the route. The value must be a time.Duration.
+const MetaMaxTimeout = "max_timeout"
+
+// DeadlineConfig configures the Deadline middleware.
+type DeadlineConfig struct {
+	// DefaultTimeout is used when the request does not specify a timeout.
+	// Zero means no timeout.
+	DefaultTimeout time.Duration
+	// MaxTimeout caps the timeouts requested by clients. Zero means no cap.
+	MaxTimeout time.Duration
+}
+
+// Deadline returns a middleware that applies a context deadline using the timeout
+// requested by the client in the X-Request-Timeout header (e.g. "1.5s" or "2")
+// or the grpc-timeout header (e.g. "100m"). The timeout is capped by the route
+// maximum set with the MetaMaxTimeout route metadata or by DeadlineConfig.MaxTimeout.
+// Invalid headers are ignored.
+func Deadline(cfg DeadlineConfig) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			timeout, ok := requestTimeout(req.Header)
+			if !ok {
+				timeout = cfg.DefaultTimeout
+			}
+
+			max := cfg.MaxTimeout
+			if v, ok := req.RouteInfo().Value(MetaMaxTimeout); ok {
+				if d, ok := v.(time.Duration); ok {
+					max = d
+				}
+			}
+			if max > 0 && (timeout <= 0 || timeout > max) {
+				timeout = max
+			}
+
+			if timeout <= 0 {
+				return next(w, req)
+			}
+
+			ctx, cancel := context.WithTimeout(req.Context(), timeout)
+			defer cancel()
+
+			return next(w, req.WithContext(ctx))
+		}
+	}
+}
+
+func requestTimeout(h http.Header) (time.Duration, bool) {
+	if s := h.Get("X-Request-Timeout"); s != "" {
+		if d, err := time.ParseDuration(s); err == nil && d > 0 {
+			return d, true
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil && f > 0 {
+			return time.Duration(f * float64(time.Second)), true
+		}
+	}
+	if s := h.Get("Grpc-Timeout"); s != "" {
+		if d, ok := parseGRPCTimeout(s); ok {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// parseGRPCTimeout parses a timeout in the gRPC over HTTP2 format:
+// up to 8 digits followed by a unit (H, M, S, m, u, or n).
+func parseGRPCTimeout(s string) (time.Duration, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || len(s) > 9 {
+		return 0, false
+	}
+
+	var unit time.Duration
+	switch s[len(s)-1] {
+	case 'H':
+		unit = time.Hour
+	case 'M':
+		unit = time.Minute
+	case 'S':
+		unit = time.Second
+	case 'm':
+		unit = time.Millisecond
+	case 'u':
+		unit = time.Microsecond
+	case 'n':
+		unit = time.Nanosecond
+	default:
+		return 0, false
+	}
+
+	n, err := strconv.ParseUint(s[:len(s)-1], 10, 64)
+	if err != nil || n == 0 {
+		return 0, false
+	}
+	// 8 digits of hours overflow time.Duration, so the timeout is clamped.
+	if max := uint64(math.MaxInt64 / unit); n > max {
+		n = max
+	}
+	return time.Duration(n) * unit, true
+}
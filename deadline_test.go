@@ -0,0 +1,80 @@
+package treemux
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDeadline(t *testing.T) {
+	var timeout time.Duration
+	var hasDeadline bool
+
+	handler := func(w http.ResponseWriter, req Request) error {
+		var deadline time.Time
+		deadline, hasDeadline = req.Context().Deadline()
+		timeout = time.Until(deadline)
+		return nil
+	}
+
+	router := New()
+	router.Use(Deadline(DeadlineConfig{MaxTimeout: 10 * time.Second}))
+	router.GET("/default", handler)
+	router.GET("/short", handler).Meta(MetaMaxTimeout, time.Second)
+
+	tests := []struct {
+		path, header, value string
+		wanted              time.Duration
+	}{
+		{"/default", "", "", 10 * time.Second},
+		{"/default", "X-Request-Timeout", "2s", 2 * time.Second},
+		{"/default", "X-Request-Timeout", "1.5", 1500 * time.Millisecond},
+		{"/default", "X-Request-Timeout", "1h", 10 * time.Second},
+		{"/default", "X-Request-Timeout", "bogus", 10 * time.Second},
+		{"/default", "Grpc-Timeout", "300m", 300 * time.Millisecond},
+		{"/default", "Grpc-Timeout", "5S", 5 * time.Second},
+		{"/default", "Grpc-Timeout", "123456789S", 10 * time.Second},
+		{"/short", "Grpc-Timeout", "1M", time.Second},
+		{"/short", "", "", time.Second},
+	}
+	for _, test := range tests {
+		r, _ := newRequest("GET", test.path, nil)
+		if test.header != "" {
+			r.Header.Set(test.header, test.value)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), r)
+
+		if !hasDeadline {
+			t.Errorf("%s %s=%s: no deadline", test.path, test.header, test.value)
+			continue
+		}
+		if timeout > test.wanted || timeout < test.wanted-100*time.Millisecond {
+			t.Errorf("%s %s=%s: got timeout %s, wanted %s",
+				test.path, test.header, test.value, timeout, test.wanted)
+		}
+	}
+}
+
+func TestParseGRPCTimeout(t *testing.T) {
+	tests := []struct {
+		value  string
+		wanted time.Duration
+		ok     bool
+	}{
+		{"100m", 100 * time.Millisecond, true},
+		{"2H", 2 * time.Hour, true},
+		{"99999999H", math.MaxInt64 / time.Hour * time.Hour, true},
+		{"99999999S", 99999999 * time.Second, true},
+		{"0S", 0, false},
+		{"123456789S", 0, false},
+		{"10x", 0, false},
+	}
+	for _, test := range tests {
+		d, ok := parseGRPCTimeout(test.value)
+		if d != test.wanted || ok != test.ok {
+			t.Errorf("%q: got %s %t, wanted %s %t", test.value, d, ok, test.wanted, test.ok)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package treemux
+
+// Routing phases traced in debug builds.
+const (
+	phaseLookup = iota
+	phaseParams
+	phaseDispatch
+	phaseHandler
+	numPhases
+)
+
+// DebugPhaseStats contains the totals of a routing phase collected in debug builds.
+type DebugPhaseStats struct {
+	// Count is the number of times the phase ran.
+	Count       uint64 `json:"count"`
+	Nanoseconds uint64 `json:"ns"`
+	Allocs      uint64 `json:"allocs"`
+	Bytes       uint64 `json:"bytes"`
+}
+
+// DebugPhases returns the time spent and the memory allocated in the routing phases
+// when the package is built with the treemuxdebug tag:
+//
+//	go run -tags treemuxdebug .
+//
+// The phases are "lookup" (finding the route and building the params), "params"
+// (sanitizing and checking the params), "middleware" (the middlewares and the router
+// overhead around the handler), and "handler". The stats are process-wide and
+// include the allocations of other goroutines running at the same time, so they are
+// meant for profiling with a single client. Tracing stops the world twice per phase,
+// which makes requests much slower. DebugPhases returns nil in regular builds.
+//
+// PublishExpvar publishes the stats as <prefix>.phases in debug builds.
+func DebugPhases() map[string]DebugPhaseStats {
+	return debugPhases()
+}
@@ -0,0 +1,17 @@
+//go:build !treemuxdebug
+// +build !treemuxdebug
+
+package treemux
+
+const debugBuild = false
+
+// debugSpan is empty in regular builds, so the tracing calls compile to nothing.
+type debugSpan struct{}
+
+func startPhase() debugSpan { return debugSpan{} }
+
+func (debugSpan) end(phase int) {}
+
+func debugPhases() map[string]DebugPhaseStats { return nil }
+
+func publishDebugPhases(prefix string) {}
@@ -0,0 +1,78 @@
+//go:build treemuxdebug
+// +build treemuxdebug
+
+package treemux
+
+import (
+	"expvar"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const debugBuild = true
+
+var phaseStats struct {
+	sync.Mutex
+	phases [numPhases]DebugPhaseStats
+}
+
+type debugSpan struct {
+	start  time.Time
+	allocs uint64
+	bytes  uint64
+}
+
+func startPhase() debugSpan {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return debugSpan{start: time.Now(), allocs: ms.Mallocs, bytes: ms.TotalAlloc}
+}
+
+func (s debugSpan) end(phase int) {
+	d := time.Since(s.start)
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	phaseStats.Lock()
+	p := &phaseStats.phases[phase]
+	p.Count++
+	p.Nanoseconds += uint64(d)
+	p.Allocs += ms.Mallocs - s.allocs
+	p.Bytes += ms.TotalAlloc - s.bytes
+	phaseStats.Unlock()
+}
+
+func debugPhases() map[string]DebugPhaseStats {
+	phaseStats.Lock()
+	phases := phaseStats.phases
+	phaseStats.Unlock()
+
+	// The dispatch phase contains the params and the handler phases.
+	middleware := phases[phaseDispatch]
+	for _, inner := range []DebugPhaseStats{phases[phaseParams], phases[phaseHandler]} {
+		middleware.Nanoseconds = saturatingSub(middleware.Nanoseconds, inner.Nanoseconds)
+		middleware.Allocs = saturatingSub(middleware.Allocs, inner.Allocs)
+		middleware.Bytes = saturatingSub(middleware.Bytes, inner.Bytes)
+	}
+
+	return map[string]DebugPhaseStats{
+		"lookup":     phases[phaseLookup],
+		"params":     phases[phaseParams],
+		"middleware": middleware,
+		"handler":    phases[phaseHandler],
+	}
+}
+
+func saturatingSub(a, b uint64) uint64 {
+	if b > a {
+		return 0
+	}
+	return a - b
+}
+
+func publishDebugPhases(prefix string) {
+	expvar.Publish(prefix+".phases", expvar.Func(func() interface{} {
+		return debugPhases()
+	}))
+}
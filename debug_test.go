@@ -0,0 +1,44 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Run with -tags treemuxdebug to test the tracing.
+func TestDebugPhases(t *testing.T) {
+	router := New()
+	router.Use(func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			return next(w, req)
+		}
+	})
+	router.GET("/users/:id", func(w http.ResponseWriter, req Request) error {
+		_, err := w.Write(make([]byte, 1<<10))
+		return err
+	})
+
+	before := DebugPhases()
+	r, _ := newRequest("GET", "/users/1", nil)
+	router.ServeHTTP(httptest.NewRecorder(), r)
+	after := DebugPhases()
+
+	if !debugBuild {
+		if after != nil {
+			t.Errorf("got %v in a regular build", after)
+		}
+		return
+	}
+	for _, phase := range []string{"lookup", "params", "middleware", "handler"} {
+		if after[phase].Count != before[phase].Count+1 {
+			t.Errorf("%s: got count %d, wanted %d", phase, after[phase].Count, before[phase].Count+1)
+		}
+		if after[phase].Nanoseconds <= before[phase].Nanoseconds {
+			t.Errorf("%s: time did not increase", phase)
+		}
+	}
+	if after["lookup"].Allocs <= before["lookup"].Allocs {
+		t.Error("lookup allocated the params, but no allocations were counted")
+	}
+}
@@ -0,0 +1,218 @@
+package treemux
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrBodyTooLarge is returned when reading a request body that exceeds the configured limit.
+var ErrBodyTooLarge = errors.New("treemux: request body too large")
+
+// MetaMaxBodySize is the route metadata key that overrides DecompressConfig.MaxSize
+// for the route. The value must be an int64.
+const MetaMaxBodySize = "max_body_size"
+
+// MetaMaxCompressedBodySize is the route metadata key that overrides
+// DecompressConfig.MaxCompressedSize for the route. The value must be an int64.
+const MetaMaxCompressedBodySize = "max_compressed_body_size"
+
+// DecompressConfig configures the Decompress middleware.
+type DecompressConfig struct {
+	// MaxSize limits the number of decompressed bytes that can be read from the body.
+	// Reading past the limit returns ErrBodyTooLarge. Zero means no limit.
+	MaxSize int64
+	// MaxCompressedSize limits the number of compressed bytes that can be read from
+	// the body. Together with MaxSize it protects against decompression bombs,
+	// where a small compressed body expands to gigabytes. Zero means no limit.
+	MaxCompressedSize int64
+}
+
+func (cfg *DecompressConfig) limits(req Request) (maxSize, maxCompressedSize int64) {
+	maxSize, maxCompressedSize = cfg.MaxSize, cfg.MaxCompressedSize
+	info := req.RouteInfo()
+	if v, ok := info.Value(MetaMaxBodySize); ok {
+		if n, ok := v.(int64); ok {
+			maxSize = n
+		}
+	}
+	if v, ok := info.Value(MetaMaxCompressedBodySize); ok {
+		if n, ok := v.(int64); ok {
+			maxCompressedSize = n
+		}
+	}
+	return maxSize, maxCompressedSize
+}
+
+// DecompressError is returned when a compressed request body is malformed.
+type DecompressError struct {
+	Encoding string
+	Err      error
+}
+
+func (e *DecompressError) Error() string {
+	return "treemux: can't decompress " + e.Encoding + " body: " + e.Err.Error()
+}
+
+func (e *DecompressError) Unwrap() error {
+	return e.Err
+}
+
+// Decompress returns a middleware that transparently decompresses gzip and deflate request
+// bodies before calling the handler. The Content-Encoding and Content-Length headers are
+// removed so the handler sees a plain body.
+//
+// Malformed bodies are rejected with 400 Bad Request, bodies exceeding MaxSize or
+// MaxCompressedSize with 413 Request Entity Too Large, and unknown encodings with
+// 415 Unsupported Media Type. Both limits can be changed per route with the
+// MetaMaxBodySize and MetaMaxCompressedBodySize route metadata:
+//
+//	router.Use(treemux.Decompress(treemux.DecompressConfig{MaxSize: 1 << 20}))
+//	router.POST("/import", importData).
+//		Meta(treemux.MetaMaxCompressedBodySize, int64(10<<20)).
+//		Meta(treemux.MetaMaxBodySize, int64(100<<20))
+//
+// Errors that happen while the handler reads the body are mapped the same way
+// when the handler returns them.
+func Decompress(cfg DecompressConfig) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			encoding := strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding")))
+			if encoding == "" || encoding == "identity" || req.Body == nil || req.Body == http.NoBody {
+				return next(w, req)
+			}
+
+			maxSize, maxCompressedSize := cfg.limits(req)
+			raw := req.Body
+			if maxCompressedSize > 0 {
+				if req.ContentLength > maxCompressedSize {
+					writeDecompressError(w, ErrBodyTooLarge)
+					return nil
+				}
+				raw = &limitedBody{ReadCloser: raw, n: maxCompressedSize}
+			}
+
+			body, err := newDecompressReader(encoding, raw)
+			if err != nil {
+				writeDecompressError(w, err)
+				return nil
+			}
+			if maxSize > 0 {
+				body = &limitedBody{ReadCloser: body, n: maxSize}
+			}
+
+			r := new(http.Request)
+			*r = *req.Request
+			r.Body = body
+			r.ContentLength = -1
+			r.Header = r.Header.Clone()
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			req.Request = r
+
+			err = next(w, req)
+			if isBodyError(err) {
+				writeDecompressError(w, err)
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func newDecompressReader(encoding string, body io.ReadCloser) (io.ReadCloser, error) {
+	switch encoding {
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, &DecompressError{Encoding: encoding, Err: err}
+		}
+		return &decompressReader{encoding: encoding, r: zr, body: body}, nil
+	case "deflate":
+		// RFC 7230 defines deflate as zlib-wrapped data,
+		// but some clients send raw deflate streams.
+		br := bufio.NewReader(body)
+		var r io.ReadCloser
+		if header, err := br.Peek(2); err == nil && isZlibHeader(header) {
+			zr, err := zlib.NewReader(br)
+			if err != nil {
+				return nil, &DecompressError{Encoding: encoding, Err: err}
+			}
+			r = zr
+		} else {
+			r = flate.NewReader(br)
+		}
+		return &decompressReader{encoding: encoding, r: r, body: body}, nil
+	default:
+		return nil, errUnsupportedEncoding
+	}
+}
+
+func isZlibHeader(b []byte) bool {
+	return b[0]&0x0f == 8 && (uint16(b[0])<<8|uint16(b[1]))%31 == 0
+}
+
+var errUnsupportedEncoding = errors.New("treemux: unsupported content encoding")
+
+type decompressReader struct {
+	encoding string
+	r        io.ReadCloser
+	body     io.ReadCloser
+}
+
+func (r *decompressReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	if err != nil && err != io.EOF {
+		err = &DecompressError{Encoding: r.encoding, Err: err}
+	}
+	return n, err
+}
+
+func (r *decompressReader) Close() error {
+	_ = r.r.Close()
+	return r.body.Close()
+}
+
+type limitedBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *limitedBody) Read(b []byte) (int, error) {
+	if r.n < 0 {
+		return 0, ErrBodyTooLarge
+	}
+	if int64(len(b)) > r.n+1 {
+		b = b[:r.n+1]
+	}
+	n, err := r.ReadCloser.Read(b)
+	r.n -= int64(n)
+	if r.n < 0 {
+		return n - 1, ErrBodyTooLarge
+	}
+	return n, err
+}
+
+func isBodyError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var decompressErr *DecompressError
+	return errors.Is(err, ErrBodyTooLarge) || errors.As(err, &decompressErr)
+}
+
+func writeDecompressError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrBodyTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case err == errUnsupportedEncoding:
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
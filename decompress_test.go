@@ -0,0 +1,150 @@
+package treemux
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func gzipBody(s string) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write([]byte(s))
+	_ = zw.Close()
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	var got string
+	router := New()
+	router.Use(Decompress(DecompressConfig{MaxSize: 16}))
+	router.POST("/upload", func(w http.ResponseWriter, req Request) error {
+		if req.Header.Get("Content-Encoding") != "" {
+			t.Errorf("Content-Encoding header was not removed")
+		}
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		got = string(b)
+		return nil
+	})
+
+	zlibBody := func(s string) []byte {
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		_, _ = zw.Write([]byte(s))
+		_ = zw.Close()
+		return buf.Bytes()
+	}
+	flateBody := func(s string) []byte {
+		var buf bytes.Buffer
+		zw, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+		_, _ = zw.Write([]byte(s))
+		_ = zw.Close()
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		encoding string
+		body     []byte
+		code     int
+		want     string
+	}{
+		{"", []byte("plain"), http.StatusOK, "plain"},
+		{"gzip", gzipBody("hello"), http.StatusOK, "hello"},
+		{"deflate", zlibBody("zlib"), http.StatusOK, "zlib"},
+		{"deflate", flateBody("raw"), http.StatusOK, "raw"},
+		{"gzip", []byte("not gzip"), http.StatusBadRequest, ""},
+		{"gzip", gzipBody(strings.Repeat("x", 17)), http.StatusRequestEntityTooLarge, ""},
+		{"br", []byte("whatever"), http.StatusUnsupportedMediaType, ""},
+	}
+	for _, test := range tests {
+		got = ""
+		r, _ := newRequest("POST", "/upload", bytes.NewReader(test.body))
+		if test.encoding != "" {
+			r.Header.Set("Content-Encoding", test.encoding)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.code {
+			t.Errorf("%s: got code %d, wanted %d", test.encoding, w.Code, test.code)
+		}
+		if got != test.want {
+			t.Errorf("%s: got body %q, wanted %q", test.encoding, got, test.want)
+		}
+	}
+}
+
+func TestDecompressCorruptStream(t *testing.T) {
+	body := gzipBody(strings.Repeat("data", 100))
+	// Corrupt the checksum.
+	body[len(body)-8] ^= 0xff
+
+	router := New()
+	router.Use(Decompress(DecompressConfig{}))
+	router.POST("/upload", func(w http.ResponseWriter, req Request) error {
+		_, err := io.Copy(ioutil.Discard, req.Body)
+		return err
+	})
+
+	r, _ := newRequest("POST", "/upload", bytes.NewReader(body))
+	r.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("got code %d, wanted %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestDecompressBomb(t *testing.T) {
+	bomb := gzipBody(strings.Repeat("0", 1<<20))
+
+	router := New()
+	router.Use(Decompress(DecompressConfig{MaxSize: 1 << 10, MaxCompressedSize: 1 << 10}))
+	handler := func(w http.ResponseWriter, req Request) error {
+		_, err := io.Copy(ioutil.Discard, req.Body)
+		return err
+	}
+	router.POST("/default", handler)
+	router.POST("/large", handler).
+		Meta(MetaMaxBodySize, int64(2<<20)).
+		Meta(MetaMaxCompressedBodySize, int64(1<<20))
+	router.POST("/compressed", handler).
+		Meta(MetaMaxBodySize, int64(2<<20)).
+		Meta(MetaMaxCompressedBodySize, int64(64))
+
+	tests := []struct {
+		path          string
+		contentLength bool
+		code          int
+	}{
+		{"/default", true, http.StatusRequestEntityTooLarge},
+		{"/default", false, http.StatusRequestEntityTooLarge},
+		{"/large", true, http.StatusOK},
+		{"/compressed", true, http.StatusRequestEntityTooLarge},
+		{"/compressed", false, http.StatusRequestEntityTooLarge},
+	}
+	for _, test := range tests {
+		r, _ := newRequest("POST", test.path, bytes.NewReader(bomb))
+		r.Header.Set("Content-Encoding", "gzip")
+		if !test.contentLength {
+			r.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.code {
+			t.Errorf("%s: got code %d, wanted %d", test.path, w.Code, test.code)
+		}
+	}
+}
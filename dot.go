@@ -0,0 +1,93 @@
+package treemux
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DumpDOT writes the routing tree in the Graphviz DOT format. Every node shows its path
+// segment, priority, handled methods, and param names; edges are labeled with the static
+// index byte, ":" for wildcard children, or "*" for catch-all children. Host and shard
+// trees are drawn as separate clusters. Render it with:
+//
+//	dot -Tsvg routes.dot > routes.svg
+func (t *TreeMux) DumpDOT(w io.Writer) error {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	hosts := make(map[*node]string, len(t.hosts))
+	for host, root := range t.hosts {
+		hosts[root] = host
+	}
+	for key, root := range t.shards {
+		hosts[root] = "shard /" + key
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph treemux {")
+	fmt.Fprintln(bw, "\tnode [shape=box, fontname=monospace];")
+
+	d := &dotWriter{w: bw}
+	for i, root := range t.trees() {
+		fmt.Fprintf(bw, "\tsubgraph cluster_%d {\n", i)
+		label := "default"
+		if host, ok := hosts[root]; ok {
+			label = host
+		}
+		fmt.Fprintf(bw, "\t\tlabel=%s;\n", strconv.Quote(label))
+		d.writeNode(root, "")
+		fmt.Fprintln(bw, "\t}")
+	}
+
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+type dotWriter struct {
+	w  io.Writer
+	id int
+}
+
+// writeNode writes the node and its descendants and returns the node id.
+func (d *dotWriter) writeNode(n *node, nodeType string) string {
+	id := "n" + strconv.Itoa(d.id)
+	d.id++
+
+	path := n.path
+	if nodeType == "" {
+		path = escapeRouteLiteral(path)
+	}
+	lines := []string{nodeType + path, "priority " + strconv.Itoa(n.priority)}
+	if n.handlerMap.Len() > 0 {
+		lines = append(lines, strings.Join(n.handlerMap.Methods(), " "))
+	}
+	if len(n.leafWildcardNames) > 0 {
+		lines = append(lines, "params "+strings.Join(n.leafWildcardNames, ", "))
+	}
+	if n.addSlash {
+		lines = append(lines, "add slash")
+	}
+
+	attrs := ""
+	if n.handlerMap.Len() > 0 {
+		attrs = ", style=bold"
+	}
+	fmt.Fprintf(d.w, "\t\t%s [label=%s%s];\n", id, strconv.Quote(strings.Join(lines, "\n")), attrs)
+
+	for i, child := range n.staticChild {
+		childID := d.writeNode(child, "")
+		fmt.Fprintf(d.w, "\t\t%s -> %s [label=%s];\n", id, childID, strconv.Quote(string(n.staticIndices[i])))
+	}
+	if n.wildcardChild != nil {
+		childID := d.writeNode(n.wildcardChild, ":")
+		fmt.Fprintf(d.w, "\t\t%s -> %s [label=\":\", style=dashed];\n", id, childID)
+	}
+	if n.catchAllChild != nil {
+		childID := d.writeNode(n.catchAllChild, "*")
+		fmt.Fprintf(d.w, "\t\t%s -> %s [label=\"*\", style=dotted];\n", id, childID)
+	}
+	return id
+}
@@ -0,0 +1,40 @@
+package treemux
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDumpDOT(t *testing.T) {
+	router := New()
+	router.GET("/users/:id", simpleHandler)
+	router.POST("/users", simpleHandler)
+	router.GET("/files/*path", simpleHandler)
+	router.Host("api.example.com").GET("/items", simpleHandler)
+
+	var buf bytes.Buffer
+	if err := router.DumpDOT(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, s := range []string{
+		"digraph treemux {\n",
+		`label="default";`,
+		`label="api.example.com";`,
+		`[label=":wildcard\npriority 0\nGET HEAD\nparams id", style=bold];`,
+		`[label="*path\npriority 0\nGET HEAD\nparams path", style=bold];`,
+		`[label=":", style=dashed];`,
+		`[label="*", style=dotted];`,
+		`[label="u"];`,
+		`[label="users\npriority 1\nPOST", style=bold];`,
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("output does not contain %q", s)
+		}
+	}
+	if !strings.HasSuffix(out, "}\n") {
+		t.Error("graph is not closed")
+	}
+}
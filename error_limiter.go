@@ -0,0 +1,147 @@
+package treemux
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrorLogConfig configures an ErrorLogLimiter.
+type ErrorLogConfig struct {
+	// Interval is the rate-limiting window. The default is one minute.
+	Interval time.Duration
+	// Burst is the number of identical errors logged per route and window. The default is 1.
+	Burst int
+	// Log logs the error.
+	Log func(req Request, err error)
+	// Suppressed is called with the number of identical errors that were not logged
+	// during a window, e.g. to log "suppressed N similar errors".
+	Suppressed func(method, route, msg string, n int)
+	// Now returns the current time. The default is time.Now.
+	Now func() time.Time
+}
+
+// ErrorLogLimiter rate-limits identical error logs per route, protecting log systems
+// when a hot endpoint starts failing. Errors are identical when they have the same
+// method, route, and message.
+type ErrorLogLimiter struct {
+	cfg ErrorLogConfig
+
+	mu      sync.Mutex
+	windows map[errorLogKey]*errorLogWindow
+	swept   time.Time
+}
+
+type errorLogKey struct {
+	method, route, msg string
+}
+
+type errorLogWindow struct {
+	start      time.Time
+	logged     int
+	suppressed int
+}
+
+func NewErrorLogLimiter(cfg ErrorLogConfig) *ErrorLogLimiter {
+	if cfg.Interval == 0 {
+		cfg.Interval = time.Minute
+	}
+	if cfg.Burst == 0 {
+		cfg.Burst = 1
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &ErrorLogLimiter{
+		cfg:     cfg,
+		windows: make(map[errorLogKey]*errorLogWindow),
+		swept:   cfg.Now(),
+	}
+}
+
+// Log logs the error unless it exceeds the limit. It can be used in the router's ErrorHandler.
+func (l *ErrorLogLimiter) Log(req Request, err error) {
+	key := errorLogKey{method: req.Method, route: req.Route(), msg: err.Error()}
+	now := l.cfg.Now()
+
+	l.mu.Lock()
+	summaries := l.sweep(now)
+
+	win := l.windows[key]
+	if win == nil {
+		win = &errorLogWindow{start: now}
+		l.windows[key] = win
+	}
+	allowed := win.logged < l.cfg.Burst
+	if allowed {
+		win.logged++
+	} else {
+		win.suppressed++
+	}
+	l.mu.Unlock()
+
+	l.report(summaries)
+	if allowed {
+		l.cfg.Log(req, err)
+	}
+}
+
+// Middleware logs errors returned by handlers. The errors are passed through unchanged.
+func (l *ErrorLogLimiter) Middleware(next HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, req Request) error {
+		err := next(w, req)
+		if err != nil {
+			l.Log(req, err)
+		}
+		return err
+	}
+}
+
+// Flush reports the errors suppressed so far, e.g. before the server exits.
+func (l *ErrorLogLimiter) Flush() {
+	l.mu.Lock()
+	summaries := l.summaries(func(win *errorLogWindow) bool { return true })
+	l.mu.Unlock()
+
+	l.report(summaries)
+}
+
+type errorLogSummary struct {
+	key errorLogKey
+	n   int
+}
+
+// sweep removes expired windows at most once per interval and returns
+// their summaries. It must be called with the lock held.
+func (l *ErrorLogLimiter) sweep(now time.Time) []errorLogSummary {
+	if now.Sub(l.swept) < l.cfg.Interval {
+		return nil
+	}
+	l.swept = now
+	return l.summaries(func(win *errorLogWindow) bool {
+		return now.Sub(win.start) >= l.cfg.Interval
+	})
+}
+
+func (l *ErrorLogLimiter) summaries(expired func(win *errorLogWindow) bool) []errorLogSummary {
+	var summaries []errorLogSummary
+	for key, win := range l.windows {
+		if !expired(win) {
+			continue
+		}
+		if win.suppressed > 0 {
+			summaries = append(summaries, errorLogSummary{key: key, n: win.suppressed})
+		}
+		delete(l.windows, key)
+	}
+	return summaries
+}
+
+func (l *ErrorLogLimiter) report(summaries []errorLogSummary) {
+	if l.cfg.Suppressed == nil {
+		return
+	}
+	for _, s := range summaries {
+		l.cfg.Suppressed(s.key.method, s.key.route, s.key.msg, s.n)
+	}
+}
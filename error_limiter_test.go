@@ -0,0 +1,74 @@
+package treemux
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestErrorLogLimiter(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var logged, suppressed []string
+	limiter := NewErrorLogLimiter(ErrorLogConfig{
+		Interval: time.Minute,
+		Burst:    2,
+		Log: func(req Request, err error) {
+			logged = append(logged, req.Route()+" "+err.Error())
+		},
+		Suppressed: func(method, route, msg string, n int) {
+			suppressed = append(suppressed, fmt.Sprintf("%s %s %s: %d", method, route, msg, n))
+		},
+		Now: func() time.Time { return now },
+	})
+
+	router := New()
+	router.ErrorHandler = func(w http.ResponseWriter, req Request, err error) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	router.Use(limiter.Middleware)
+	router.GET("/users/:id", func(w http.ResponseWriter, req Request) error {
+		return errors.New("db is down")
+	})
+	router.GET("/other", func(w http.ResponseWriter, req Request) error {
+		return errors.New("db is down")
+	})
+
+	serve := func(path string) {
+		r, _ := newRequest("GET", path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	for i := 0; i < 5; i++ {
+		serve(fmt.Sprintf("/users/%d", i))
+	}
+	serve("/other")
+
+	wanted := []string{"/users/:id db is down", "/users/:id db is down", "/other db is down"}
+	if !reflect.DeepEqual(logged, wanted) {
+		t.Errorf("got logged %q", logged)
+	}
+	if len(suppressed) != 0 {
+		t.Errorf("got suppressed %q", suppressed)
+	}
+
+	now = now.Add(time.Minute)
+	serve("/users/1")
+
+	if len(logged) != 4 {
+		t.Errorf("error was not logged in the new window: %q", logged)
+	}
+	if !reflect.DeepEqual(suppressed, []string{"GET /users/:id db is down: 3"}) {
+		t.Errorf("got suppressed %q", suppressed)
+	}
+
+	serve("/users/1")
+	serve("/users/1")
+	limiter.Flush()
+	if len(suppressed) != 2 || suppressed[1] != "GET /users/:id db is down: 1" {
+		t.Errorf("got suppressed %q", suppressed)
+	}
+}
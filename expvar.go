@@ -0,0 +1,83 @@
+package treemux
+
+import (
+	"expvar"
+)
+
+// TreeStats describes the routing trees of all hosts.
+type TreeStats struct {
+	// Routes is the number of registered routes (method and path pairs).
+	Routes int
+	// Nodes is the number of nodes in the tree.
+	Nodes int
+	// Depth is the maximum depth of the tree.
+	Depth int
+}
+
+// TreeStats returns the statistics of the routing tree.
+func (t *TreeMux) TreeStats() TreeStats {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	var stats TreeStats
+	seen := make(map[*RouteInfo]struct{})
+	for _, root := range t.trees() {
+		root.walk(0, func(n *node, depth int) {
+			stats.Nodes++
+			if depth > stats.Depth {
+				stats.Depth = depth
+			}
+			if n.handlerMap == nil {
+				return
+			}
+			// Implicit HEAD routes and escaped copies of routes share the RouteInfo.
+			for _, info := range n.handlerMap.routes {
+				seen[info] = struct{}{}
+			}
+		})
+	}
+	stats.Routes = len(seen)
+	return stats
+}
+
+// PublishExpvar publishes the router statistics using expvar under the prefix:
+//
+//	<prefix>.tree    - TreeStats
+//	<prefix>.routes  - per-route counters collected by Metrics.Middleware,
+//	                   keyed by "<method> <route>", or "<method> <host><route>"
+//	                   for the routes of TreeMux.Host
+//	<prefix>.phases  - DebugPhases, only in builds with the treemuxdebug tag
+//
+// Like expvar.Publish, it panics if the names are already published.
+func (t *TreeMux) PublishExpvar(prefix string) {
+	expvar.Publish(prefix+".tree", expvar.Func(func() interface{} {
+		return t.TreeStats()
+	}))
+	expvar.Publish(prefix+".routes", expvar.Func(func() interface{} {
+		return expvarRoutes(t.Metrics())
+	}))
+	publishDebugPhases(prefix)
+}
+
+type expvarRouteStats struct {
+	Requests     uint64         `json:"requests"`
+	Errors       uint64         `json:"errors"`
+	StatusCodes  map[int]uint64 `json:"status_codes"`
+	LatencySum   float64        `json:"latency_seconds_sum"`
+	ResponseSize float64        `json:"response_bytes_sum"`
+}
+
+func expvarRoutes(m *Metrics) map[string]expvarRouteStats {
+	snapshot := m.Snapshot()
+	routes := make(map[string]expvarRouteStats, len(snapshot))
+	for _, s := range snapshot {
+		routes[s.Method+" "+s.Host+s.Route] = expvarRouteStats{
+			Requests:     s.Requests,
+			Errors:       s.Errors,
+			StatusCodes:  s.StatusCodes,
+			LatencySum:   s.Latency.Sum,
+			ResponseSize: s.ResponseSize.Sum,
+		}
+	}
+	return routes
+}
@@ -0,0 +1,56 @@
+package treemux
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTreeStats(t *testing.T) {
+	router := New()
+	router.GET("/users/:id", simpleHandler)
+	router.POST("/users/:id", simpleHandler)
+	router.GET("/files/*path", simpleHandler)
+	router.GET("/", simpleHandler)
+
+	stats := router.TreeStats()
+	if stats.Routes != 4 {
+		t.Errorf("got %d routes, wanted 4", stats.Routes)
+	}
+	if stats.Nodes < 4 || stats.Depth < 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestPublishExpvar(t *testing.T) {
+	router := New()
+	router.Use(router.Metrics().Middleware)
+	router.GET("/users/:id", simpleHandler)
+	router.Host("api.example.com").GET("/users/:id", simpleHandler)
+	router.PublishExpvar("treemux_test")
+
+	for _, host := range []string{"example.com", "api.example.com", "api.example.com"} {
+		r, _ := newRequest("GET", "/users/1", nil)
+		r.Host = host
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	var tree TreeStats
+	if err := json.Unmarshal([]byte(expvar.Get("treemux_test.tree").String()), &tree); err != nil {
+		t.Fatal(err)
+	}
+	if tree.Routes != 2 {
+		t.Errorf("got %+v", tree)
+	}
+
+	var routes map[string]struct {
+		Requests uint64 `json:"requests"`
+	}
+	if err := json.Unmarshal([]byte(expvar.Get("treemux_test.routes").String()), &routes); err != nil {
+		t.Fatal(err)
+	}
+	if routes["GET /users/:id"].Requests != 1 || routes["GET api.example.com/users/:id"].Requests != 2 {
+		t.Errorf("got %+v", routes)
+	}
+}
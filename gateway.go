@@ -0,0 +1,88 @@
+package treemux
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PathParamsHandlerFunc is a handler that receives path params as a map. It has the same
+// signature as runtime.HandlerFunc from grpc-gateway, so generated gateway handlers and
+// handlers registered with runtime.ServeMux.HandlePath can be used with treemux.
+type PathParamsHandlerFunc func(w http.ResponseWriter, req *http.Request, pathParams map[string]string)
+
+// PathParamsHandler adapts fn to a HandlerFunc, passing route params as the path params map:
+//
+//	router.GET("/v1/users/:id", treemux.PathParamsHandler(getUser))
+func PathParamsHandler(fn PathParamsHandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, req Request) error {
+		pathParams := req.Params.Map()
+		if pathParams == nil {
+			pathParams = make(map[string]string)
+		}
+		fn(w, req.httpRequest(), pathParams)
+		return nil
+	}
+}
+
+// MountGateway mounts a gateway handler, for example, a grpc-gateway runtime.ServeMux or
+// a Connect handler, so it serves all requests under the group prefix that are not
+// handled by other routes. The gateway does its own routing, so the request path is
+// passed unmodified. Requests are served through the group middlewares, which lets
+// mixed REST and gRPC services share one router and middleware stack.
+func (g *Group) MountGateway(h http.Handler) {
+	handler := func(w http.ResponseWriter, req Request) error {
+		h.ServeHTTP(w, req.httpRequest())
+		return nil
+	}
+
+	paths := []string{"/*path"}
+	if g.path != "" {
+		paths = append(paths, "")
+	} else {
+		paths = append(paths, "/")
+	}
+
+	for _, path := range paths {
+		for _, method := range []string{
+			http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+		} {
+			g.Handle(method, path, handler)
+		}
+	}
+}
+
+// Mount serves all requests under the prefix with the handler, which is useful for
+// third-party handlers like net/http/pprof, Prometheus, or Swagger UI. The prefix is
+// stripped from the request path, so the handler sees "/" for the prefix itself and
+// "/metrics" for "<prefix>/metrics". Requests for the prefix without the trailing slash
+// are redirected according to RedirectTrailingSlash. The prefix can contain params,
+// which are available to the group middlewares, and must not use the param name "path".
+//
+//	router.Mount("/debug/pprof", http.HandlerFunc(pprof.Index))
+//	router.Mount("/docs", http.FileServer(http.Dir("swagger-ui")))
+func (g *Group) Mount(prefix string, h http.Handler) {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	handler := func(w http.ResponseWriter, req Request) error {
+		r := new(http.Request)
+		*r = *req.httpRequest()
+		u := new(url.URL)
+		*u = *r.URL
+		u.Path = "/" + strings.TrimPrefix(req.Param("path"), "/")
+		u.RawPath = ""
+		r.URL = u
+
+		h.ServeHTTP(w, r)
+		return nil
+	}
+
+	for _, path := range []string{prefix + "/", prefix + "/*path"} {
+		for _, method := range []string{
+			http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+			http.MethodOptions,
+		} {
+			g.Handle(method, path, handler)
+		}
+	}
+}
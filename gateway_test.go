@@ -0,0 +1,110 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestPathParamsHandler(t *testing.T) {
+	var got map[string]string
+	router := New()
+	router.GET("/v1/users/:id/posts/:post", PathParamsHandler(
+		func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
+			got = pathParams
+		}))
+
+	r, _ := newRequest("GET", "/v1/users/1/posts/2", nil)
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	wanted := map[string]string{"id": "1", "post": "2"}
+	if !reflect.DeepEqual(got, wanted) {
+		t.Errorf("got %v, wanted %v", got, wanted)
+	}
+}
+
+func TestMountGateway(t *testing.T) {
+	var paths []string
+	gateway := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		paths = append(paths, req.Method+" "+req.URL.Path)
+	})
+
+	var middlewareCalls int
+	router := New()
+	api := router.NewGroup("/api")
+	api.Use(func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			middlewareCalls++
+			return next(w, req)
+		}
+	})
+	api.GET("/health", simpleHandler)
+	api.MountGateway(gateway)
+
+	for _, req := range []struct{ method, path string }{
+		{"GET", "/api/health"},
+		{"POST", "/api/v1/users"},
+		{"DELETE", "/api/v1/users/1"},
+		{"GET", "/api"},
+		{"GET", "/other"},
+	} {
+		r, _ := newRequest(req.method, req.path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	wanted := []string{"POST /api/v1/users", "DELETE /api/v1/users/1", "GET /api"}
+	if !reflect.DeepEqual(paths, wanted) {
+		t.Errorf("got %v, wanted %v", paths, wanted)
+	}
+	if middlewareCalls != 4 {
+		t.Errorf("got %d middleware calls, wanted 4", middlewareCalls)
+	}
+}
+
+func TestMount(t *testing.T) {
+	var gotPath string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	var tenant string
+	router := New()
+	router.Mount("/debug/pprof/", h)
+	g := router.NewGroup("/tenants/:tenant")
+	g.Use(func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			tenant = req.Param("tenant")
+			return next(w, req)
+		}
+	})
+	g.Mount("/files", h)
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		want   string
+	}{
+		{"GET", "/debug/pprof/", http.StatusAccepted, "/"},
+		{"GET", "/debug/pprof/heap", http.StatusAccepted, "/heap"},
+		{"POST", "/debug/pprof/a/b%20c", http.StatusAccepted, "/a/b c"},
+		{"GET", "/debug/pprof", http.StatusMovedPermanently, ""},
+		{"PUT", "/tenants/acme/files/x.txt", http.StatusAccepted, "/x.txt"},
+	}
+	for _, test := range tests {
+		gotPath = ""
+		r, _ := newRequest(test.method, test.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.code || gotPath != test.want {
+			t.Errorf("%s %s: got code %d and path %q, wanted %d and %q",
+				test.method, test.path, w.Code, gotPath, test.code, test.want)
+		}
+	}
+	if tenant != "acme" {
+		t.Errorf("got tenant %q", tenant)
+	}
+}
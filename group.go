@@ -9,6 +9,9 @@ import (
 
 type MiddlewareFunc func(next HandlerFunc) HandlerFunc
 
+// handlerWithMiddlewares composes the middleware chain. Chains are composed when routes
+// and middlewares are added, never while serving requests, so every MiddlewareFunc is
+// called once per route it wraps.
 func handlerWithMiddlewares(handler HandlerFunc, stack []MiddlewareFunc) HandlerFunc {
 	for i := len(stack) - 1; i >= 0; i-- {
 		handler = stack[i](handler)
@@ -30,6 +33,23 @@ type Group struct {
 	path  string
 	mux   *TreeMux
 	stack []MiddlewareFunc
+	// names contains middleware names added with UseNamed. It has the same length as stack
+	// and unnamed middlewares have empty names.
+	names []string
+	// root is the tree routes are added to. Nil means the mux tree.
+	root *node
+	// host is the host passed to TreeMux.Host.
+	host string
+	// shards contains the shard trees routes are added to when the mux is sharded.
+	// Nil means the mux shards.
+	shards map[string]*node
+	// shard is the segment of the shard rebuilt by TreeMux.ReloadShard. All routes
+	// of the group must be in the shard.
+	shard string
+	// handlers contains the handlers set on the group. Nil means the mux handlers.
+	handlers *groupHandlers
+	// routeNames contains the routes named by a reload build. Nil means the mux names.
+	routeNames map[string]*RouteInfo
 }
 
 // Lock returns a locked group that does not allow mutating the original group.
@@ -41,10 +61,19 @@ func (g *Group) Lock() *LockedGroup {
 
 // NewGroup adds a sub-group to this group.
 func (g *Group) NewGroup(path string) *Group {
+	path = joinPath(g.path, path)
 	return &Group{
-		path:  joinPath(g.path, path),
-		mux:   g.mux,
-		stack: g.stack[:len(g.stack):len(g.stack)],
+		path:     path,
+		mux:      g.mux,
+		stack:    g.stack[:len(g.stack):len(g.stack)],
+		names:    g.names[:len(g.names):len(g.names)],
+		root:     g.root,
+		host:     g.host,
+		shards:   g.shards,
+		shard:    g.shard,
+		handlers: newGroupHandlers(g.handlers, g.host, path),
+
+		routeNames: g.routeNames,
 	}
 }
 
@@ -55,6 +84,15 @@ func (g *Group) WithGroup(path string, fn func(g *Group)) {
 // Use appends a middleware handler to the Group middleware stack.
 func (g *Group) Use(fn MiddlewareFunc) {
 	g.stack = append(g.stack, fn)
+	g.names = append(g.names, "")
+}
+
+// UseNamed is like Use, but also registers the middleware name. Names of the middlewares
+// that wrap a route are available in RouteInfo.Middlewares and the time spent in each named
+// middleware is reported to TreeMux.MiddlewareTimer.
+func (g *Group) UseNamed(name string, fn MiddlewareFunc) {
+	g.stack = append(g.stack, timedMiddleware(g.mux, name, fn))
+	g.names = append(g.names, name)
 }
 
 // UseHandler is like Use, but handler can't modify the request.
@@ -68,6 +106,18 @@ func (g *Group) UseHandler(fn HandlerFunc) {
 		}
 	}
 	g.stack = append(g.stack, middleware)
+	g.names = append(g.names, "")
+}
+
+// tree returns the tree the route is added to.
+func (g *Group) tree(route string) *node {
+	if g.host == "" && g.mux.ShardByFirstSegment {
+		return g.shardTree(route)
+	}
+	if g.root != nil {
+		return g.root
+	}
+	return g.mux.root
 }
 
 // Path elements starting with : indicate a wildcard in the path. A wildcard will only match on a
@@ -78,6 +128,17 @@ func (g *Group) UseHandler(fn HandlerFunc) {
 // in the URL matched by the wildcards. For example, with a pattern of `/images/*path` and a
 // requested URL `images/abc/def`, path would contain `abc/def`.
 //
+// A backslash escapes ':', '*', and '\' anywhere in a segment, so `/\:literal` and
+// `/v1\:batch` match the paths "/:literal" and "/v1:batch". Since ':' and '*' are only
+// special at the start of a segment, RouteInfo.Route keeps only the escapes needed there,
+// e.g. `/v1:batch`.
+//
+// A wildcard can declare its type after a pipe, e.g. `/posts/:id|int` or `/docs/:slug|uuid`.
+// Requests with values that don't match the type are rejected with NotFoundHandler and the
+// decoded values are available via Request.ParamValue. The built-in types are string, int,
+// int64, uint64, float64, bool, and uuid; custom types can be added with
+// TreeMux.RegisterParamType.
+//
 // # Routing Rule Priority
 //
 // The priority rules in the router are simple.
@@ -132,93 +193,226 @@ func (g *Group) UseHandler(fn HandlerFunc) {
 // 	GET /posts will redirect to /posts/.
 // 	GET /posts/ will match normally.
 // 	POST /posts will redirect to /posts/, because the GET method used a trailing slash.
-func (g *Group) Handle(method string, path string, handler HandlerFunc) {
+//
+// Middlewares passed to Handle are applied only to the route, after the middlewares
+// of the group:
+//
+//	router.POST("/upload", uploadHandler, rateLimit, maxBodySize)
+//
+// Handle returns a Route that can be used to attach metadata to the route.
+func (g *Group) Handle(method string, path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
 	g.mux.mutex.Lock()
 	defer g.mux.mutex.Unlock()
 
+	return g.handle(method, path, handler, middlewares)
+}
+
+// handle adds the route. The caller must hold g.mux.mutex.
+func (g *Group) handle(method string, path string, handler HandlerFunc, middlewares []MiddlewareFunc) *Route {
+	return g.handlePaths(method, []string{path}, handler, middlewares)
+}
+
+// handlePaths adds one route matching all the paths. The first path is the route
+// template. The caller must hold g.mux.mutex.
+func (g *Group) handlePaths(
+	method string, paths []string, handler HandlerFunc, middlewares []MiddlewareFunc,
+) *Route {
+	info := &RouteInfo{
+		Host:            g.host,
+		Method:          method,
+		middlewareCount: len(g.stack) + len(middlewares),
+		handlers:        g.handlers,
+	}
+	info.Handler, info.File, info.Line = funcInfo(handler)
+	if g.mux.RecordCallers {
+		info.Caller = registrationCaller()
+		defer annotateConflict(info)
+	}
+	handler = routeHandler(g.mux, info, handler)
+	if len(middlewares) > 0 {
+		handler = handlerWithMiddlewares(handler, middlewares)
+	}
 	if len(g.stack) > 0 {
 		handler = handlerWithMiddlewares(handler, g.stack)
 	}
 
+	for _, name := range g.names {
+		if name != "" {
+			info.Middlewares = append(info.Middlewares, name)
+		}
+	}
+
 	var addSlash bool
 	addOne := func(fullPath string) {
-		node := g.mux.root.addPath(fullPath[1:], nil, false)
+		root := g.tree(fullPath)
+		if root.arena == nil && g.mux.NodeArenaSize > 0 {
+			root.arena = newNodeArena(g.mux.NodeArenaSize)
+		}
+		b := treeBuilder{arena: root.arena, deferSort: g.mux.batching}
+		node := root.addPathWith(b, fullPath[1:], nil, false)
+		root.addStaticRoute(fullPath, node)
 		if node.route == "" {
 			node.route = fullPath
 		} else if node.route != fullPath {
-			panic(fmt.Errorf("%q != %q", node.route, fullPath))
+			panic(fmt.Errorf("%q%s != %q", node.route, registeredAt(node.handlerMap.anyRoute()), fullPath))
 		}
 		if addSlash {
 			node.addSlash = true
 		}
 		node.setHandler(method, handler, false)
+		node.handlerMap.SetRoute(method, info)
 
 		if g.mux.HeadCanUseGet &&
 			method == http.MethodGet &&
 			node.handlerMap.Get(http.MethodHead) == nil {
 			node.setHandler(http.MethodHead, handler, true)
+			node.handlerMap.SetRoute(http.MethodHead, info)
 		}
-	}
 
-	checkPath(path)
-	path = g.path + path
-	if len(path) == 0 {
-		panic("Cannot map an empty path")
+		if method == anyMethod && node.handlerMap.isImplicit(http.MethodOptions) {
+			// The handler of any method also handles OPTIONS.
+			node.handlerMap.Set(http.MethodOptions, nil)
+			node.handlerMap.implicitOptions = false
+		}
+		if g.mux.AutoOptions &&
+			method != http.MethodOptions &&
+			node.handlerMap.Lookup(http.MethodOptions) == nil {
+			options := autoOptionsHandler(g.mux, node.handlerMap)
+			node.setHandler(http.MethodOptions, handlerWithMiddlewares(options, g.stack), true)
+		}
 	}
 
-	if len(path) > 1 && path[len(path)-1] == '/' && g.mux.RedirectTrailingSlash {
-		addSlash = true
-		path = path[:len(path)-1]
-	}
+	for i, path := range paths {
+		addSlash = false
+		checkPath(path)
+		path = g.path + path
+		if len(path) == 0 {
+			panic("Cannot map an empty path")
+		}
+
+		var braceTypes map[string]*ParamType
+		if g.mux.PathSyntax == BraceSyntax {
+			path, braceTypes = convertBraceParams(path)
+		}
+		path, types := g.mux.parsePatternTypes(path)
+		for name, typ := range braceTypes {
+			if types == nil {
+				types = make(map[string]*ParamType)
+			}
+			types[name] = typ
+		}
+		path = unescapeRoute(path)
+		if i > 0 {
+			// The param types are declared by the route template.
+			types = nil
+		}
+		for name, typ := range types {
+			if info.ParamTypes == nil {
+				info.ParamTypes = make(map[string]*ParamType)
+			}
+			info.ParamTypes[name] = typ
+			info.patternTypes = append(info.patternTypes, name)
+		}
 
-	if g.mux.EscapeAddedRoutes {
-		u, err := url.ParseRequestURI(path)
-		if err != nil {
-			panic("URL parsing error " + err.Error() + " on url " + path)
+		if len(path) > 1 && path[len(path)-1] == '/' && g.mux.RedirectTrailingSlash {
+			addSlash = true
+			path = path[:len(path)-1]
+		}
+		if g.mux.CanonicalPaths {
+			path = canonicalRoute(path)
+		}
+		if g.mux.CaseInsensitive {
+			path = foldRoute(path)
+		}
+		if i == 0 {
+			info.Route = path
+			info.trailingSlash = addSlash
 		}
-		escapedPath := unescapeSpecial(u.String())
 
-		if escapedPath != path {
-			addOne(escapedPath)
+		if g.mux.EscapeAddedRoutes && !g.mux.CanonicalPaths {
+			u, err := url.ParseRequestURI(path)
+			if err != nil {
+				panic("URL parsing error " + err.Error() + " on url " + path)
+			}
+			escapedPath := unescapeSpecial(u.String())
+			if g.mux.CaseInsensitive {
+				escapedPath = foldRoute(escapedPath)
+			}
+
+			if escapedPath != path {
+				addOne(escapedPath)
+			}
 		}
+
+		addOne(path)
 	}
 
-	addOne(path)
+	return &Route{mux: g.mux, info: info, names: g.routeNames}
+}
+
+// Syntactic sugar for Handle("GET", path, handler, middlewares...)
+func (g *Group) GET(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Handle("GET", path, handler, middlewares...)
+}
+
+// Syntactic sugar for Handle("POST", path, handler, middlewares...)
+func (g *Group) POST(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Handle("POST", path, handler, middlewares...)
+}
+
+// Syntactic sugar for Handle("PUT", path, handler, middlewares...)
+func (g *Group) PUT(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Handle("PUT", path, handler, middlewares...)
+}
+
+// Syntactic sugar for Handle("DELETE", path, handler, middlewares...)
+func (g *Group) DELETE(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Handle("DELETE", path, handler, middlewares...)
+}
+
+// Syntactic sugar for Handle("PATCH", path, handler, middlewares...)
+func (g *Group) PATCH(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Handle("PATCH", path, handler, middlewares...)
+}
+
+// Syntactic sugar for Handle("HEAD", path, handler, middlewares...)
+func (g *Group) HEAD(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Handle("HEAD", path, handler, middlewares...)
 }
 
-// Syntactic sugar for Handle("GET", path, handler)
-func (g *Group) GET(path string, handler HandlerFunc) {
-	g.Handle("GET", path, handler)
+// Syntactic sugar for Handle("OPTIONS", path, handler, middlewares...)
+func (g *Group) OPTIONS(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Handle("OPTIONS", path, handler, middlewares...)
 }
 
-// Syntactic sugar for Handle("POST", path, handler)
-func (g *Group) POST(path string, handler HandlerFunc) {
-	g.Handle("POST", path, handler)
+// Syntactic sugar for Handle("PROPFIND", path, handler, middlewares...)
+func (g *Group) PROPFIND(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Handle("PROPFIND", path, handler, middlewares...)
 }
 
-// Syntactic sugar for Handle("PUT", path, handler)
-func (g *Group) PUT(path string, handler HandlerFunc) {
-	g.Handle("PUT", path, handler)
+// Syntactic sugar for Handle("MKCOL", path, handler, middlewares...)
+func (g *Group) MKCOL(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Handle("MKCOL", path, handler, middlewares...)
 }
 
-// Syntactic sugar for Handle("DELETE", path, handler)
-func (g *Group) DELETE(path string, handler HandlerFunc) {
-	g.Handle("DELETE", path, handler)
+// Syntactic sugar for Handle("COPY", path, handler, middlewares...)
+func (g *Group) COPY(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Handle("COPY", path, handler, middlewares...)
 }
 
-// Syntactic sugar for Handle("PATCH", path, handler)
-func (g *Group) PATCH(path string, handler HandlerFunc) {
-	g.Handle("PATCH", path, handler)
+// Syntactic sugar for Handle("MOVE", path, handler, middlewares...)
+func (g *Group) MOVE(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Handle("MOVE", path, handler, middlewares...)
 }
 
-// Syntactic sugar for Handle("HEAD", path, handler)
-func (g *Group) HEAD(path string, handler HandlerFunc) {
-	g.Handle("HEAD", path, handler)
+// Syntactic sugar for Handle("LOCK", path, handler, middlewares...)
+func (g *Group) LOCK(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Handle("LOCK", path, handler, middlewares...)
 }
 
-// Syntactic sugar for Handle("OPTIONS", path, handler)
-func (g *Group) OPTIONS(path string, handler HandlerFunc) {
-	g.Handle("OPTIONS", path, handler)
+// Syntactic sugar for Handle("UNLOCK", path, handler, middlewares...)
+func (g *Group) UNLOCK(path string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return g.Handle("UNLOCK", path, handler, middlewares...)
 }
 
 func joinPath(base, path string) string {
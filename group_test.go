@@ -3,6 +3,7 @@ package treemux
 import (
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 )
 
@@ -172,3 +173,32 @@ func TestSetGetAfterHead(t *testing.T) {
 	testMethod("HEAD", "HEAD")
 	testMethod("GET", "GET")
 }
+
+func TestMiddlewareChainsComposedOnce(t *testing.T) {
+	calls := make(map[string]int)
+	counted := func(name string) MiddlewareFunc {
+		return func(next HandlerFunc) HandlerFunc {
+			calls[name]++
+			return next
+		}
+	}
+
+	router := New()
+	router.Use(counted("global"))
+	api := router.NewGroup("/api")
+	api.Use(counted("group"))
+	api.UseNamed("named", counted("named"))
+	api.GET("/users", simpleHandler, counted("route"))
+	api.Route("/posts").GET(simpleHandler).Use(counted("builder"))
+
+	want := map[string]int{"global": 1, "group": 2, "named": 2, "route": 1, "builder": 1}
+	for i := 0; i < 3; i++ {
+		for _, path := range []string{"/api/users", "/api/posts", "/missing"} {
+			r, _ := newRequest("GET", path, nil)
+			router.ServeHTTP(httptest.NewRecorder(), r)
+		}
+		if !reflect.DeepEqual(calls, want) {
+			t.Fatalf("got middleware calls %v, wanted %v", calls, want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package treemux
+
+import (
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// NormalizeHost returns the canonical form of a host name: lower case, without
+// the trailing dot, and with international labels encoded using punycode.
+// The port, if any, is preserved. It is applied to Host values before host-based
+// matching, so patterns can be written either in Unicode or in punycode.
+//
+//	NormalizeHost("Bücher.Example.") == "xn--bcher-kva.example"
+func NormalizeHost(host string) string {
+	hostname, port := host, ""
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		hostname, port = h, p
+	}
+
+	if strings.HasPrefix(hostname, "[") || strings.IndexByte(hostname, ':') >= 0 {
+		// IPv6 literals only need lower casing.
+		return strings.ToLower(host)
+	}
+
+	hostname = strings.TrimSuffix(hostname, ".")
+	hostname = strings.ToLower(hostname)
+
+	if !isASCII(hostname) {
+		labels := strings.Split(hostname, ".")
+		for i, label := range labels {
+			if !isASCII(label) && utf8.ValidString(label) {
+				labels[i] = "xn--" + punycodeEncode(label)
+			}
+		}
+		hostname = strings.Join(labels, ".")
+	}
+
+	if port != "" {
+		return net.JoinHostPort(hostname, port)
+	}
+	return hostname
+}
+
+// HostNormalizer returns a handler that normalizes the request Host with NormalizeHost
+// before passing the request to the next handler, usually the router.
+//
+//	http.ListenAndServe(":8080", treemux.HostNormalizer(router))
+func HostNormalizer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Host = NormalizeHost(r.Host)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Host returns a group whose routes only match requests for the host. Every host has
+// an independent routing tree, and requests for hosts without their own tree are routed
+// using the default tree of the router. The host is normalized with NormalizeHost.
+// If it has no port, requests for the host with any port match.
+//
+//	api := router.Host("api.example.com")
+//	api.GET("/users/:id", getUser)
+//	router.GET("/", home) // any other host
+//
+// The group has the middlewares of the router. Host trees are not affected by Reload.
+func (t *TreeMux) Host(host string) *Group {
+	host = NormalizeHost(host)
+
+	t.mutex.Lock()
+	root, ok := t.hosts[host]
+	if !ok {
+		root = &node{path: "/"}
+		if t.hosts == nil {
+			t.hosts = make(map[string]*node)
+		}
+		t.hosts[host] = root
+	}
+	t.mutex.Unlock()
+
+	g := t.Group.NewGroup("")
+	g.root = root
+	g.host = host
+	return g
+}
+
+// hostTree returns the routing tree for the request host.
+func (t *TreeMux) hostTree(r *http.Request) *node {
+	if len(t.hosts) == 0 {
+		return t.root
+	}
+	host := NormalizeHost(r.Host)
+	if root, ok := t.hosts[host]; ok {
+		return root
+	}
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		if root, ok := t.hosts[hostname]; ok {
+			return root
+		}
+	}
+	return t.root
+}
+
+// trees returns the default tree and its shards sorted by segment followed by the host
+// trees sorted by host.
+func (t *TreeMux) trees() []*node {
+	hosts := make([]string, 0, len(t.hosts))
+	for host := range t.hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	trees := make([]*node, 0, 1+len(t.shards)+len(hosts))
+	trees = append(trees, t.root)
+	for _, key := range t.shardKeys() {
+		trees = append(trees, t.shards[key])
+	}
+	for _, host := range hosts {
+		trees = append(trees, t.hosts[host])
+	}
+	return trees
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// Punycode parameters from RFC 3492.
+const (
+	punyBase        = 36
+	punyTMin        = 1
+	punyTMax        = 26
+	punySkew        = 38
+	punyDamp        = 700
+	punyInitialBias = 72
+	punyInitialN    = 128
+)
+
+// punycodeEncode encodes the label using the punycode algorithm from RFC 3492.
+func punycodeEncode(label string) string {
+	runes := []rune(label)
+	out := make([]byte, 0, 2*len(label))
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			out = append(out, byte(r))
+		}
+	}
+	basic := len(out)
+	handled := basic
+	if basic > 0 {
+		out = append(out, '-')
+	}
+
+	n, delta, bias := rune(punyInitialN), 0, punyInitialBias
+	for handled < len(runes) {
+		m := rune(utf8.MaxRune)
+		for _, r := range runes {
+			if r >= n && r < m {
+				m = r
+			}
+		}
+		delta += int(m-n) * (handled + 1)
+		n = m
+
+		for _, r := range runes {
+			if r < n {
+				delta++
+			}
+			if r != n {
+				continue
+			}
+
+			q := delta
+			for k := punyBase; ; k += punyBase {
+				t := k - bias
+				if t < punyTMin {
+					t = punyTMin
+				} else if t > punyTMax {
+					t = punyTMax
+				}
+				if q < t {
+					break
+				}
+				out = append(out, punycodeDigit(t+(q-t)%(punyBase-t)))
+				q = (q - t) / (punyBase - t)
+			}
+			out = append(out, punycodeDigit(q))
+
+			bias = punycodeAdapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+		delta++
+		n++
+	}
+	return string(out)
+}
+
+func punycodeDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
+
+func punycodeAdapt(delta, numPoints int, first bool) int {
+	if first {
+		delta /= punyDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+
+	k := 0
+	for delta > ((punyBase-punyTMin)*punyTMax)/2 {
+		delta /= punyBase - punyTMin
+		k += punyBase
+	}
+	return k + (punyBase-punyTMin+1)*delta/(delta+punySkew)
+}
@@ -0,0 +1,93 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNormalizeHost(t *testing.T) {
+	tests := []struct {
+		host   string
+		wanted string
+	}{
+		{"Example.COM", "example.com"},
+		{"example.com.", "example.com"},
+		{"example.com:8080", "example.com:8080"},
+		{"Bücher.Example.", "xn--bcher-kva.example"},
+		{"münchen.de:443", "xn--mnchen-3ya.de:443"},
+		{"пример.испытание", "xn--e1afmkfd.xn--80akhbyknj4f"},
+		{"例え.テスト", "xn--r8jz45g.xn--zckzah"},
+		{"xn--bcher-kva.example", "xn--bcher-kva.example"},
+		{"[::1]:80", "[::1]:80"},
+		{"127.0.0.1", "127.0.0.1"},
+	}
+	for _, test := range tests {
+		if got := NormalizeHost(test.host); got != test.wanted {
+			t.Errorf("NormalizeHost(%q) = %q, wanted %q", test.host, got, test.wanted)
+		}
+	}
+}
+
+func TestHostNormalizer(t *testing.T) {
+	var host string
+	handler := HostNormalizer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+	}))
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Host = "Bücher.example."
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	if host != "xn--bcher-kva.example" {
+		t.Errorf("got host %q", host)
+	}
+}
+
+func TestHostRouting(t *testing.T) {
+	var got string
+	handler := func(name string) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			got = name + " " + req.Param("id")
+			return nil
+		}
+	}
+
+	router := New()
+	router.GET("/users/:id", handler("default"))
+	router.Host("API.example.com").GET("/users/:id", handler("api"))
+	router.Host("admin.example.com:8443").NewGroup("/admin").GET("/users/:id", handler("admin"))
+
+	tests := []struct {
+		host string
+		path string
+		code int
+		want string
+	}{
+		{"example.com", "/users/1", 200, "default 1"},
+		{"api.example.com", "/users/2", 200, "api 2"},
+		{"api.example.com:8080", "/users/3", 200, "api 3"},
+		{"Api.Example.Com.", "/users/4", 200, "api 4"},
+		{"admin.example.com:8443", "/admin/users/5", 200, "admin 5"},
+		{"admin.example.com", "/admin/users/6", 404, ""},
+		{"api.example.com", "/admin/users/7", 404, ""},
+	}
+	for _, test := range tests {
+		got = ""
+		r, _ := newRequest("GET", test.path, nil)
+		r.Host = test.host
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.code || got != test.want {
+			t.Errorf("%s%s: got code %d and %q, wanted %d and %q",
+				test.host, test.path, w.Code, got, test.code, test.want)
+		}
+	}
+
+	if stats := router.TreeStats(); stats.Routes != 3 {
+		t.Errorf("got %d routes, wanted 3", stats.Routes)
+	}
+	if infos := router.routeInfos(); infos[len(infos)-1].Host != "api.example.com" {
+		t.Errorf("got host %q", infos[len(infos)-1].Host)
+	}
+}
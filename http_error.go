@@ -0,0 +1,83 @@
+package treemux
+
+import (
+	"errors"
+	"net/http"
+)
+
+// HTTPError is an error with the HTTP status code of the response. Handlers return
+// it to choose the status code and the message rendered by the ErrorHandler:
+//
+//	if user == nil {
+//		return &treemux.HTTPError{Code: http.StatusNotFound, Message: "user not found"}
+//	}
+type HTTPError struct {
+	Code int
+	// Message is sent to the client. The status text is used if it is empty.
+	Message string
+	// Err is the underlying error. It is not sent to the client.
+	Err error
+}
+
+// NewHTTPError returns an HTTPError with the status code and the message.
+func NewHTTPError(code int, message string) *HTTPError {
+	return &HTTPError{Code: code, Message: message}
+}
+
+func (e *HTTPError) Error() string {
+	s := e.message()
+	if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	return s
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the status code of the error.
+func (e *HTTPError) StatusCode() int {
+	return e.Code
+}
+
+func (e *HTTPError) message() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return http.StatusText(e.Code)
+}
+
+// ErrorStatusCode returns the status code of the first error in the chain that
+// implements StatusCode() int, e.g. *HTTPError or *ParamError, or 500.
+func ErrorStatusCode(err error) int {
+	var sc interface{ StatusCode() int }
+	if errors.As(err, &sc) {
+		if code := sc.StatusCode(); code != 0 {
+			return code
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// DefaultErrorHandler is the default TreeMux.ErrorHandler. It responds with the
+// status code of ErrorStatusCode and the message of the *HTTPError in the chain, or
+// the error text for other 4xx errors. 5xx responses only contain the status text
+// unless the message is set explicitly with HTTPError, so internal details don't leak
+// to clients.
+func DefaultErrorHandler(w http.ResponseWriter, req Request, err error) {
+	code := ErrorStatusCode(err)
+	message := http.StatusText(code)
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.Code == code {
+		message = httpErr.message()
+	} else if code < 500 {
+		message = err.Error()
+		if routeErr, ok := err.(*RouteError); ok {
+			message = routeErr.Err.Error()
+		}
+	}
+
+	http.Error(w, message, code)
+}
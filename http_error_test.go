@@ -0,0 +1,64 @@
+package treemux
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDefaultErrorHandler(t *testing.T) {
+	router := New()
+	router.WrapErrors = true
+	router.GET("/missing", func(w http.ResponseWriter, req Request) error {
+		return &HTTPError{Code: http.StatusNotFound, Message: "user not found", Err: errors.New("no rows")}
+	})
+	router.GET("/conflict", func(w http.ResponseWriter, req Request) error {
+		return fmt.Errorf("update: %w", NewHTTPError(http.StatusConflict, ""))
+	})
+	router.GET("/param/:id", func(w http.ResponseWriter, req Request) error {
+		var id int
+		return req.Params.Decode("id", &id)
+	})
+	router.GET("/internal", func(w http.ResponseWriter, req Request) error {
+		return errors.New("connection refused")
+	})
+
+	tests := []struct {
+		path    string
+		code    int
+		message string
+	}{
+		{"/missing", http.StatusNotFound, "user not found"},
+		{"/conflict", http.StatusConflict, "Conflict"},
+		{"/param/x", http.StatusBadRequest, "id"},
+		{"/internal", http.StatusInternalServerError, "Internal Server Error"},
+	}
+	for _, test := range tests {
+		r, _ := newRequest("GET", test.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != test.code {
+			t.Errorf("%s: got code %d, wanted %d", test.path, w.Code, test.code)
+		}
+		body := w.Body.String()
+		if !strings.Contains(body, test.message) || strings.Contains(body, "GET /") {
+			t.Errorf("%s: got body %q, wanted %q", test.path, body, test.message)
+		}
+	}
+}
+
+func TestErrorStatusCode(t *testing.T) {
+	err := &HTTPError{Code: http.StatusTeapot, Err: errors.New("cold")}
+	if got := ErrorStatusCode(fmt.Errorf("brew: %w", err)); got != http.StatusTeapot {
+		t.Errorf("got %d", got)
+	}
+	if got := err.Error(); got != "I'm a teapot: cold" {
+		t.Errorf("got message %q", got)
+	}
+	if got := ErrorStatusCode(errors.New("x")); got != http.StatusInternalServerError {
+		t.Errorf("got %d", got)
+	}
+}
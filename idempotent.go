@@ -0,0 +1,45 @@
+package treemux
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// MetaIdempotent is the route metadata key that marks whether the route is safe to retry.
+// The value must be a bool.
+const MetaIdempotent = "idempotent"
+
+// IdempotentHeader is the response header set by the IdempotencyHeader middleware.
+const IdempotentHeader = "X-Idempotent"
+
+// Idempotent reports whether requests to the route are safe to retry. Routes can be
+// marked explicitly with the MetaIdempotent route metadata, for example, a POST route
+// that deduplicates requests by an idempotency key. Otherwise the result is based on
+// the method semantics defined by RFC 7231.
+func (r *RouteInfo) Idempotent() bool {
+	if idempotent, ok := r.Bool(MetaIdempotent); ok {
+		return idempotent
+	}
+	if r == nil {
+		return false
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// IdempotencyHeader is a middleware that advertises whether the route is safe to retry
+// using the X-Idempotent response header, so clients and proxies can decide
+// whether a failed request can be retried.
+func IdempotencyHeader(next HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, req Request) error {
+		if info := req.RouteInfo(); info != nil {
+			w.Header().Set(IdempotentHeader, strconv.FormatBool(info.Idempotent()))
+		}
+		return next(w, req)
+	}
+}
@@ -0,0 +1,39 @@
+package treemux
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIdempotent(t *testing.T) {
+	router := New()
+	router.Use(IdempotencyHeader)
+	router.GET("/items", simpleHandler)
+	router.POST("/items", simpleHandler)
+	router.POST("/payments", simpleHandler).Meta(MetaIdempotent, true)
+	router.PUT("/legacy", simpleHandler).Meta(MetaIdempotent, false)
+
+	tests := []struct {
+		method, path, wanted string
+	}{
+		{"GET", "/items", "true"},
+		{"HEAD", "/items", "true"},
+		{"POST", "/items", "false"},
+		{"POST", "/payments", "true"},
+		{"PUT", "/legacy", "false"},
+	}
+	for _, test := range tests {
+		r, _ := newRequest(test.method, test.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if got := w.Header().Get(IdempotentHeader); got != test.wanted {
+			t.Errorf("%s %s: got %q, wanted %q", test.method, test.path, got, test.wanted)
+		}
+	}
+
+	var info *RouteInfo
+	if info.Idempotent() {
+		t.Error("nil route must not be idempotent")
+	}
+}
@@ -0,0 +1,137 @@
+package treemux
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// Inspection is the part of a request passed to an inspector.
+type Inspection struct {
+	Method string
+	// Route is the route template, e.g. "/users/:id".
+	Route  string
+	Params Params
+	Header http.Header
+	// Body contains up to the first InspectConfig.MaxBodyPreview bytes of the body.
+	Body []byte
+	// Truncated reports whether the body is longer than the preview.
+	Truncated bool
+}
+
+// Verdict is the decision of an inspector.
+type Verdict struct {
+	// Block rejects the request with 403 Forbidden.
+	Block bool
+	// Reason is sent in the body of blocked requests. The default is "Forbidden".
+	Reason string
+	// Tags are attached to allowed requests and can be retrieved with InspectionTags,
+	// e.g. to log suspicious requests or to rate limit them harder.
+	Tags []string
+}
+
+// InspectConfig configures the Inspect middleware.
+type InspectConfig struct {
+	// Inspect returns the verdict for the request. An error fails the request.
+	Inspect func(ctx context.Context, in *Inspection) (Verdict, error)
+	// MaxBodyPreview is the maximum number of body bytes passed to the inspector.
+	// The default is 4KB. Negative value disables the body preview.
+	MaxBodyPreview int64
+	// OnBlock is called when a request is blocked.
+	OnBlock func(req Request, verdict Verdict)
+}
+
+type inspectionTagsKey struct{}
+
+// InspectionTags returns the tags attached to the request by the inspector.
+func InspectionTags(req Request) []string {
+	tags, _ := req.Context().Value(inspectionTagsKey{}).([]string)
+	return tags
+}
+
+// Inspect returns a middleware that passes requests to an inspector before they reach
+// the handler, which makes it possible to bolt a WAF-style rules engine onto the router.
+// The inspector sees the matched route and a bounded preview of the body, which is
+// still available to the handler in full.
+//
+//	router.Use(treemux.Inspect(treemux.InspectConfig{
+//		Inspect: func(ctx context.Context, in *treemux.Inspection) (treemux.Verdict, error) {
+//			if bytes.Contains(in.Body, []byte("<script")) {
+//				return treemux.Verdict{Block: true, Reason: "xss"}, nil
+//			}
+//			return treemux.Verdict{}, nil
+//		},
+//	}))
+func Inspect(cfg InspectConfig) MiddlewareFunc {
+	if cfg.MaxBodyPreview == 0 {
+		cfg.MaxBodyPreview = 4 << 10
+	}
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			in := &Inspection{
+				Method: req.Method,
+				Route:  req.Route(),
+				Params: req.Params,
+				Header: req.Header,
+			}
+			if cfg.MaxBodyPreview > 0 {
+				var err error
+				in.Body, in.Truncated, err = previewBody(&req, cfg.MaxBodyPreview)
+				if err != nil {
+					return err
+				}
+			}
+
+			verdict, err := cfg.Inspect(req.Context(), in)
+			if err != nil {
+				return err
+			}
+
+			if verdict.Block {
+				if cfg.OnBlock != nil {
+					cfg.OnBlock(req, verdict)
+				}
+				reason := verdict.Reason
+				if reason == "" {
+					reason = http.StatusText(http.StatusForbidden)
+				}
+				http.Error(w, reason, http.StatusForbidden)
+				return nil
+			}
+
+			if len(verdict.Tags) > 0 {
+				tags := append(InspectionTags(req), verdict.Tags...)
+				req = req.WithContext(context.WithValue(req.Context(), inspectionTagsKey{}, tags))
+			}
+			return next(w, req)
+		}
+	}
+}
+
+// previewBody reads up to limit first bytes of the body and restores the body,
+// so it can be read again in full.
+func previewBody(req *Request, limit int64) ([]byte, bool, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, false, nil
+	}
+
+	buf, err := ioutil.ReadAll(io.LimitReader(req.Body, limit+1))
+	if err != nil {
+		return nil, false, err
+	}
+
+	r := new(http.Request)
+	*r = *req.Request
+	r.Body = readCloser{
+		Reader: io.MultiReader(bytes.NewReader(buf), req.Body),
+		Closer: req.Body,
+	}
+	req.Request = r
+
+	if int64(len(buf)) > limit {
+		return buf[:limit], true, nil
+	}
+	return buf, false, nil
+}
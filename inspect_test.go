@@ -0,0 +1,74 @@
+package treemux
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	var inspected *Inspection
+	var blocked int
+	router := New()
+	router.Use(Inspect(InspectConfig{
+		Inspect: func(ctx context.Context, in *Inspection) (Verdict, error) {
+			inspected = in
+			if bytes.Contains(in.Body, []byte("<script")) {
+				return Verdict{Block: true, Reason: "xss"}, nil
+			}
+			if in.Header.Get("User-Agent") == "sqlmap" {
+				return Verdict{Tags: []string{"scanner"}}, nil
+			}
+			return Verdict{}, nil
+		},
+		MaxBodyPreview: 8,
+		OnBlock: func(req Request, verdict Verdict) {
+			blocked++
+		},
+	}))
+	router.POST("/users/:id", func(w http.ResponseWriter, req Request) error {
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("X-Tags", strings.Join(InspectionTags(req), ","))
+		_, err = w.Write(b)
+		return err
+	})
+
+	post := func(body, userAgent string) *httptest.ResponseRecorder {
+		r, _ := newRequest("POST", "/users/1", strings.NewReader(body))
+		r.Header.Set("User-Agent", userAgent)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := post("hello world", "curl")
+	if w.Code != http.StatusOK || w.Body.String() != "hello world" {
+		t.Errorf("got %d %q", w.Code, w.Body)
+	}
+	if inspected.Method != "POST" || inspected.Route != "/users/:id" ||
+		!reflect.DeepEqual(inspected.Params, Params{{"id", "1"}}) {
+		t.Errorf("got inspection %+v", inspected)
+	}
+	if string(inspected.Body) != "hello wo" || !inspected.Truncated {
+		t.Errorf("got body preview %q, truncated %v", inspected.Body, inspected.Truncated)
+	}
+
+	if w := post("<script>", "curl"); w.Code != http.StatusForbidden || w.Body.String() != "xss\n" || blocked != 1 {
+		t.Errorf("got %d %q, blocked %d", w.Code, w.Body, blocked)
+	}
+	if inspected.Truncated {
+		t.Error("short body must not be truncated")
+	}
+
+	if w := post("hi", "sqlmap"); w.Code != http.StatusOK || w.Header().Get("X-Tags") != "scanner" {
+		t.Errorf("got %d, tags %q", w.Code, w.Header().Get("X-Tags"))
+	}
+}
@@ -0,0 +1,114 @@
+package treemux
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// MetaOwner is the route metadata key that names the team or person owning the route.
+	// The value must be a string.
+	MetaOwner = "owner"
+	// MetaAuth is the route metadata key that names the authentication scheme of the
+	// route, e.g. "oauth2" or "none". The value must be a string.
+	MetaAuth = "auth"
+	// MetaDeprecation is the route metadata key that marks the route as deprecated.
+	// The value must be a Deprecation or true.
+	MetaDeprecation = "deprecation"
+)
+
+// Deprecation describes a deprecated route.
+type Deprecation struct {
+	// Since is when the route was deprecated. It is optional.
+	Since time.Time
+	// Sunset is when the route will be removed. It is optional.
+	Sunset time.Time
+	// Replacement is the route template or URL to use instead. It is optional.
+	Replacement string
+}
+
+// Inventory is a machine-readable list of the endpoints served by the router, e.g. for
+// API catalogs and governance tooling. It can be marshaled to JSON and loaded by
+// Terraform with jsondecode.
+type Inventory struct {
+	Endpoints []InventoryEndpoint `json:"endpoints"`
+}
+
+// InventoryEndpoint describes a route registered for a single HTTP method.
+type InventoryEndpoint struct {
+	Name   string `json:"name,omitempty"`
+	Host   string `json:"host,omitempty"`
+	Method string `json:"method"`
+	Route  string `json:"route"`
+	// Params contains the param names in path order.
+	Params []string `json:"params,omitempty"`
+	// Owner and Auth are set with the MetaOwner and MetaAuth route metadata.
+	Owner string `json:"owner,omitempty"`
+	Auth  string `json:"auth,omitempty"`
+	// Deprecated is true for routes with the MetaDeprecation metadata, which also
+	// provides the optional Since, Sunset, and Replacement.
+	Deprecated  bool       `json:"deprecated"`
+	Since       *time.Time `json:"since,omitempty"`
+	Sunset      *time.Time `json:"sunset,omitempty"`
+	Replacement string     `json:"replacement,omitempty"`
+	Handler     string     `json:"handler,omitempty"`
+	// Middlewares contains names of the middlewares added with Group.UseNamed.
+	Middlewares []string `json:"middlewares,omitempty"`
+}
+
+// ExportInventory returns the registered routes of all hosts sorted by path and method
+// with their ownership, authentication, and deprecation metadata:
+//
+//	router.GET("/v1/users/:id", getUser).
+//		Meta(treemux.MetaOwner, "identity-team").
+//		Meta(treemux.MetaAuth, "oauth2").
+//		Meta(treemux.MetaDeprecation, treemux.Deprecation{Replacement: "/v2/users/:id"})
+//
+// A GET route that also serves HEAD requests is listed once.
+func (t *TreeMux) ExportInventory() *Inventory {
+	infos := t.routeInfos()
+	inv := &Inventory{Endpoints: make([]InventoryEndpoint, len(infos))}
+	for i, info := range infos {
+		inv.Endpoints[i] = newInventoryEndpoint(info)
+	}
+	return inv
+}
+
+func newInventoryEndpoint(info *RouteInfo) InventoryEndpoint {
+	ep := InventoryEndpoint{
+		Name:    info.Name,
+		Host:    info.Host,
+		Method:  info.Method,
+		Route:   info.Route,
+		Handler: info.Handler,
+	}
+	for _, seg := range strings.Split(info.Route, "/") {
+		if seg != "" && (seg[0] == ':' || seg[0] == '*') {
+			ep.Params = append(ep.Params, seg[1:])
+		}
+	}
+	if len(info.Middlewares) > 0 {
+		ep.Middlewares = append([]string(nil), info.Middlewares...)
+	}
+	if v, ok := info.Value(MetaOwner); ok {
+		ep.Owner, _ = v.(string)
+	}
+	if v, ok := info.Value(MetaAuth); ok {
+		ep.Auth, _ = v.(string)
+	}
+
+	switch v, _ := info.Value(MetaDeprecation); v := v.(type) {
+	case bool:
+		ep.Deprecated = v
+	case Deprecation:
+		ep.Deprecated = true
+		if !v.Since.IsZero() {
+			ep.Since = &v.Since
+		}
+		if !v.Sunset.IsZero() {
+			ep.Sunset = &v.Sunset
+		}
+		ep.Replacement = v.Replacement
+	}
+	return ep
+}
@@ -0,0 +1,66 @@
+package treemux
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExportInventory(t *testing.T) {
+	sunset := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	router := New()
+	v1 := router.NewGroup("/v1")
+	v1.UseNamed("auth", func(next HandlerFunc) HandlerFunc { return next })
+	v1.GET("/users/:id", simpleHandler).
+		Name("getUser").
+		Meta(MetaOwner, "identity").
+		Meta(MetaAuth, "oauth2").
+		Meta(MetaDeprecation, Deprecation{Sunset: sunset, Replacement: "/v2/users/:id"})
+	router.POST("/login", simpleHandler).Meta(MetaAuth, "none").Meta(MetaDeprecation, true)
+	router.Host("api.example.com").GET("/status", simpleHandler)
+
+	inv := router.ExportInventory()
+	if len(inv.Endpoints) != 3 {
+		t.Fatalf("got %d endpoints, wanted 3", len(inv.Endpoints))
+	}
+
+	login := inv.Endpoints[0]
+	if login.Route != "/login" || login.Auth != "none" || !login.Deprecated || login.Sunset != nil {
+		t.Errorf("got login endpoint %+v", login)
+	}
+	if status := inv.Endpoints[1]; status.Host != "api.example.com" || status.Deprecated {
+		t.Errorf("got status endpoint %+v", status)
+	}
+
+	user := inv.Endpoints[2]
+	want := InventoryEndpoint{
+		Name:        "getUser",
+		Method:      "GET",
+		Route:       "/v1/users/:id",
+		Params:      []string{"id"},
+		Owner:       "identity",
+		Auth:        "oauth2",
+		Deprecated:  true,
+		Sunset:      &sunset,
+		Replacement: "/v2/users/:id",
+		Handler:     user.Handler,
+		Middlewares: []string{"auth"},
+	}
+	if !reflect.DeepEqual(user, want) {
+		t.Errorf("got user endpoint %+v, wanted %+v", user, want)
+	}
+
+	b, err := json.Marshal(inv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Inventory
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&got, inv) {
+		t.Errorf("inventory does not survive the JSON round trip:\n%s", b)
+	}
+}
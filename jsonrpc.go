@@ -0,0 +1,182 @@
+package treemux
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	JSONRPCParseError     = -32700
+	JSONRPCInvalidRequest = -32600
+	JSONRPCMethodNotFound = -32601
+	JSONRPCInvalidParams  = -32602
+	JSONRPCInternalError  = -32603
+)
+
+// JSONRPCError is a JSON-RPC error object. Methods can return it to control
+// the error code sent to the client.
+type JSONRPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *JSONRPCError) Error() string {
+	return fmt.Sprintf("jsonrpc: %s (code %d)", e.Message, e.Code)
+}
+
+// JSONRPCMethod handles a JSON-RPC method call. Params contains the raw "params" member
+// of the call and is empty when the member is omitted.
+type JSONRPCMethod func(req Request, params json.RawMessage) (interface{}, error)
+
+// JSONRPC dispatches JSON-RPC 2.0 calls to registered methods. It supports batches and
+// notifications and is mounted as a regular handler, so it reuses the router middlewares:
+//
+//	rpc := treemux.NewJSONRPC()
+//	rpc.Register("sum", sum)
+//	router.POST("/rpc", rpc.ServeJSONRPC)
+type JSONRPC struct {
+	// OnError is called with errors returned by methods that are not *JSONRPCError.
+	// Such errors are reported to the client as internal errors.
+	OnError func(req Request, method string, err error)
+
+	mu      sync.RWMutex
+	methods map[string]JSONRPCMethod
+}
+
+func NewJSONRPC() *JSONRPC {
+	return &JSONRPC{
+		methods: make(map[string]JSONRPCMethod),
+	}
+}
+
+// Register adds a method to the registry.
+func (s *JSONRPC) Register(method string, fn JSONRPCMethod) {
+	s.mu.Lock()
+	s.methods[method] = fn
+	s.mu.Unlock()
+}
+
+type jsonrpcRequest struct {
+	Version string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type jsonrpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *JSONRPCError   `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+var jsonrpcNullID = json.RawMessage("null")
+
+// ServeJSONRPC is a HandlerFunc that serves JSON-RPC calls.
+// Errors reading the request body are returned to the router's ErrorHandler.
+func (s *JSONRPC) ServeJSONRPC(w http.ResponseWriter, req Request) error {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	body = bytes.TrimSpace(body)
+
+	var resp interface{}
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			resp = jsonrpcErrorResponse(nil, JSONRPCParseError, "parse error")
+		} else if len(batch) == 0 {
+			resp = jsonrpcErrorResponse(nil, JSONRPCInvalidRequest, "invalid request")
+		} else {
+			responses := make([]*jsonrpcResponse, 0, len(batch))
+			for _, call := range batch {
+				if r := s.call(req, call); r != nil {
+					responses = append(responses, r)
+				}
+			}
+			if len(responses) > 0 {
+				resp = responses
+			}
+		}
+	} else if r := s.call(req, body); r != nil {
+		resp = r
+	}
+
+	if resp == nil {
+		// Only notifications were received.
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(resp)
+}
+
+func (s *JSONRPC) call(req Request, b []byte) *jsonrpcResponse {
+	var call jsonrpcRequest
+	if err := json.Unmarshal(b, &call); err != nil {
+		if _, ok := err.(*json.SyntaxError); ok {
+			return jsonrpcErrorResponse(nil, JSONRPCParseError, "parse error")
+		}
+		return jsonrpcErrorResponse(nil, JSONRPCInvalidRequest, "invalid request")
+	}
+	if call.Version != "2.0" || call.Method == "" {
+		return jsonrpcErrorResponse(call.ID, JSONRPCInvalidRequest, "invalid request")
+	}
+	notification := call.ID == nil
+
+	s.mu.RLock()
+	fn := s.methods[call.Method]
+	s.mu.RUnlock()
+
+	if fn == nil {
+		if notification {
+			return nil
+		}
+		return jsonrpcErrorResponse(call.ID, JSONRPCMethodNotFound, "method not found")
+	}
+
+	result, err := fn(req, call.Params)
+	if notification {
+		if err != nil {
+			s.reportError(req, call.Method, err)
+		}
+		return nil
+	}
+	if err != nil {
+		rpcErr, ok := err.(*JSONRPCError)
+		if !ok {
+			s.reportError(req, call.Method, err)
+			rpcErr = &JSONRPCError{Code: JSONRPCInternalError, Message: "internal error"}
+		}
+		return &jsonrpcResponse{Version: "2.0", Error: rpcErr, ID: call.ID}
+	}
+	if result == nil {
+		result = jsonrpcNullID
+	}
+	return &jsonrpcResponse{Version: "2.0", Result: result, ID: call.ID}
+}
+
+func (s *JSONRPC) reportError(req Request, method string, err error) {
+	if s.OnError != nil {
+		s.OnError(req, method, err)
+	}
+}
+
+func jsonrpcErrorResponse(id json.RawMessage, code int, message string) *jsonrpcResponse {
+	if id == nil {
+		id = jsonrpcNullID
+	}
+	return &jsonrpcResponse{
+		Version: "2.0",
+		Error:   &JSONRPCError{Code: code, Message: message},
+		ID:      id,
+	}
+}
@@ -0,0 +1,98 @@
+package treemux
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestJSONRPC(t *testing.T) {
+	var reported []string
+	rpc := NewJSONRPC()
+	rpc.OnError = func(req Request, method string, err error) {
+		reported = append(reported, method+": "+err.Error())
+	}
+	rpc.Register("sum", func(req Request, params json.RawMessage) (interface{}, error) {
+		var nums []int
+		if err := json.Unmarshal(params, &nums); err != nil {
+			return nil, &JSONRPCError{Code: JSONRPCInvalidParams, Message: "invalid params"}
+		}
+		var sum int
+		for _, n := range nums {
+			sum += n
+		}
+		return sum, nil
+	})
+	rpc.Register("fail", func(req Request, params json.RawMessage) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+
+	router := New()
+	router.POST("/rpc", rpc.ServeJSONRPC)
+
+	call := func(body string) (int, string) {
+		r, _ := newRequest("POST", "/rpc", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Code, strings.TrimSpace(w.Body.String())
+	}
+
+	tests := []struct {
+		body   string
+		code   int
+		wanted string
+	}{
+		{
+			`{"jsonrpc": "2.0", "method": "sum", "params": [1, 2, 3], "id": 1}`,
+			200, `{"jsonrpc":"2.0","result":6,"id":1}`,
+		},
+		{
+			`{"jsonrpc": "2.0", "method": "sum", "params": {}, "id": "a"}`,
+			200, `{"jsonrpc":"2.0","error":{"code":-32602,"message":"invalid params"},"id":"a"}`,
+		},
+		{
+			`{"jsonrpc": "2.0", "method": "missing", "id": 2}`,
+			200, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"method not found"},"id":2}`,
+		},
+		{
+			`{"jsonrpc": "2.0", "method": "fail", "id": 3}`,
+			200, `{"jsonrpc":"2.0","error":{"code":-32603,"message":"internal error"},"id":3}`,
+		},
+		{
+			`{"jsonrpc": "2.0", "method"`,
+			200, `{"jsonrpc":"2.0","error":{"code":-32700,"message":"parse error"},"id":null}`,
+		},
+		{
+			`[]`,
+			200, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request"},"id":null}`,
+		},
+		{
+			`[
+				{"jsonrpc": "2.0", "method": "sum", "params": [1], "id": 1},
+				{"jsonrpc": "2.0", "method": "sum", "params": [2]},
+				{"foo": "bar"},
+				{"jsonrpc": "2.0", "method": "sum", "params": [3], "id": 2}
+			]`,
+			200, `[{"jsonrpc":"2.0","result":1,"id":1},` +
+				`{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request"},"id":null},` +
+				`{"jsonrpc":"2.0","result":3,"id":2}]`,
+		},
+		{
+			`[{"jsonrpc": "2.0", "method": "sum", "params": [1]}]`,
+			http.StatusNoContent, ``,
+		},
+	}
+	for _, test := range tests {
+		code, body := call(test.body)
+		if code != test.code || body != test.wanted {
+			t.Errorf("%s:\ngot %d %s\nwanted %d %s", test.body, code, body, test.code, test.wanted)
+		}
+	}
+
+	if len(reported) != 1 || reported[0] != "fail: boom" {
+		t.Errorf("got reported errors %q", reported)
+	}
+}
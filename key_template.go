@@ -0,0 +1,124 @@
+package treemux
+
+import (
+	"net"
+	"strings"
+)
+
+// ExpandTemplate substitutes "{name}" placeholders in the template, e.g.
+// "tenant={tenant}:ip={req.ip}", with the values of the request route params.
+// Names with the "req." prefix are reserved for the request properties, so
+// they can't be shadowed by route params:
+//
+//	{req.ip}        the client address without the port
+//	{req.method}    the request method
+//	{req.route}     the route template, e.g. "/users/:id"
+//	{req.host}      the request host
+//	{req.header.X}  the value of the X request header
+//	{req.query.x}   the value of the x query argument
+//
+// Other names resolve to an empty string.
+func (req Request) ExpandTemplate(tmpl string) string {
+	return expandTemplate(tmpl, req.templateVar)
+}
+
+func (req Request) templateVar(name string) string {
+	if !strings.HasPrefix(name, "req.") {
+		return req.Params.Text(name)
+	}
+	name = name[len("req."):]
+	switch {
+	case name == "ip":
+		return remoteIP(req.RemoteAddr)
+	case name == "method":
+		return req.Method
+	case name == "route":
+		return req.route
+	case name == "host":
+		return req.Host
+	case strings.HasPrefix(name, "header."):
+		return req.Header.Get(name[len("header."):])
+	case strings.HasPrefix(name, "query."):
+		return req.URL.Query().Get(name[len("query."):])
+	}
+	return ""
+}
+
+func remoteIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// expandKeys substitutes "{name}" placeholders in the surrogate key templates with
+// the params. The values are escaped with escapeKey.
+func expandKeys(templates []string, params Params) []string {
+	if len(templates) == 0 {
+		return nil
+	}
+
+	lookup := func(name string) string {
+		return escapeKey(params.Text(name))
+	}
+	keys := make([]string, len(templates))
+	for i, tmpl := range templates {
+		keys[i] = expandTemplate(tmpl, lookup)
+	}
+	return keys
+}
+
+// escapeKey percent-encodes the bytes that can't appear in a surrogate key. Commas are
+// encoded too, since Cloudflare separates the keys with them.
+func escapeKey(s string) string {
+	const hex = "0123456789ABCDEF"
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c > ' ' && c < 0x7f && c != ',' && c != '%' {
+			if b.Len() > 0 {
+				b.WriteByte(c)
+			}
+			continue
+		}
+		if b.Len() == 0 {
+			b.Grow(len(s) + 8)
+			b.WriteString(s[:i])
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&15])
+	}
+	if b.Len() == 0 {
+		return s
+	}
+	return b.String()
+}
+
+// expandTemplate substitutes "{name}" placeholders in the template with the values
+// returned by lookup. Unterminated placeholders are kept as is.
+func expandTemplate(tmpl string, lookup func(name string) string) string {
+	if strings.IndexByte(tmpl, '{') == -1 {
+		return tmpl
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		if start == -1 {
+			break
+		}
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end == -1 {
+			break
+		}
+		end += start
+
+		b.WriteString(tmpl[:start])
+		b.WriteString(lookup(tmpl[start+1 : end]))
+		tmpl = tmpl[end+1:]
+	}
+	b.WriteString(tmpl)
+	return b.String()
+}
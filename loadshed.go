@@ -0,0 +1,158 @@
+package treemux
+
+import (
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MetaPriority is the route metadata key that sets the route priority used by
+// the LoadShedder. The value must be an int. Routes without it have priority 0.
+const MetaPriority = "priority"
+
+// RuntimeLoad describes the load of the process.
+type RuntimeLoad struct {
+	Goroutines int
+	// HeapBytes is the number of bytes of allocated heap objects.
+	HeapBytes uint64
+}
+
+// LoadShedConfig configures a LoadShedder.
+type LoadShedConfig struct {
+	// MaxGoroutines is the number of goroutines past which the process is overloaded.
+	// Zero means no limit.
+	MaxGoroutines int
+	// MaxHeapBytes is the heap size past which the process is overloaded.
+	// Zero means no limit.
+	MaxHeapBytes uint64
+	// MinPriority is the lowest route priority that is served while the process
+	// is overloaded. For example, 1 sheds all routes without MetaPriority.
+	MinPriority int
+	// Interval is how often the load is sampled. The default is one second.
+	Interval time.Duration
+	// RetryAfter is sent in the Retry-After header of shed requests.
+	// The default is the sampling interval rounded up to seconds.
+	RetryAfter time.Duration
+	// Sample returns the current load. The default reads the Go runtime statistics.
+	Sample func() RuntimeLoad
+	// OnChange is called when the process becomes overloaded or recovers.
+	OnChange func(overloaded bool, load RuntimeLoad)
+}
+
+// LoadShedder is the last line of defense of an overloaded process: it monitors the
+// number of goroutines and the heap size and, past the thresholds, rejects requests
+// to routes with a priority below MinPriority with 503 Service Unavailable and
+// Retry-After, keeping the capacity for the important routes:
+//
+//	shedder := treemux.NewLoadShedder(treemux.LoadShedConfig{
+//		MaxGoroutines: 10000,
+//		MinPriority:   1,
+//	})
+//	defer shedder.Close()
+//	router.Use(shedder.Middleware)
+//	router.POST("/checkout", checkout).Meta(treemux.MetaPriority, 1)
+type LoadShedder struct {
+	cfg        LoadShedConfig
+	overloaded int32
+	retryAfter string
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewLoadShedder samples the load and starts monitoring it in the background.
+// Close must be called to stop monitoring.
+func NewLoadShedder(cfg LoadShedConfig) *LoadShedder {
+	if cfg.Interval == 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.RetryAfter == 0 {
+		cfg.RetryAfter = cfg.Interval
+	}
+	if cfg.Sample == nil {
+		cfg.Sample = sampleRuntimeLoad
+	}
+
+	s := &LoadShedder{
+		cfg:        cfg,
+		retryAfter: strconv.FormatInt(int64((cfg.RetryAfter+time.Second-1)/time.Second), 10),
+		stop:       make(chan struct{}),
+	}
+	s.Check()
+	go s.monitor()
+	return s
+}
+
+func sampleRuntimeLoad() RuntimeLoad {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return RuntimeLoad{
+		Goroutines: runtime.NumGoroutine(),
+		HeapBytes:  stats.HeapAlloc,
+	}
+}
+
+func (s *LoadShedder) monitor() {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Check()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Check samples the load and updates the state of the shedder. It is called
+// periodically, but can also be called directly.
+func (s *LoadShedder) Check() {
+	load := s.cfg.Sample()
+	overloaded := s.cfg.MaxGoroutines > 0 && load.Goroutines > s.cfg.MaxGoroutines ||
+		s.cfg.MaxHeapBytes > 0 && load.HeapBytes > s.cfg.MaxHeapBytes
+
+	var v int32
+	if overloaded {
+		v = 1
+	}
+	if atomic.SwapInt32(&s.overloaded, v) != v && s.cfg.OnChange != nil {
+		s.cfg.OnChange(overloaded, load)
+	}
+}
+
+// Overloaded reports whether the last sample exceeded the thresholds.
+func (s *LoadShedder) Overloaded() bool {
+	return atomic.LoadInt32(&s.overloaded) == 1
+}
+
+// Close stops monitoring the load.
+func (s *LoadShedder) Close() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+}
+
+// Middleware sheds requests to low-priority routes while the process is overloaded.
+func (s *LoadShedder) Middleware(next HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, req Request) error {
+		if s.Overloaded() && routePriority(req) < s.cfg.MinPriority {
+			w.Header().Set("Retry-After", s.retryAfter)
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return nil
+		}
+		return next(w, req)
+	}
+}
+
+func routePriority(req Request) int {
+	if v, ok := req.RouteInfo().Value(MetaPriority); ok {
+		if n, ok := v.(int); ok {
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,62 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadShedder(t *testing.T) {
+	var goroutines int64 = 10
+	var changes []bool
+	shedder := NewLoadShedder(LoadShedConfig{
+		MaxGoroutines: 100,
+		MinPriority:   1,
+		Interval:      time.Hour,
+		RetryAfter:    1500 * time.Millisecond,
+		Sample: func() RuntimeLoad {
+			return RuntimeLoad{Goroutines: int(atomic.LoadInt64(&goroutines))}
+		},
+		OnChange: func(overloaded bool, load RuntimeLoad) {
+			changes = append(changes, overloaded)
+		},
+	})
+	defer shedder.Close()
+
+	router := New()
+	router.Use(shedder.Middleware)
+	router.GET("/feed", simpleHandler)
+	router.POST("/checkout", simpleHandler).Meta(MetaPriority, 1)
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		r, _ := newRequest(method, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := serve("GET", "/feed"); w.Code != http.StatusOK {
+		t.Errorf("got code %d before overload", w.Code)
+	}
+
+	atomic.StoreInt64(&goroutines, 1000)
+	shedder.Check()
+	if w := serve("GET", "/feed"); w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "2" {
+		t.Errorf("got code %d and Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := serve("POST", "/checkout"); w.Code != http.StatusOK {
+		t.Errorf("got code %d for a high priority route", w.Code)
+	}
+
+	atomic.StoreInt64(&goroutines, 10)
+	shedder.Check()
+	if w := serve("GET", "/feed"); w.Code != http.StatusOK {
+		t.Errorf("got code %d after recovery", w.Code)
+	}
+
+	if len(changes) != 2 || !changes[0] || changes[1] {
+		t.Errorf("got changes %v", changes)
+	}
+}
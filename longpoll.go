@@ -0,0 +1,74 @@
+package treemux
+
+import (
+	"net/http"
+	"time"
+)
+
+// ResumeTokenHeader is the header used by long-poll endpoints to exchange resume tokens.
+// Clients send the last token they received and servers respond with the next one.
+const ResumeTokenHeader = "X-Resume-Token"
+
+// ResumeToken returns the resume token sent by the client in the ResumeTokenHeader
+// or in the "resume_token" query parameter.
+func ResumeToken(req Request) string {
+	if token := req.Header.Get(ResumeTokenHeader); token != "" {
+		return token
+	}
+	return req.URL.Query().Get("resume_token")
+}
+
+// SetResumeToken sets the resume token that the client should send with the next poll.
+// It must be called before the response is written.
+func SetResumeToken(w http.ResponseWriter, token string) {
+	w.Header().Set(ResumeTokenHeader, token)
+}
+
+// LongPollConfig configures a long-poll handler.
+type LongPollConfig struct {
+	// Timeout is the maximum time the request is held. The default is 30 seconds.
+	Timeout time.Duration
+	// Wait returns a channel that is closed (or receives a value) when there are updates
+	// after the resume token. A nil channel means the updates are already available.
+	Wait func(req Request, token string) <-chan struct{}
+	// Respond writes the updates after the resume token. It should call SetResumeToken
+	// with the next token before writing the response.
+	Respond func(w http.ResponseWriter, req Request, token string) error
+}
+
+// LongPoll returns a handler for a long-poll endpoint. The request is held until Wait
+// signals updates, the timeout expires, or the client disconnects. On timeout the handler
+// responds with 204 No Content and echoes the resume token, so the client can poll again.
+//
+//	router.GET("/notifications", treemux.LongPoll(treemux.LongPollConfig{
+//		Wait:    hub.Wait,
+//		Respond: hub.Respond,
+//	}))
+func LongPoll(cfg LongPollConfig) HandlerFunc {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	return func(w http.ResponseWriter, req Request) error {
+		token := ResumeToken(req)
+
+		if ch := cfg.Wait(req, token); ch != nil {
+			timer := time.NewTimer(cfg.Timeout)
+			defer timer.Stop()
+
+			select {
+			case <-ch:
+			case <-timer.C:
+				if token != "" {
+					SetResumeToken(w, token)
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return nil
+			case <-req.Context().Done():
+				// The client is gone or the request deadline expired.
+				return nil
+			}
+		}
+
+		return cfg.Respond(w, req, token)
+	}
+}
@@ -0,0 +1,67 @@
+package treemux
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLongPoll(t *testing.T) {
+	updates := make(chan struct{})
+	router := New()
+	router.GET("/poll", LongPoll(LongPollConfig{
+		Timeout: 20 * time.Millisecond,
+		Wait: func(req Request, token string) <-chan struct{} {
+			if token == "" {
+				return nil
+			}
+			return updates
+		},
+		Respond: func(w http.ResponseWriter, req Request, token string) error {
+			SetResumeToken(w, token+"1")
+			_, err := w.Write([]byte("update"))
+			return err
+		},
+	}))
+
+	poll := func(ctx context.Context, token string) *httptest.ResponseRecorder {
+		r, _ := newRequest("GET", "/poll", nil)
+		r = r.WithContext(ctx)
+		if token != "" {
+			r.Header.Set(ResumeTokenHeader, token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := poll(context.Background(), "")
+	if w.Code != http.StatusOK || w.Body.String() != "update" || w.Header().Get(ResumeTokenHeader) != "1" {
+		t.Fatalf("got %d %q token %q", w.Code, w.Body.String(), w.Header().Get(ResumeTokenHeader))
+	}
+
+	w = poll(context.Background(), "1")
+	if w.Code != http.StatusNoContent || w.Header().Get(ResumeTokenHeader) != "1" {
+		t.Fatalf("timeout: got %d token %q", w.Code, w.Header().Get(ResumeTokenHeader))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w = poll(ctx, "1")
+	if w.Body.Len() != 0 {
+		t.Fatalf("disconnect: got body %q", w.Body.String())
+	}
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		close(updates)
+	}()
+	r, _ := newRequest("GET", "/poll?resume_token=1", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Header().Get(ResumeTokenHeader) != "11" {
+		t.Fatalf("update: got %d token %q", w.Code, w.Header().Get(ResumeTokenHeader))
+	}
+}
@@ -0,0 +1,93 @@
+package treemux
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// LookupMiss describes why a request did not match any route. It is passed to
+// NotFoundHandler and MethodNotAllowedHandler via the request context and can be
+// retrieved with LookupMissFrom to produce helpful error responses:
+//
+//	router.NotFoundHandler = func(w http.ResponseWriter, r *http.Request) {
+//		miss := treemux.LookupMissFrom(r)
+//		if miss.TrailingSlash {
+//			http.Error(w, "did you mean "+miss.Path+"/?", http.StatusNotFound)
+//			return
+//		}
+//		http.NotFound(w, r)
+//	}
+type LookupMiss struct {
+	// StatusCode is http.StatusNotFound or http.StatusMethodNotAllowed.
+	StatusCode int
+	// Path is the requested path.
+	Path string
+	// NearestRoute is the route template that matches the longest prefix of the path,
+	// e.g. "/users/:id" for "/users/1/unknown". It is empty if no prefix matches.
+	// For 405 responses it is the route that matched the path.
+	NearestRoute string
+	// AllowedMethods contains the sorted methods registered for the path.
+	// It is only set for 405 responses.
+	AllowedMethods []string
+	// TrailingSlash reports whether the path with a trailing slash added or removed
+	// matches a route for the requested method.
+	TrailingSlash bool
+}
+
+type lookupMissKey struct{}
+
+// LookupMissFrom returns the LookupMiss passed to NotFoundHandler or
+// MethodNotAllowedHandler. It returns nil for other requests.
+func LookupMissFrom(r *http.Request) *LookupMiss {
+	miss, _ := r.Context().Value(lookupMissKey{}).(*LookupMiss)
+	return miss
+}
+
+// withLookupMiss attaches the description of the failed lookup to the request.
+// The caller must hold t.mutex when SafeAddRoutesWhileRunning is enabled.
+func (t *TreeMux) withLookupMiss(r *http.Request, lr *LookupResult) *http.Request {
+	path := r.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	miss := &LookupMiss{
+		StatusCode: lr.StatusCode,
+		Path:       path,
+	}
+	if lr.StatusCode == http.StatusMethodNotAllowed && lr.handlerMap != nil {
+		if info := lr.handlerMap.anyRoute(); info != nil {
+			miss.NearestRoute = info.Route
+		}
+		miss.AllowedMethods = lr.handlerMap.Methods()
+	} else {
+		miss.NearestRoute = t.nearestRoute(t.hostTree(r), path)
+	}
+
+	if path != "/" {
+		toggled := path + "/"
+		if strings.HasSuffix(path, "/") {
+			toggled = path[:len(path)-1]
+		}
+		_, handler, _ := t.find(t.hostTree(r), r.Method, toggled[1:])
+		miss.TrailingSlash = handler != nil
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), lookupMissKey{}, miss))
+}
+
+// nearestRoute returns the route that matches the longest prefix of the path.
+func (t *TreeMux) nearestRoute(root *node, path string) string {
+	path = strings.TrimSuffix(path, "/")
+	for {
+		if n, _, _ := t.find(root, "", strings.TrimPrefix(path, "/")); n != nil && n.route != "" {
+			return n.route
+		}
+		i := strings.LastIndexByte(path, '/')
+		if i == -1 {
+			return ""
+		}
+		path = path[:i]
+	}
+}
@@ -0,0 +1,66 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestLookupMiss(t *testing.T) {
+	var miss *LookupMiss
+	router := New()
+	router.RedirectTrailingSlash = false
+	router.NotFoundHandler = func(w http.ResponseWriter, r *http.Request) {
+		miss = LookupMissFrom(r)
+		http.NotFound(w, r)
+	}
+	router.MethodNotAllowedHandler = func(w http.ResponseWriter, r *http.Request, methods map[string]HandlerFunc) {
+		miss = LookupMissFrom(r)
+		MethodNotAllowedHandler(w, r, methods)
+	}
+	router.GET("/users/:id", simpleHandler)
+	router.PUT("/users/:id", simpleHandler)
+	router.GET("/docs/", simpleHandler)
+
+	tests := []struct {
+		method string
+		path   string
+		want   LookupMiss
+	}{
+		{"GET", "/users/1/unknown", LookupMiss{
+			StatusCode:   http.StatusNotFound,
+			Path:         "/users/1/unknown",
+			NearestRoute: "/users/:id",
+		}},
+		{"POST", "/users/1", LookupMiss{
+			StatusCode:     http.StatusMethodNotAllowed,
+			Path:           "/users/1",
+			NearestRoute:   "/users/:id",
+			AllowedMethods: []string{"GET", "HEAD", "PUT"},
+		}},
+		{"GET", "/users/1/", LookupMiss{
+			StatusCode:    http.StatusNotFound,
+			Path:          "/users/1/",
+			NearestRoute:  "/users/:id",
+			TrailingSlash: true,
+		}},
+		{"GET", "/other", LookupMiss{
+			StatusCode: http.StatusNotFound,
+			Path:       "/other",
+		}},
+	}
+	for _, test := range tests {
+		miss = nil
+		r, _ := newRequest(test.method, test.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.want.StatusCode {
+			t.Errorf("%s %s: got code %d, wanted %d", test.method, test.path, w.Code, test.want.StatusCode)
+		}
+		if miss == nil || !reflect.DeepEqual(*miss, test.want) {
+			t.Errorf("%s %s: got %+v, wanted %+v", test.method, test.path, miss, test.want)
+		}
+	}
+}
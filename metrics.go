@@ -0,0 +1,209 @@
+package treemux
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultLatencyBuckets are the upper bounds of the latency histogram buckets in seconds.
+	DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// DefaultSizeBuckets are the upper bounds of the size histogram buckets in bytes.
+	DefaultSizeBuckets = []float64{100, 1000, 10000, 100000, 1e6, 1e7}
+)
+
+// Histogram counts observations in buckets.
+type Histogram struct {
+	// Buckets are the upper bounds of the buckets.
+	Buckets []float64
+	// Counts contains the number of observations in every bucket (non-cumulative).
+	// The last element counts observations larger than the last bucket.
+	Counts []uint64
+	Count  uint64
+	Sum    float64
+}
+
+func newHistogram(buckets []float64) Histogram {
+	return Histogram{
+		Buckets: buckets,
+		Counts:  make([]uint64, len(buckets)+1),
+	}
+}
+
+func (h *Histogram) observe(v float64) {
+	i := sort.SearchFloat64s(h.Buckets, v)
+	h.Counts[i]++
+	h.Count++
+	h.Sum += v
+}
+
+func (h *Histogram) clone() Histogram {
+	c := *h
+	c.Counts = append([]uint64(nil), h.Counts...)
+	return c
+}
+
+// RouteStats contains the metrics of a route.
+type RouteStats struct {
+	// Host is the host passed to TreeMux.Host or empty for the default tree.
+	Host   string
+	Method string
+	Route  string
+	// Requests is the number of served requests.
+	Requests uint64
+	// Errors is the number of requests that failed with an error or a 5xx status.
+	Errors uint64
+	// StatusCodes contains the number of responses by status code.
+	StatusCodes map[int]uint64
+	// Latency is the histogram of request durations in seconds.
+	Latency Histogram
+	// StatusLatency contains the latency histograms by status code.
+	StatusLatency map[int]Histogram
+	// RequestSize and ResponseSize are histograms of body sizes in bytes. Requests with
+	// unknown content length are not counted in RequestSize.
+	RequestSize  Histogram
+	ResponseSize Histogram
+}
+
+// Metrics is a dependency-free registry of per-route counters and histograms. Install
+// Metrics.Middleware to collect the metrics and use Snapshot to export them:
+//
+//	router.Use(router.Metrics().Middleware)
+//	for _, stats := range router.Metrics().Snapshot() { ... }
+type Metrics struct {
+	LatencyBuckets []float64
+	SizeBuckets    []float64
+
+	mu     sync.RWMutex
+	routes map[*RouteInfo]*routeMetrics
+}
+
+type routeMetrics struct {
+	mu    sync.Mutex
+	stats RouteStats
+}
+
+// NewMetrics returns a registry with the default buckets.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		LatencyBuckets: DefaultLatencyBuckets,
+		SizeBuckets:    DefaultSizeBuckets,
+		routes:         make(map[*RouteInfo]*routeMetrics),
+	}
+}
+
+// Metrics returns the metrics registry of the router.
+func (t *TreeMux) Metrics() *Metrics {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.metrics == nil {
+		t.metrics = NewMetrics()
+	}
+	return t.metrics
+}
+
+// Middleware records the metrics of the requests served by registered routes.
+func (m *Metrics) Middleware(next HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, req Request) error {
+		info := req.RouteInfo()
+		if info == nil {
+			return next(w, req)
+		}
+
+		start := time.Now()
+		rw := NewResponseWriter(w)
+		err := next(rw, req)
+		elapsed := time.Since(start)
+
+		statusCode := responseStatus(rw, err)
+		rm := m.route(info)
+
+		rm.mu.Lock()
+		s := &rm.stats
+		s.Requests++
+		if err != nil || statusCode >= 500 {
+			s.Errors++
+		}
+		s.StatusCodes[statusCode]++
+		s.Latency.observe(elapsed.Seconds())
+		h, ok := s.StatusLatency[statusCode]
+		if !ok {
+			h = newHistogram(m.LatencyBuckets)
+		}
+		h.observe(elapsed.Seconds())
+		s.StatusLatency[statusCode] = h
+		if req.ContentLength >= 0 {
+			s.RequestSize.observe(float64(req.ContentLength))
+		}
+		s.ResponseSize.observe(float64(rw.Written()))
+		rm.mu.Unlock()
+
+		return err
+	}
+}
+
+func (m *Metrics) route(info *RouteInfo) *routeMetrics {
+	m.mu.RLock()
+	rm := m.routes[info]
+	m.mu.RUnlock()
+	if rm != nil {
+		return rm
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rm = m.routes[info]; rm != nil {
+		return rm
+	}
+	rm = &routeMetrics{
+		stats: RouteStats{
+			Host:          info.Host,
+			Method:        info.Method,
+			Route:         info.Route,
+			StatusCodes:   make(map[int]uint64),
+			Latency:       newHistogram(m.LatencyBuckets),
+			StatusLatency: make(map[int]Histogram),
+			RequestSize:   newHistogram(m.SizeBuckets),
+			ResponseSize:  newHistogram(m.SizeBuckets),
+		},
+	}
+	m.routes[info] = rm
+	return rm
+}
+
+// Snapshot returns a copy of the metrics of all routes that served requests,
+// sorted by route and method.
+func (m *Metrics) Snapshot() []RouteStats {
+	m.mu.RLock()
+	all := make([]RouteStats, 0, len(m.routes))
+	for _, rm := range m.routes {
+		rm.mu.Lock()
+		s := rm.stats
+		s.StatusCodes = make(map[int]uint64, len(rm.stats.StatusCodes))
+		for code, n := range rm.stats.StatusCodes {
+			s.StatusCodes[code] = n
+		}
+		s.Latency = rm.stats.Latency.clone()
+		s.StatusLatency = make(map[int]Histogram, len(rm.stats.StatusLatency))
+		for code, h := range rm.stats.StatusLatency {
+			s.StatusLatency[code] = h.clone()
+		}
+		s.RequestSize = rm.stats.RequestSize.clone()
+		s.ResponseSize = rm.stats.ResponseSize.clone()
+		rm.mu.Unlock()
+		all = append(all, s)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Route != all[j].Route {
+			return all[i].Route < all[j].Route
+		}
+		return all[i].Method < all[j].Method
+	})
+	return all
+}
@@ -0,0 +1,53 @@
+package treemux
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	router := New()
+	router.ErrorHandler = func(w http.ResponseWriter, req Request, err error) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	metrics := router.Metrics()
+	if router.Metrics() != metrics {
+		t.Fatal("Metrics returned a different registry")
+	}
+	router.Use(metrics.Middleware)
+	router.POST("/users/:id", func(w http.ResponseWriter, req Request) error {
+		if req.Param("id") == "fail" {
+			return errors.New("failed")
+		}
+		_, err := w.Write([]byte(strings.Repeat("x", 500)))
+		return err
+	})
+	router.GET("/about", simpleHandler)
+
+	for _, id := range []string{"1", "2", "fail"} {
+		r, _ := newRequest("POST", "/users/"+id, strings.NewReader("{}"))
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	stats := metrics.Snapshot()
+	if len(stats) != 1 {
+		t.Fatalf("got %d routes, wanted 1", len(stats))
+	}
+	s := stats[0]
+	if s.Method != "POST" || s.Route != "/users/:id" || s.Requests != 3 || s.Errors != 1 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+	if s.StatusCodes[200] != 2 || s.StatusCodes[500] != 1 {
+		t.Errorf("got status codes %v", s.StatusCodes)
+	}
+	if s.Latency.Count != 3 || s.RequestSize.Sum != 6 || s.ResponseSize.Sum != 1000 {
+		t.Errorf("unexpected histograms %+v %+v %+v", s.Latency, s.RequestSize, s.ResponseSize)
+	}
+	// 500 bytes fall into the 1000 bucket and 0 bytes into the first bucket.
+	if s.ResponseSize.Counts[0] != 1 || s.ResponseSize.Counts[1] != 2 {
+		t.Errorf("got response size counts %v", s.ResponseSize.Counts)
+	}
+}
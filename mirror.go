@@ -0,0 +1,184 @@
+package treemux
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+)
+
+// MetaMirror is the route metadata key that overrides the MirrorConfig for the route.
+// The value must be a MirrorRoute.
+//
+//	percent, maxBodySize := 1.0, int64(4<<10)
+//	router.POST("/payments", createPayment).Meta(treemux.MetaMirror, treemux.MirrorRoute{
+//		Percent:       &percent,
+//		MaxBodySize:   &maxBodySize,
+//		RedactHeaders: []string{"X-Card-Token"},
+//	})
+const MetaMirror = "mirror"
+
+// MirrorConfig configures the Mirror middleware.
+type MirrorConfig struct {
+	// Target receives copies of the requests. Its responses are discarded.
+	// Use httputil.ReverseProxy to mirror requests to another upstream.
+	Target http.Handler
+	// Percent is the percentage of requests that are mirrored, from 0 to 100.
+	// Set it to 100 to mirror all requests.
+	Percent float64
+	// MaxConcurrent limits the number of mirrored requests in flight, so a slow target
+	// can't pile up goroutines. Requests are not mirrored while the limit is reached.
+	// The default is 100.
+	MaxConcurrent int
+	// OnDrop is called when a request is not mirrored because of MaxConcurrent,
+	// e.g. to count the dropped requests.
+	OnDrop func(req Request)
+	// MaxBodySize limits the size of the request body that is copied.
+	// Requests with larger bodies are not mirrored. Zero means that only requests
+	// without a body are mirrored.
+	MaxBodySize int64
+	// RedactHeaders are the names of the request headers that are masked in the copies,
+	// e.g. "Authorization" and "Cookie".
+	RedactHeaders []string
+	// HeaderMask replaces the values of the redacted headers. Empty string removes them.
+	HeaderMask string
+}
+
+// MirrorRoute overrides the MirrorConfig for a route. See MetaMirror.
+type MirrorRoute struct {
+	// Disabled excludes the route from mirroring.
+	Disabled bool
+	// Percent replaces MirrorConfig.Percent, including with zero. Nil keeps
+	// the configured percentage.
+	Percent *float64
+	// MaxBodySize replaces MirrorConfig.MaxBodySize, including with zero. Nil keeps
+	// the configured limit.
+	MaxBodySize *int64
+	// RedactHeaders are redacted in addition to MirrorConfig.RedactHeaders.
+	RedactHeaders []string
+}
+
+// Mirror returns a middleware that asynchronously sends copies of a percentage of requests
+// (method, URL, headers, and body) to a secondary handler without affecting
+// the primary response. It can be used to test new backends with real traffic.
+//
+// Sampling, body limits, and header redaction can be tuned per route with
+// the MetaMirror route metadata, so mirroring can stay enabled on sensitive routes.
+func Mirror(cfg MirrorConfig) MiddlewareFunc {
+	if cfg.MaxConcurrent == 0 {
+		cfg.MaxConcurrent = 100
+	}
+	inflight := make(chan struct{}, cfg.MaxConcurrent)
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			v, _ := req.RouteInfo().Value(MetaMirror)
+			route, _ := v.(MirrorRoute)
+			if route.Disabled {
+				return next(w, req)
+			}
+
+			percent := cfg.Percent
+			if route.Percent != nil {
+				percent = *route.Percent
+			}
+			if rand.Float64()*100 >= percent {
+				return next(w, req)
+			}
+
+			select {
+			case inflight <- struct{}{}:
+			default:
+				if cfg.OnDrop != nil {
+					cfg.OnDrop(req)
+				}
+				return next(w, req)
+			}
+
+			maxBodySize := cfg.MaxBodySize
+			if route.MaxBodySize != nil {
+				maxBodySize = *route.MaxBodySize
+			}
+			body, ok := bufferBody(&req, maxBodySize)
+			if !ok {
+				<-inflight
+				return next(w, req)
+			}
+
+			mirror := req.Request.Clone(context.Background())
+			mirror.Body = ioutil.NopCloser(bytes.NewReader(body))
+			mirror.ContentLength = int64(len(body))
+			redactHeaders(mirror.Header, cfg.RedactHeaders, cfg.HeaderMask)
+			redactHeaders(mirror.Header, route.RedactHeaders, cfg.HeaderMask)
+			go func() {
+				defer func() { <-inflight }()
+				cfg.Target.ServeHTTP(newDiscardResponseWriter(), mirror)
+			}()
+			return next(w, req)
+		}
+	}
+}
+
+func redactHeaders(h http.Header, names []string, mask string) {
+	for _, name := range names {
+		if _, ok := h[http.CanonicalHeaderKey(name)]; !ok {
+			continue
+		}
+		if mask == "" {
+			h.Del(name)
+		} else {
+			h.Set(name, mask)
+		}
+	}
+}
+
+// bufferBody reads up to limit bytes from the request body and restores the body
+// so the handler can read it again. It returns false if the body is larger than limit.
+func bufferBody(req *Request, limit int64) ([]byte, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true
+	}
+	if req.ContentLength > limit {
+		return nil, false
+	}
+
+	buf, err := ioutil.ReadAll(io.LimitReader(req.Body, limit+1))
+
+	r := new(http.Request)
+	*r = *req.Request
+	r.Body = readCloser{
+		Reader: io.MultiReader(bytes.NewReader(buf), req.Body),
+		Closer: req.Body,
+	}
+	req.Request = r
+
+	if err != nil || int64(len(buf)) > limit {
+		return nil, false
+	}
+	return buf, true
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func newDiscardResponseWriter() *discardResponseWriter {
+	return &discardResponseWriter{header: make(http.Header)}
+}
+
+func (w *discardResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *discardResponseWriter) WriteHeader(statusCode int) {}
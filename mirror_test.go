@@ -0,0 +1,204 @@
+package treemux
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMirror(t *testing.T) {
+	type mirrored struct {
+		method, path, header, body string
+	}
+	mirrorCh := make(chan mirrored, 10)
+
+	target := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		mirrorCh <- mirrored{r.Method, r.URL.Path, r.Header.Get("X-Test"), string(b)}
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	router := New()
+	router.Use(Mirror(MirrorConfig{Target: target, Percent: 100, MaxBodySize: 8}))
+	router.POST("/items/:id", func(w http.ResponseWriter, req Request) error {
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	})
+
+	send := func(body string) *httptest.ResponseRecorder {
+		r, _ := newRequest("POST", "/items/1", strings.NewReader(body))
+		r.Header.Set("X-Test", "yes")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := send("small")
+	if w.Code != http.StatusOK || w.Body.String() != "small" {
+		t.Fatalf("primary response was affected: %d %q", w.Code, w.Body.String())
+	}
+	select {
+	case m := <-mirrorCh:
+		wanted := mirrored{"POST", "/items/1", "yes", "small"}
+		if m != wanted {
+			t.Errorf("got %+v, wanted %+v", m, wanted)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("request was not mirrored")
+	}
+
+	// Bodies larger than the limit are not mirrored, but are still served.
+	w = send("a large body")
+	if w.Body.String() != "a large body" {
+		t.Fatalf("got %q", w.Body.String())
+	}
+	select {
+	case m := <-mirrorCh:
+		t.Errorf("unexpected mirrored request %+v", m)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMirrorRoute(t *testing.T) {
+	type mirrored struct {
+		path, auth, token, body string
+	}
+	mirrorCh := make(chan mirrored, 10)
+
+	target := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		_, hasToken := r.Header["X-Card-Token"]
+		token := r.Header.Get("X-Card-Token")
+		if !hasToken {
+			token = "<removed>"
+		}
+		mirrorCh <- mirrored{r.URL.Path, r.Header.Get("Authorization"), token, string(b)}
+	})
+
+	var primaryAuth string
+	handler := func(w http.ResponseWriter, req Request) error {
+		primaryAuth = req.Header.Get("Authorization")
+		_, err := ioutil.ReadAll(req.Body)
+		return err
+	}
+
+	all, none, maxBodySize := 100.0, 0.0, int64(16)
+	router := New()
+	router.Use(Mirror(MirrorConfig{
+		Target:        target,
+		Percent:       100,
+		MaxBodySize:   4,
+		RedactHeaders: []string{"authorization"},
+		HeaderMask:    "***",
+	}))
+	router.POST("/payments", handler).Meta(MetaMirror, MirrorRoute{
+		Percent:       &all,
+		MaxBodySize:   &maxBodySize,
+		RedactHeaders: []string{"X-Card-Token"},
+	})
+	router.POST("/private", handler).Meta(MetaMirror, MirrorRoute{Disabled: true})
+	router.POST("/unsampled", handler).Meta(MetaMirror, MirrorRoute{Percent: &none})
+
+	send := func(path string) {
+		r, _ := newRequest("POST", path, strings.NewReader("card=1234"))
+		r.Header.Set("Authorization", "Bearer secret")
+		r.Header.Set("X-Card-Token", "tok")
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	send("/payments")
+	if primaryAuth != "Bearer secret" {
+		t.Errorf("primary request was redacted: %q", primaryAuth)
+	}
+	select {
+	case m := <-mirrorCh:
+		wanted := mirrored{"/payments", "***", "***", "card=1234"}
+		if m != wanted {
+			t.Errorf("got %+v, wanted %+v", m, wanted)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("request was not mirrored")
+	}
+
+	send("/private")
+	send("/unsampled")
+	select {
+	case m := <-mirrorCh:
+		t.Errorf("unexpected mirrored request %+v", m)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMirrorMaxConcurrent(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	target := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	})
+
+	dropped := make(chan string, 2)
+	router := New()
+	router.Use(Mirror(MirrorConfig{
+		Target:        target,
+		Percent:       100,
+		MaxConcurrent: 1,
+		OnDrop: func(req Request) {
+			dropped <- req.URL.Path
+		},
+	}))
+	router.GET("/items", func(w http.ResponseWriter, req Request) error {
+		return nil
+	})
+
+	send := func() {
+		r, _ := newRequest("GET", "/items", nil)
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	send()
+	<-started
+	send()
+	select {
+	case path := <-dropped:
+		if path != "/items" {
+			t.Errorf("got %q", path)
+		}
+	default:
+		t.Fatal("request over the limit was not dropped")
+	}
+
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for {
+		send()
+		if len(dropped) == 0 {
+			break
+		}
+		<-dropped
+		if time.Now().After(deadline) {
+			t.Fatal("the limit was not released")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("request was not mirrored after the limit was released")
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	h := http.Header{"Cookie": {"a=1"}, "Accept": {"*/*"}}
+	redactHeaders(h, []string{"cookie", "Authorization"}, "")
+	if len(h) != 1 || h.Get("Accept") != "*/*" {
+		t.Errorf("got headers %v", h)
+	}
+}
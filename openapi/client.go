@@ -0,0 +1,440 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"net/url"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/vmihailenco/treemux"
+)
+
+// ClientConfig configures GenerateClient and GenerateTypeScriptClient.
+type ClientConfig struct {
+	// Package is the name of the generated Go package. The default is "client".
+	Package string
+	// Host selects the routes registered with TreeMux.Host. The default is the routes
+	// of the default tree.
+	Host string
+}
+
+// clientMethod describes a named route.
+type clientMethod struct {
+	Name     string
+	Method   string
+	Route    string
+	Params   []clientParam
+	Request  reflect.Type
+	Response reflect.Type
+}
+
+type clientParam struct {
+	Name     string
+	Type     *treemux.ParamType
+	CatchAll bool
+	// Literal is set for static segments.
+	Literal string
+}
+
+// clientMethods returns the named routes sorted by name.
+func clientMethods(mux *treemux.TreeMux, host string) []clientMethod {
+	var methods []clientMethod
+	mux.Walk(func(route treemux.RouteNode) bool {
+		if route.Host != host {
+			return true
+		}
+		for method, info := range route.Routes {
+			if info.Method != method || info.Name == "" {
+				continue
+			}
+			pattern := route.Pattern
+			if route.AddSlash {
+				pattern += "/"
+			}
+			m := clientMethod{
+				Name:   info.Name,
+				Method: info.Method,
+				Route:  pattern,
+				Params: clientParams(info, route.AddSlash),
+			}
+			if v, ok := info.Value(MetaRequest); ok && v != nil {
+				m.Request = reflect.TypeOf(v)
+			}
+			if v, ok := info.Value(MetaResponse); ok && v != nil {
+				m.Response = reflect.TypeOf(v)
+			}
+			methods = append(methods, m)
+		}
+		return true
+	})
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Name < methods[j].Name
+	})
+	return methods
+}
+
+// clientParams splits the route into static segments and params.
+func clientParams(info *treemux.RouteInfo, addSlash bool) []clientParam {
+	var params []clientParam
+	var literal strings.Builder
+	for _, seg := range strings.Split(info.Route, "/")[1:] {
+		literal.WriteByte('/')
+		if seg == "" || seg[0] != ':' && seg[0] != '*' {
+			literal.WriteString(url.PathEscape(seg))
+			continue
+		}
+		params = append(params, clientParam{Literal: literal.String()})
+		literal.Reset()
+		params = append(params, clientParam{
+			Name:     seg[1:],
+			Type:     info.ParamType(seg[1:]),
+			CatchAll: seg[0] == '*',
+		})
+	}
+	if addSlash {
+		literal.WriteByte('/')
+	}
+	if literal.Len() > 0 {
+		params = append(params, clientParam{Literal: literal.String()})
+	}
+	return params
+}
+
+// identifier converts the name, e.g. "get-user" or "user_id", to a Go or TypeScript
+// identifier in camel case, exported if upper is set.
+func identifier(name string, upper bool) string {
+	var b strings.Builder
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = b.Len() > 0
+			continue
+		}
+		if b.Len() == 0 && unicode.IsDigit(r) {
+			b.WriteByte('_')
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		} else if b.Len() == 0 {
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+// argNames returns unique argument names for the params that don't collide
+// with the reserved names.
+func argNames(params []clientParam, reserved ...string) map[int]string {
+	used := make(map[string]bool)
+	for _, name := range reserved {
+		used[name] = true
+	}
+	names := make(map[int]string)
+	for i, p := range params {
+		if p.Literal != "" {
+			continue
+		}
+		name := identifier(p.Name, false)
+		if token.Lookup(name).IsKeyword() || isTypeScriptKeyword(name) {
+			name += "Param"
+		}
+		for base, n := name, 2; used[name]; n++ {
+			name = base + strconv.Itoa(n)
+		}
+		used[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+// GenerateClient writes the source of a typed Go client with one method per named route.
+// Path params become arguments typed according to their param types, and the request
+// and response bodies are encoded as JSON using the types of MetaRequest and MetaResponse.
+//
+//	router.GET("/users/:id|int", getUser).
+//		Name("get-user").
+//		Meta(openapi.MetaResponse, User{})
+//
+// generates
+//
+//	func (c *Client) GetUser(ctx context.Context, id int) (*api.User, error)
+func GenerateClient(w io.Writer, mux *treemux.TreeMux, cfg ClientConfig) error {
+	if cfg.Package == "" {
+		cfg.Package = "client"
+	}
+
+	g := &goClient{imports: make(map[string]string)}
+	var methods []string
+	for _, m := range clientMethods(mux, cfg.Host) {
+		methods = append(methods, g.method(m))
+	}
+
+	var buf bytes.Buffer
+	if err := goClientTemplate.Execute(&buf, struct {
+		Package string
+		Imports []string
+		Methods []string
+	}{cfg.Package, g.importSpecs(), methods}); err != nil {
+		return err
+	}
+
+	b, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+type goClient struct {
+	// imports maps package paths to package names.
+	imports map[string]string
+}
+
+func (g *goClient) method(m clientMethod) string {
+	var b strings.Builder
+	names := argNames(m.Params, "ctx", "body", "out", "path", "c")
+
+	args := []string{"ctx context.Context"}
+	for i, p := range m.Params {
+		if p.Literal == "" {
+			args = append(args, names[i]+" "+goParamType(p))
+		}
+	}
+	if m.Request != nil {
+		args = append(args, "body "+g.typeExpr(m.Request))
+	}
+
+	results := "error"
+	if m.Response != nil {
+		results = "(*" + g.typeExpr(m.Response) + ", error)"
+	}
+
+	fmt.Fprintf(&b, "// %s calls %s %s.\n", identifier(m.Name, true), m.Method, m.Route)
+	fmt.Fprintf(&b, "func (c *Client) %s(%s) %s {\n", identifier(m.Name, true), strings.Join(args, ", "), results)
+
+	var parts []string
+	for i, p := range m.Params {
+		switch {
+		case p.Literal != "":
+			parts = append(parts, strconv.Quote(p.Literal))
+		case p.CatchAll:
+			parts = append(parts, "escapePath("+names[i]+")")
+		case p.Type == nil || p.Type.Name == treemux.String.Name || p.Type.Name == treemux.UUID.Name:
+			parts = append(parts, "url.PathEscape("+names[i]+")")
+		default:
+			parts = append(parts, "url.PathEscape(fmt.Sprint("+names[i]+"))")
+		}
+	}
+	fmt.Fprintf(&b, "\tpath := %s\n", strings.Join(parts, " + "))
+
+	body := "nil"
+	if m.Request != nil {
+		body = "body"
+	}
+	if m.Response == nil {
+		fmt.Fprintf(&b, "\treturn c.do(ctx, %q, path, %s, nil)\n", m.Method, body)
+	} else {
+		fmt.Fprintf(&b, "\tout := new(%s)\n", g.typeExpr(m.Response))
+		fmt.Fprintf(&b, "\tif err := c.do(ctx, %q, path, %s, out); err != nil {\n", m.Method, body)
+		b.WriteString("\t\treturn nil, err\n\t}\n\treturn out, nil\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func goParamType(p clientParam) string {
+	if p.CatchAll || p.Type == nil {
+		return "string"
+	}
+	switch p.Type.Name {
+	case treemux.Int.Name, treemux.Int64.Name, treemux.Uint64.Name, treemux.Float64.Name, treemux.Bool.Name:
+		return p.Type.Name
+	default:
+		return "string"
+	}
+}
+
+// typeExpr returns the Go type expression of the type and records the imports it needs.
+func (g *goClient) typeExpr(typ reflect.Type) string {
+	if typ.Name() != "" {
+		if typ.PkgPath() == "" {
+			return typ.Name()
+		}
+		return g.importName(typ.PkgPath()) + "." + typ.Name()
+	}
+	switch typ.Kind() {
+	case reflect.Ptr:
+		return "*" + g.typeExpr(typ.Elem())
+	case reflect.Slice:
+		return "[]" + g.typeExpr(typ.Elem())
+	case reflect.Array:
+		return "[" + strconv.Itoa(typ.Len()) + "]" + g.typeExpr(typ.Elem())
+	case reflect.Map:
+		return "map[" + g.typeExpr(typ.Key()) + "]" + g.typeExpr(typ.Elem())
+	case reflect.Interface:
+		if typ.NumMethod() == 0 {
+			return "interface{}"
+		}
+	}
+	// Anonymous structs and the like are described by their schema only.
+	return "map[string]interface{}"
+}
+
+func (g *goClient) importName(pkgPath string) string {
+	if name, ok := g.imports[pkgPath]; ok {
+		return name
+	}
+	base := strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, path.Base(pkgPath))
+	if base == "" || !unicode.IsLetter([]rune(base)[0]) {
+		base = "pkg" + base
+	}
+	name := base
+	for n := 2; g.nameTaken(name); n++ {
+		name = base + strconv.Itoa(n)
+	}
+	g.imports[pkgPath] = name
+	return name
+}
+
+func (g *goClient) nameTaken(name string) bool {
+	switch name {
+	case "bytes", "context", "json", "fmt", "io", "ioutil", "http", "url", "strings":
+		return true
+	}
+	for _, taken := range g.imports {
+		if taken == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *goClient) importSpecs() []string {
+	var specs []string
+	for pkgPath, name := range g.imports {
+		spec := strconv.Quote(pkgPath)
+		if path.Base(pkgPath) != name {
+			spec = name + " " + spec
+		}
+		specs = append(specs, spec)
+	}
+	sort.Strings(specs)
+	return specs
+}
+
+var goClientTemplate = template.Must(template.New("client").Parse(`// This file was generated by openapi.GenerateClient.
+
+package {{.Package}}
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+{{range .Imports}}
+	{{.}}
+{{- end}}
+)
+
+// Client calls the API.
+type Client struct {
+	// BaseURL is the URL of the API, e.g. "https://api.example.com".
+	BaseURL string
+	// HTTPClient is the client used to send requests. The default is http.DefaultClient.
+	HTTPClient *http.Client
+	// Header is added to every request, e.g. to authenticate.
+	Header http.Header
+}
+
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Error is returned for responses with non-2xx status codes.
+type Error struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("client: got status %d: %s", e.StatusCode, bytes.TrimSpace(e.Body))
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, c.BaseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	for k, v := range c.Header {
+		req.Header[k] = v
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return &Error{StatusCode: resp.StatusCode, Body: b}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func escapePath(s string) string {
+	segments := strings.Split(strings.TrimPrefix(s, "/"), "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return strings.Join(segments, "/")
+}
+{{range .Methods}}
+{{.}}
+{{- end}}
+`))
@@ -0,0 +1,74 @@
+package openapi_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/vmihailenco/treemux"
+	"github.com/vmihailenco/treemux/openapi"
+)
+
+func clientRouter() *treemux.TreeMux {
+	router := treemux.New()
+	router.GET("/users/:id|int", handler).
+		Name("get-user").
+		Meta(openapi.MetaResponse, User{})
+	router.PUT("/users/:id|int/profile/", handler).
+		Name("update_user").
+		Meta(openapi.MetaRequest, &UpdateUser{})
+	router.GET("/files/:type/*path", handler).Name("getFile")
+	router.GET("/health", handler)
+	return router
+}
+
+func TestGenerateClient(t *testing.T) {
+	var buf bytes.Buffer
+	if err := openapi.GenerateClient(&buf, clientRouter(), openapi.ClientConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, s := range []string{
+		"package client\n",
+		"\t\"github.com/vmihailenco/treemux/openapi_test\"\n",
+		"func (c *Client) GetUser(ctx context.Context, id int) (*openapi_test.User, error) {\n",
+		"\tpath := \"/users/\" + url.PathEscape(fmt.Sprint(id))\n",
+		"// UpdateUser calls PUT /users/:id/profile/.\n",
+		"func (c *Client) UpdateUser(ctx context.Context, id int, body *openapi_test.UpdateUser) error {\n",
+		"\tpath := \"/users/\" + url.PathEscape(fmt.Sprint(id)) + \"/profile/\"\n",
+		"func (c *Client) GetFile(ctx context.Context, typeParam string, path2 string) error {\n",
+		"\tpath := \"/files/\" + url.PathEscape(typeParam) + \"/\" + escapePath(path2)\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("output does not contain %q:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "Health") {
+		t.Error("routes without names must be skipped")
+	}
+}
+
+func TestGenerateTypeScriptClient(t *testing.T) {
+	var buf bytes.Buffer
+	if err := openapi.GenerateTypeScriptClient(&buf, clientRouter(), openapi.ClientConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, s := range []string{
+		"export type UpdateUser = {\n  name: string;\n};\n",
+		"export type User = {\n  created_at: string;\n  email?: string | null;\n  id: number;\n" +
+			"  manager?: Record<string, unknown> | null;\n  name: string;\n  tags?: Array<string>;\n};\n",
+		"  getUser(id: number): Promise<User> {\n" +
+			"    return this.request(\"GET\", `/users/${encodeURIComponent(String(id))}`);\n",
+		"  updateUser(id: number, body: UpdateUser): Promise<void> {\n" +
+			"    return this.request(\"PUT\", `/users/${encodeURIComponent(String(id))}/profile/`, body);\n",
+		"  getFile(typeParam: string, path: string): Promise<void> {\n" +
+			"    return this.request(\"GET\", `/files/${encodeURIComponent(String(typeParam))}/${escapePath(path)}`);\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("output does not contain %q:\n%s", s, out)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/vmihailenco/treemux"
+)
+
+var typeScriptKeywords = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true,
+	"continue": true, "debugger": true, "default": true, "delete": true, "do": true,
+	"else": true, "enum": true, "export": true, "extends": true, "false": true,
+	"finally": true, "for": true, "function": true, "if": true, "import": true,
+	"in": true, "instanceof": true, "new": true, "null": true, "return": true,
+	"super": true, "switch": true, "this": true, "throw": true, "true": true,
+	"try": true, "typeof": true, "var": true, "void": true, "while": true, "with": true,
+	"let": true, "static": true, "yield": true, "await": true,
+}
+
+func isTypeScriptKeyword(name string) bool {
+	return typeScriptKeywords[name]
+}
+
+// isTypeScriptIdentifier reports whether the name can be used as a property name
+// without quotes.
+func isTypeScriptIdentifier(name string) bool {
+	if name == "" || isTypeScriptKeyword(name) {
+		return false
+	}
+	for i, c := range name {
+		if c == '_' || c == '$' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' ||
+			i > 0 && '0' <= c && c <= '9' {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// GenerateTypeScriptClient writes the source of a TypeScript client using fetch
+// with one method per named route. Types of the request and response bodies are
+// derived from the schemas of MetaRequest and MetaResponse.
+func GenerateTypeScriptClient(w io.Writer, mux *treemux.TreeMux, cfg ClientConfig) error {
+	g := &tsClient{names: make(map[reflect.Type]string)}
+	var methods []string
+	for _, m := range clientMethods(mux, cfg.Host) {
+		methods = append(methods, g.method(m))
+	}
+
+	var types []string
+	for typ, name := range g.names {
+		types = append(types, fmt.Sprintf("export type %s = %s;\n", name, tsType(SchemaOf(reflect.Zero(typ).Interface()), "")))
+	}
+	sort.Strings(types)
+
+	return tsClientTemplate.Execute(w, struct {
+		Types   []string
+		Methods []string
+	}{types, methods})
+}
+
+type tsClient struct {
+	// names maps the named Go types of the bodies to the TypeScript type names.
+	names map[reflect.Type]string
+}
+
+func (g *tsClient) method(m clientMethod) string {
+	var b bytes.Buffer
+	names := argNames(m.Params, "body")
+
+	var args []string
+	for i, p := range m.Params {
+		if p.Literal == "" {
+			args = append(args, names[i]+": "+tsParamType(p))
+		}
+	}
+	if m.Request != nil {
+		args = append(args, "body: "+g.typeName(m.Request, "  "))
+	}
+
+	result := "void"
+	if m.Response != nil {
+		result = g.typeName(m.Response, "  ")
+	}
+
+	var path strings.Builder
+	for i, p := range m.Params {
+		switch {
+		case p.Literal != "":
+			path.WriteString(tsTemplateEscaper.Replace(p.Literal))
+		case p.CatchAll:
+			path.WriteString("${escapePath(" + names[i] + ")}")
+		default:
+			path.WriteString("${encodeURIComponent(String(" + names[i] + "))}")
+		}
+	}
+
+	body := ""
+	if m.Request != nil {
+		body = ", body"
+	}
+
+	fmt.Fprintf(&b, "  /** %s %s */\n", m.Method, m.Route)
+	fmt.Fprintf(&b, "  %s(%s): Promise<%s> {\n", identifier(m.Name, false), strings.Join(args, ", "), result)
+	fmt.Fprintf(&b, "    return this.request(%s, `%s`%s);\n", strconv.Quote(m.Method), path.String(), body)
+	b.WriteString("  }\n")
+	return b.String()
+}
+
+var tsTemplateEscaper = strings.NewReplacer("\\", "\\\\", "`", "\\`", "${", "\\${")
+
+func tsParamType(p clientParam) string {
+	if p.CatchAll || p.Type == nil {
+		return "string"
+	}
+	switch p.Type.Name {
+	case treemux.Int.Name, treemux.Int64.Name, treemux.Uint64.Name, treemux.Float64.Name:
+		return "number"
+	case treemux.Bool.Name:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+// typeName returns the name of the body type. Named Go types are declared as type
+// aliases and other types are inlined using the indent.
+func (g *tsClient) typeName(typ reflect.Type, indent string) string {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Name() == "" || typ.PkgPath() == "" {
+		return tsType(SchemaOf(reflect.Zero(typ).Interface()), indent)
+	}
+	if name, ok := g.names[typ]; ok {
+		return name
+	}
+
+	base := identifier(typ.Name(), true)
+	name := base
+	for n := 2; g.nameTaken(name); n++ {
+		name = base + strconv.Itoa(n)
+	}
+	g.names[typ] = name
+	return name
+}
+
+func (g *tsClient) nameTaken(name string) bool {
+	if name == "Client" || name == "ApiError" {
+		return true
+	}
+	for _, taken := range g.names {
+		if taken == name {
+			return true
+		}
+	}
+	return false
+}
+
+// tsType returns the TypeScript type of the schema indented with the indent.
+func tsType(s *Schema, indent string) string {
+	var typ string
+	switch s.Type {
+	case "boolean":
+		typ = "boolean"
+	case "integer", "number":
+		typ = "number"
+	case "string":
+		typ = "string"
+	case "array":
+		item := "unknown"
+		if s.Items != nil {
+			item = tsType(s.Items, indent)
+		}
+		typ = "Array<" + item + ">"
+	case "object":
+		switch {
+		case s.Properties != nil:
+			typ = tsObject(s, indent)
+		case s.AdditionalProperties != nil:
+			typ = "Record<string, " + tsType(s.AdditionalProperties, indent) + ">"
+		default:
+			typ = "Record<string, unknown>"
+		}
+	default:
+		typ = "unknown"
+	}
+	if s.Nullable && typ != "unknown" {
+		typ += " | null"
+	}
+	return typ
+}
+
+func tsObject(s *Schema, indent string) string {
+	if len(s.Properties) == 0 {
+		return "{}"
+	}
+
+	required := make(map[string]bool, len(s.Required))
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, name := range names {
+		key := name
+		if !isTypeScriptIdentifier(name) {
+			key = strconv.Quote(name)
+		}
+		optional := ""
+		if !required[name] {
+			optional = "?"
+		}
+		fmt.Fprintf(&b, "%s  %s%s: %s;\n", indent, key, optional, tsType(s.Properties[name], indent+"  "))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+var tsClientTemplate = template.Must(template.New("client").Parse(`// This file was generated by openapi.GenerateTypeScriptClient.
+{{range .Types}}
+{{.}}
+{{- end}}
+export class ApiError extends Error {
+  constructor(public status: number, public body: string) {
+    super(` + "`got status ${status}: ${body}`" + `);
+  }
+}
+
+function escapePath(path: string): string {
+  return path.replace(/^\//, "").split("/").map(encodeURIComponent).join("/");
+}
+
+export class Client {
+  constructor(private baseURL: string, private init: RequestInit = {}) {}
+
+  private async request<T>(method: string, path: string, body?: unknown): Promise<T> {
+    const headers = new Headers(this.init.headers);
+    headers.set("Accept", "application/json");
+    if (body !== undefined) {
+      headers.set("Content-Type", "application/json");
+    }
+    const resp = await fetch(this.baseURL + path, {
+      ...this.init,
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    const text = await resp.text();
+    if (!resp.ok) {
+      throw new ApiError(resp.status, text);
+    }
+    return (text ? JSON.parse(text) : undefined) as T;
+  }
+{{range .Methods}}
+{{.}}
+{{- end}}}
+`))
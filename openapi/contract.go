@@ -0,0 +1,258 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/format"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/vmihailenco/treemux"
+)
+
+// ContractConfig configures GenerateContractTests.
+type ContractConfig struct {
+	// Package is the package clause of the generated file, e.g. "api_test".
+	Package string
+	// Router is a Go expression that returns the http.Handler under test, e.g. "newRouter()".
+	Router string
+	// TestName is the name of the generated test function. The default is "TestContract".
+	TestName string
+	// Host selects the routes registered with TreeMux.Host. The default is the routes
+	// of the default tree.
+	Host string
+}
+
+// GenerateContractTests writes the source of a table-driven Go test that sends a request
+// to every route and checks the status code (see MetaStatus) and the response body against
+// the schema of MetaResponse. Params are filled with sample values based on their types,
+// and request bodies are built from the schema of MetaRequest. The generated file is
+// a starting point of a regression suite and usually needs adjustments, e.g. to use
+// the ids of existing fixtures:
+//
+//	f, err := os.Create("contract_test.go")
+//	...
+//	err = openapi.GenerateContractTests(f, router, openapi.ContractConfig{
+//		Package: "api_test",
+//		Router:  "newRouter()",
+//	})
+func GenerateContractTests(w io.Writer, mux *treemux.TreeMux, cfg ContractConfig) error {
+	if cfg.TestName == "" {
+		cfg.TestName = "TestContract"
+	}
+
+	var cases []contractCase
+	var err error
+	mux.Walk(func(route treemux.RouteNode) bool {
+		if route.Host != cfg.Host {
+			return true
+		}
+		for method, info := range route.Routes {
+			if info.Method != method {
+				continue
+			}
+			var c contractCase
+			c, err = newContractCase(info)
+			if err != nil {
+				return false
+			}
+			cases = append(cases, c)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(cases, func(i, j int) bool {
+		return cases[i].Name < cases[j].Name
+	})
+
+	var buf bytes.Buffer
+	if err := contractTemplate.Execute(&buf, struct {
+		ContractConfig
+		Cases []contractCase
+	}{cfg, cases}); err != nil {
+		return err
+	}
+
+	b, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+type contractCase struct {
+	Name   string
+	Method string
+	Path   string
+	Body   string
+	Status int
+	Schema string
+}
+
+func newContractCase(info *treemux.RouteInfo) (contractCase, error) {
+	c := contractCase{
+		Name:   quote(info.Method + " " + info.Route),
+		Method: quote(info.Method),
+		Path:   quote(samplePath(info)),
+		Body:   `""`,
+		Status: successStatus(info),
+		Schema: `""`,
+	}
+	if v, ok := info.Value(MetaRequest); ok {
+		b, err := json.Marshal(SchemaOf(v).Sample())
+		if err != nil {
+			return c, err
+		}
+		c.Body = quote(string(b))
+	}
+	if v, ok := info.Value(MetaResponse); ok {
+		b, err := json.Marshal(SchemaOf(v))
+		if err != nil {
+			return c, err
+		}
+		c.Schema = quote(string(b))
+	}
+	return c, nil
+}
+
+// quote returns the Go string literal, preferring raw strings for readability.
+func quote(s string) string {
+	if strconv.CanBackquote(s) {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
+
+// samplePath returns the route path with params replaced with sample values.
+func samplePath(info *treemux.RouteInfo) string {
+	segments := strings.Split(info.Route, "/")
+	for i, seg := range segments {
+		if seg == "" {
+			continue
+		}
+		if seg[0] == ':' || seg[0] == '*' {
+			segments[i] = sampleParam(info.ParamType(seg[1:]))
+		} else {
+			segments[i] = url.PathEscape(seg)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func sampleParam(typ *treemux.ParamType) string {
+	if typ == nil {
+		return "test"
+	}
+	switch typ.Name {
+	case treemux.Int.Name, treemux.Int64.Name, treemux.Uint64.Name:
+		return "1"
+	case treemux.Float64.Name:
+		return "1.5"
+	case treemux.Bool.Name:
+		return "true"
+	case treemux.UUID.Name:
+		return "00000000-0000-0000-0000-000000000001"
+	default:
+		return "test"
+	}
+}
+
+// Sample returns a value that is valid against the schema and can be marshaled to JSON.
+func (s *Schema) Sample() interface{} {
+	switch s.Type {
+	case "boolean":
+		return false
+	case "integer", "number":
+		return 0
+	case "string":
+		if s.Format == "date-time" {
+			return "2006-01-02T15:04:05Z"
+		}
+		return "string"
+	case "array":
+		return []interface{}{}
+	case "object":
+		obj := make(map[string]interface{}, len(s.Properties))
+		for name, prop := range s.Properties {
+			obj[name] = prop.Sample()
+		}
+		return obj
+	default:
+		return nil
+	}
+}
+
+var contractTemplate = template.Must(template.New("contract").Parse(`// This file was generated by openapi.GenerateContractTests.
+
+package {{.Package}}
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vmihailenco/treemux/openapi"
+)
+
+func {{.TestName}}(t *testing.T) {
+	handler := {{.Router}}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		schema string
+	}{
+{{- range .Cases}}
+		{ {{.Name}}, {{.Method}}, {{.Path}}, {{.Body}}, {{.Status}}, {{.Schema}} },
+{{- end}}
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var body io.Reader
+			if test.body != "" {
+				body = strings.NewReader(test.body)
+			}
+			req := httptest.NewRequest(test.method, test.path, body)
+			if test.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != test.status {
+				t.Fatalf("got status %d, wanted %d: %s", w.Code, test.status, w.Body)
+			}
+			if test.schema == "" {
+				return
+			}
+
+			var schema openapi.Schema
+			if err := json.Unmarshal([]byte(test.schema), &schema); err != nil {
+				t.Fatal(err)
+			}
+			dec := json.NewDecoder(w.Body)
+			dec.UseNumber()
+			var v interface{}
+			if err := dec.Decode(&v); err != nil {
+				t.Fatal(err)
+			}
+			if err := schema.Validate(v); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+`))
@@ -0,0 +1,46 @@
+package openapi_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/vmihailenco/treemux"
+	"github.com/vmihailenco/treemux/openapi"
+)
+
+func TestGenerateContractTests(t *testing.T) {
+	router := treemux.New()
+	router.GET("/users/:id|int", handler).Meta(openapi.MetaResponse, User{})
+	router.POST("/users", handler).
+		Meta(openapi.MetaRequest, UpdateUser{}).
+		Meta(openapi.MetaStatus, http.StatusCreated)
+	router.GET("/files/*path", handler)
+
+	var buf bytes.Buffer
+	err := openapi.GenerateContractTests(&buf, router, openapi.ContractConfig{
+		Package: "api_test",
+		Router:  "newRouter()",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, s := range []string{
+		"package api_test\n",
+		"func TestContract(t *testing.T) {\n",
+		"handler := newRouter()\n",
+		"{`GET /files/*path`, `GET`, `/files/test`, \"\", 200, \"\"},\n",
+		"{`POST /users`, `POST`, `/users`, `{\"name\":\"string\"}`, 201, \"\"},\n",
+		"{`GET /users/:id`, `GET`, `/users/1`, \"\", 200, `{\"type\":\"object\",",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("output does not contain %q:\n%s", s, out)
+		}
+	}
+	if i, j := strings.Index(out, "GET /files"), strings.Index(out, "POST /users"); i > j {
+		t.Error("cases are not sorted")
+	}
+}
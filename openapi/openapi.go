@@ -0,0 +1,245 @@
+// Package openapi generates OpenAPI 3 documents from the routes of a treemux router.
+// Routes are described with metadata attached when they are registered:
+//
+//	router.POST("/users/:id|int", updateUser).
+//		Meta(openapi.MetaSummary, "Update a user").
+//		Meta(openapi.MetaRequest, UpdateUserRequest{}).
+//		Meta(openapi.MetaResponse, User{})
+//	router.GET("/openapi.json", openapi.Handler(router, openapi.Config{
+//		Title:   "Users API",
+//		Version: "1.0.0",
+//	}))
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/vmihailenco/treemux"
+)
+
+// Route metadata keys used to describe operations.
+const (
+	// MetaSummary is a short summary of the operation. The value must be a string.
+	MetaSummary = "openapi.summary"
+	// MetaDescription is a verbose description of the operation. The value must be a string.
+	MetaDescription = "openapi.description"
+	// MetaTags groups operations. The value must be a []string.
+	MetaTags = "openapi.tags"
+	// MetaRequest is a value whose type describes the JSON request body, e.g. CreateUser{}.
+	MetaRequest = "openapi.request"
+	// MetaResponse is a value whose type describes the JSON body of the success response.
+	MetaResponse = "openapi.response"
+	// MetaStatus is the status code of the success response. The value must be an int.
+	// The default is 200.
+	MetaStatus = "openapi.status"
+)
+
+// Config configures the generated document.
+type Config struct {
+	Title       string
+	Version     string
+	Description string
+	// Host selects the routes registered with TreeMux.Host. The default is the routes
+	// of the default tree.
+	Host string
+}
+
+// Document is an OpenAPI 3 document.
+type Document struct {
+	OpenAPI string              `json:"openapi"`
+	Info    Info                `json:"info"`
+	Paths   map[string]PathItem `json:"paths"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lower-case methods to operations.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Generate returns the document describing the routes of the router.
+// Implicit HEAD and OPTIONS handlers are not documented.
+func Generate(mux *treemux.TreeMux, cfg Config) *Document {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       cfg.Title,
+			Version:     cfg.Version,
+			Description: cfg.Description,
+		},
+		Paths: make(map[string]PathItem),
+	}
+
+	mux.Walk(func(route treemux.RouteNode) bool {
+		if route.Host != cfg.Host {
+			return true
+		}
+		path, names := pathTemplate(route.Pattern)
+		for method, info := range route.Routes {
+			if info.Method != method {
+				continue
+			}
+			item := doc.Paths[path]
+			if item == nil {
+				item = make(PathItem)
+				doc.Paths[path] = item
+			}
+			item[strings.ToLower(method)] = newOperation(info, names)
+		}
+		return true
+	})
+
+	return doc
+}
+
+// Handler returns a handler that serves the document generated on the first request.
+func Handler(mux *treemux.TreeMux, cfg Config) treemux.HandlerFunc {
+	var once sync.Once
+	var b []byte
+	var err error
+	return func(w http.ResponseWriter, req treemux.Request) error {
+		once.Do(func() {
+			b, err = json.Marshal(Generate(mux, cfg))
+		})
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write(b)
+		return err
+	}
+}
+
+// pathTemplate converts the route template to the OpenAPI path template,
+// e.g. "/users/:id/*path" to "/users/{id}/{path}", and returns the param names.
+func pathTemplate(route string) (string, []string) {
+	var names []string
+	segments := strings.Split(route, "/")
+	for i, seg := range segments {
+		if seg == "" || seg[0] != ':' && seg[0] != '*' {
+			continue
+		}
+		names = append(names, seg[1:])
+		segments[i] = "{" + seg[1:] + "}"
+	}
+	return strings.Join(segments, "/"), names
+}
+
+func newOperation(info *treemux.RouteInfo, params []string) *Operation {
+	op := &Operation{
+		OperationID: info.Name,
+		Responses:   make(map[string]*Response),
+	}
+	if v, ok := info.Value(MetaSummary); ok {
+		op.Summary, _ = v.(string)
+	}
+	if v, ok := info.Value(MetaDescription); ok {
+		op.Description, _ = v.(string)
+	}
+	if v, ok := info.Value(MetaTags); ok {
+		op.Tags, _ = v.([]string)
+	}
+
+	seen := make(map[string]bool, len(params))
+	for _, name := range params {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   paramSchema(info.ParamType(name)),
+		})
+	}
+
+	if v, ok := info.Value(MetaRequest); ok {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  jsonContent(v),
+		}
+	}
+
+	status := successStatus(info)
+	resp := &Response{Description: http.StatusText(status)}
+	if v, ok := info.Value(MetaResponse); ok {
+		resp.Content = jsonContent(v)
+	}
+	op.Responses[strconv.Itoa(status)] = resp
+
+	return op
+}
+
+func successStatus(info *treemux.RouteInfo) int {
+	if v, ok := info.Value(MetaStatus); ok {
+		if status, ok := v.(int); ok {
+			return status
+		}
+	}
+	return http.StatusOK
+}
+
+func jsonContent(v interface{}) map[string]*MediaType {
+	return map[string]*MediaType{
+		"application/json": {Schema: SchemaOf(v)},
+	}
+}
+
+func paramSchema(typ *treemux.ParamType) *Schema {
+	if typ == nil {
+		return &Schema{Type: "string"}
+	}
+	switch typ.Name {
+	case treemux.Int.Name, treemux.Int64.Name:
+		return &Schema{Type: "integer", Format: "int64"}
+	case treemux.Uint64.Name:
+		return &Schema{Type: "integer", Format: "int64", Minimum: new(float64)}
+	case treemux.Float64.Name:
+		return &Schema{Type: "number", Format: "double"}
+	case treemux.Bool.Name:
+		return &Schema{Type: "boolean"}
+	case treemux.UUID.Name:
+		return &Schema{Type: "string", Format: "uuid"}
+	default:
+		return &Schema{Type: "string"}
+	}
+}
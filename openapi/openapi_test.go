@@ -0,0 +1,126 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vmihailenco/treemux"
+	"github.com/vmihailenco/treemux/openapi"
+)
+
+type Base struct {
+	ID int64 `json:"id"`
+}
+
+type User struct {
+	Base
+	Name      string    `json:"name"`
+	Email     *string   `json:"email,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Manager   *User     `json:"manager,omitempty"`
+	password  string
+}
+
+type UpdateUser struct {
+	Name   string `json:"name"`
+	Secret string `json:"-"`
+}
+
+func handler(w http.ResponseWriter, req treemux.Request) error {
+	return nil
+}
+
+func TestGenerate(t *testing.T) {
+	router := treemux.New()
+	router.GET("/users/:id|int", handler).
+		Name("get-user").
+		Meta(openapi.MetaSummary, "Get a user").
+		Meta(openapi.MetaTags, []string{"users"}).
+		Meta(openapi.MetaResponse, User{})
+	router.PUT("/users/:id|int", handler).
+		Meta(openapi.MetaRequest, UpdateUser{})
+	router.GET("/files/*path", handler)
+	router.Host("admin.example.com").GET("/stats", handler)
+
+	doc := openapi.Generate(router, openapi.Config{Title: "API", Version: "1.0"})
+	if doc.OpenAPI != "3.0.3" || doc.Info.Title != "API" || doc.Info.Version != "1.0" {
+		t.Errorf("got %+v", doc)
+	}
+	if len(doc.Paths) != 2 {
+		t.Fatalf("got paths %v", doc.Paths)
+	}
+
+	users := doc.Paths["/users/{id}"]
+	if len(users) != 2 {
+		t.Fatalf("got operations %v", users)
+	}
+	get := users["get"]
+	if get.OperationID != "get-user" || get.Summary != "Get a user" || !reflect.DeepEqual(get.Tags, []string{"users"}) {
+		t.Errorf("got %+v", get)
+	}
+	if len(get.Parameters) != 1 || get.Parameters[0].Name != "id" || get.Parameters[0].In != "path" ||
+		get.Parameters[0].Schema.Type != "integer" {
+		t.Errorf("got parameters %+v", get.Parameters)
+	}
+
+	user := get.Responses["200"].Content["application/json"].Schema
+	if user.Type != "object" || !reflect.DeepEqual(user.Required, []string{"id", "name", "created_at"}) {
+		t.Errorf("got schema %+v", user)
+	}
+	if len(user.Properties) != 6 {
+		t.Errorf("got properties %v", user.Properties)
+	}
+	if p := user.Properties["created_at"]; p.Type != "string" || p.Format != "date-time" {
+		t.Errorf("got created_at %+v", p)
+	}
+	if p := user.Properties["email"]; p.Type != "string" || !p.Nullable {
+		t.Errorf("got email %+v", p)
+	}
+	if p := user.Properties["tags"]; p.Type != "array" || p.Items.Type != "string" {
+		t.Errorf("got tags %+v", p)
+	}
+	if p := user.Properties["manager"]; p.Type != "object" || p.Properties != nil {
+		t.Errorf("got manager %+v", p)
+	}
+
+	body := users["put"].RequestBody.Content["application/json"].Schema
+	if len(body.Properties) != 1 || body.Properties["name"] == nil {
+		t.Errorf("got request body %+v", body)
+	}
+
+	files := doc.Paths["/files/{path}"]["get"]
+	if files.Parameters[0].Name != "path" || files.Parameters[0].Schema.Type != "string" {
+		t.Errorf("got %+v", files.Parameters[0])
+	}
+	if files.Responses["200"].Description != "OK" {
+		t.Errorf("got responses %+v", files.Responses)
+	}
+
+	admin := openapi.Generate(router, openapi.Config{Host: "admin.example.com"})
+	if len(admin.Paths) != 1 || admin.Paths["/stats"]["get"] == nil {
+		t.Errorf("got paths %v", admin.Paths)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	router := treemux.New()
+	router.GET("/openapi.json", openapi.Handler(router, openapi.Config{Title: "API"}))
+
+	r := httptest.NewRequest("GET", "/openapi.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	paths := doc["paths"].(map[string]interface{})
+	if _, ok := paths["/openapi.json"]; !ok {
+		t.Errorf("got %s", w.Body)
+	}
+}
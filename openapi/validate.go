@@ -0,0 +1,232 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vmihailenco/treemux"
+)
+
+// ValidateConfig configures the ValidateResponses middleware.
+type ValidateConfig struct {
+	// Fail replaces invalid responses with 500 Internal Server Error describing
+	// the mismatch, which makes the tests fail loudly.
+	Fail bool
+	// OnMismatch is called with the validation error of invalid responses.
+	// The default logs the error.
+	OnMismatch func(req treemux.Request, err error)
+}
+
+// ValidateResponses returns a middleware that validates JSON bodies of success responses
+// (see MetaStatus) against the schema of MetaResponse declared for the route, catching
+// contract drift before the clients do. Responses are buffered, so it is meant for
+// development and tests only:
+//
+//	if devMode {
+//		router.Use(openapi.ValidateResponses(openapi.ValidateConfig{Fail: true}))
+//	}
+func ValidateResponses(cfg ValidateConfig) treemux.MiddlewareFunc {
+	if cfg.OnMismatch == nil {
+		cfg.OnMismatch = func(req treemux.Request, err error) {
+			log.Printf("openapi: %s %s: %s", req.Method, req.Route(), err)
+		}
+	}
+	return func(next treemux.HandlerFunc) treemux.HandlerFunc {
+		return func(w http.ResponseWriter, req treemux.Request) error {
+			v, ok := req.RouteInfo().Value(MetaResponse)
+			if !ok {
+				return next(w, req)
+			}
+
+			bw := &bufferedWriter{ResponseWriter: w}
+			if err := next(bw, req); err != nil {
+				bw.flush()
+				return err
+			}
+
+			if bw.status() == successStatus(req.RouteInfo()) && isJSON(w.Header().Get("Content-Type")) {
+				if err := validateBody(SchemaOf(v), bw.buf.Bytes()); err != nil {
+					cfg.OnMismatch(req, err)
+					if cfg.Fail {
+						w.Header().Del("Content-Length")
+						http.Error(w, "openapi: invalid response: "+err.Error(), http.StatusInternalServerError)
+						return nil
+					}
+				}
+			}
+
+			bw.flush()
+			return nil
+		}
+	}
+}
+
+func isJSON(contentType string) bool {
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	contentType = strings.TrimSpace(contentType)
+	return contentType == "application/json" || strings.HasSuffix(contentType, "+json")
+}
+
+type bufferedWriter struct {
+	http.ResponseWriter
+	statusCode int
+	buf        bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	return w.buf.Write(b)
+}
+
+func (w *bufferedWriter) status() int {
+	if w.statusCode == 0 {
+		return http.StatusOK
+	}
+	return w.statusCode
+}
+
+func (w *bufferedWriter) flush() {
+	if w.statusCode != 0 {
+		w.ResponseWriter.WriteHeader(w.statusCode)
+	}
+	_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+}
+
+func validateBody(s *Schema, body []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	return s.Validate(v)
+}
+
+// Validate checks the value decoded from JSON against the schema. Numbers can be
+// decoded either as float64 or as json.Number.
+func (s *Schema) Validate(v interface{}) error {
+	return s.validate("$", v)
+}
+
+func (s *Schema) validate(path string, v interface{}) error {
+	if v == nil {
+		if s.Nullable || s.Type == "" {
+			return nil
+		}
+		return fmt.Errorf("%s: got null, wanted %s", path, s.Type)
+	}
+
+	switch s.Type {
+	case "":
+		return nil
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return typeError(path, v, s.Type)
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return typeError(path, v, s.Type)
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				return fmt.Errorf("%s: %q is not a date-time", path, str)
+			}
+		}
+	case "integer", "number":
+		f, ok := number(v)
+		if !ok {
+			return typeError(path, v, s.Type)
+		}
+		if s.Type == "integer" && f != float64(int64(f)) {
+			return fmt.Errorf("%s: %v is not an integer", path, v)
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			return fmt.Errorf("%s: %v is less than %v", path, v, *s.Minimum)
+		}
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			return typeError(path, v, s.Type)
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+					return err
+				}
+			}
+		}
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return typeError(path, v, s.Type)
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("%s: required property %q is missing", path, name)
+			}
+		}
+		for name, value := range obj {
+			prop := s.Properties[name]
+			if prop == nil {
+				prop = s.AdditionalProperties
+			}
+			if prop == nil {
+				if s.Properties != nil {
+					return fmt.Errorf("%s: unknown property %q", path, name)
+				}
+				continue
+			}
+			if err := prop.validate(path+"."+name, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func number(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func typeError(path string, v interface{}, wanted string) error {
+	var got string
+	switch v.(type) {
+	case bool:
+		got = "boolean"
+	case string:
+		got = "string"
+	case float64, json.Number:
+		got = "number"
+	case []interface{}:
+		got = "array"
+	case map[string]interface{}:
+		got = "object"
+	default:
+		got = fmt.Sprintf("%T", v)
+	}
+	return fmt.Errorf("%s: got %s, wanted %s", path, got, wanted)
+}
@@ -0,0 +1,89 @@
+package openapi_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vmihailenco/treemux"
+	"github.com/vmihailenco/treemux/openapi"
+)
+
+func TestValidateResponses(t *testing.T) {
+	var mismatches []string
+	router := treemux.New()
+	router.ErrorHandler = func(w http.ResponseWriter, req treemux.Request, err error) {}
+	router.Use(openapi.ValidateResponses(openapi.ValidateConfig{
+		Fail: true,
+		OnMismatch: func(req treemux.Request, err error) {
+			mismatches = append(mismatches, err.Error())
+		},
+	}))
+
+	body := ""
+	status := http.StatusOK
+	respond := func(w http.ResponseWriter, req treemux.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, err := w.Write([]byte(body))
+		return err
+	}
+	router.GET("/users/:id", respond).Meta(openapi.MetaResponse, User{})
+	router.GET("/raw", respond)
+	router.GET("/fail", func(w http.ResponseWriter, req treemux.Request) error {
+		_, _ = w.Write([]byte("partial"))
+		return errors.New("fail")
+	}).Meta(openapi.MetaResponse, User{})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	tests := []struct {
+		status   int
+		body     string
+		mismatch string
+	}{
+		{200, `{"id": 1, "name": "x", "created_at": "2020-01-02T03:04:05Z", "tags": ["a"]}`, ""},
+		{200, `{"id": 1, "name": "x", "created_at": "2020-01-02T03:04:05Z", "email": null}`, ""},
+		{200, `{"id": 1, "name": "x"}`, `$: required property "created_at" is missing`},
+		{200, `{"id": 1.5, "name": "x", "created_at": "2020-01-02T03:04:05Z"}`, "$.id: 1.5 is not an integer"},
+		{200, `{"id": 1, "name": 2, "created_at": "2020-01-02T03:04:05Z"}`, "$.name: got number, wanted string"},
+		{200, `{"id": 1, "name": "x", "created_at": "yesterday"}`, `$.created_at: "yesterday" is not a date-time`},
+		{200, `{"id": 1, "name": "x", "created_at": "2020-01-02T03:04:05Z", "tags": [1]}`, "$.tags[0]: got number, wanted string"},
+		{200, `{"id": 1, "name": "x", "created_at": "2020-01-02T03:04:05Z", "age": 1}`, `$: unknown property "age"`},
+		{200, `[]`, "$: got array, wanted object"},
+		{404, `{"error": "not found"}`, ""},
+	}
+	for _, test := range tests {
+		mismatches = nil
+		body, status = test.body, test.status
+
+		w := get("/users/1")
+		if test.mismatch == "" {
+			if len(mismatches) != 0 || w.Code != test.status || w.Body.String() != test.body {
+				t.Errorf("%s: got %d %q, mismatches %v", test.body, w.Code, w.Body, mismatches)
+			}
+			continue
+		}
+		if len(mismatches) != 1 || mismatches[0] != test.mismatch {
+			t.Errorf("%s: got mismatches %q, wanted %q", test.body, mismatches, test.mismatch)
+		}
+		if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), test.mismatch) {
+			t.Errorf("%s: got %d %q", test.body, w.Code, w.Body)
+		}
+	}
+
+	mismatches = nil
+	body, status = `[]`, http.StatusOK
+	if w := get("/raw"); w.Code != http.StatusOK || w.Body.String() != "[]" || len(mismatches) != 0 {
+		t.Errorf("route without schema: got %d %q", w.Code, w.Body)
+	}
+	if w := get("/fail"); w.Body.String() != "partial" {
+		t.Errorf("got %q, wanted the partial response to be written", w.Body)
+	}
+}
@@ -0,0 +1,105 @@
+package treemux
+
+import (
+	"net/http"
+	"strings"
+)
+
+// autoOptionsHandler returns the OPTIONS handler added by TreeMux.AutoOptions.
+func autoOptionsHandler(t *TreeMux, methods *handlerMap) HandlerFunc {
+	return func(w http.ResponseWriter, req Request) error {
+		if t.OptionsHandler != nil {
+			return t.OptionsHandler(w, req)
+		}
+
+		if t.SafeAddRoutesWhileRunning {
+			t.mutex.RLock()
+		}
+		matched := methods
+		if req.lookup != nil && req.lookup.handlerMap != nil {
+			matched = req.lookup.handlerMap
+		}
+		allowed := matched.Methods()
+		if t.SafeAddRoutesWhileRunning {
+			t.mutex.RUnlock()
+		}
+
+		for _, method := range allowed {
+			w.Header().Add("Allow", method)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+// allowedMethods returns the methods of all routes matching the path. The handlerMap
+// of the matched node n only lists the methods of the best match, but search falls
+// back to the wildcard and catch-all routes for the other methods, e.g. GET
+// "/users/me" is served by "/users/:id" if "/users/me" only handles DELETE. It returns
+// n.handlerMap if no other route matches.
+func (t *TreeMux) allowedMethods(root *node, path string, n *node) *handlerMap {
+	var merged *handlerMap
+	visit := func(m *node) {
+		if m == n {
+			return
+		}
+		if merged == nil {
+			merged = newHandlerMap()
+			merge(merged, n.handlerMap)
+		}
+		merge(merged, m.handlerMap)
+	}
+
+	if root == t.root && len(t.shards) > 0 {
+		seg := path
+		if i := strings.IndexByte(path, '/'); i != -1 {
+			seg = path[:i]
+		}
+		if shard, ok := t.shards[seg]; ok {
+			shard.eachMatch(path, visit)
+		}
+	}
+	root.eachMatch(path, visit)
+
+	if merged == nil {
+		return n.handlerMap
+	}
+	return merged
+}
+
+// preflightTarget returns the route that answers a CORS preflight request matched to
+// the node n. If the route of n doesn't handle the method in the
+// Access-Control-Request-Method header, but a wildcard or catch-all route matching
+// the path does, its automatic OPTIONS handler answers instead, so the preflight
+// response comes from the middlewares of the route that will serve the request.
+func (t *TreeMux) preflightTarget(
+	root *node, r *http.Request, path string, n *node, handler HandlerFunc, params []Param,
+) (*node, HandlerFunc, []Param) {
+	requested := r.Header.Get("Access-Control-Request-Method")
+	if requested == "" || !n.handlerMap.isImplicit(http.MethodOptions) ||
+		n.handlerMap.Lookup(requested) != nil {
+		return n, handler, params
+	}
+
+	target, targetHandler, targetParams := t.find(root, requested, path)
+	if targetHandler == nil {
+		return n, handler, params
+	}
+	if options := target.handlerMap.Get(http.MethodOptions); options != nil {
+		return target, options, targetParams
+	}
+	return n, handler, params
+}
+
+// merge adds the methods of src that dst doesn't handle to dst.
+func merge(dst, src *handlerMap) {
+	src.each(func(method string, handler HandlerFunc) {
+		if dst.Get(method) != nil {
+			return
+		}
+		dst.Set(method, handler)
+		if info := src.Route(method); info != nil {
+			dst.SetRoute(method, info)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestAutoOptions(t *testing.T) {
+	router := New()
+	router.AutoOptions = true
+
+	api := router.NewGroup("/api")
+	api.Use(func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			if req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != "" {
+				w.WriteHeader(http.StatusOK)
+				return nil
+			}
+			return next(w, req)
+		}
+	})
+	api.GET("/users", simpleHandler)
+	api.POST("/users", simpleHandler)
+	router.GET("/custom", simpleHandler)
+	router.OPTIONS("/custom", func(w http.ResponseWriter, req Request) error {
+		w.WriteHeader(http.StatusTeapot)
+		return nil
+	})
+
+	tests := []struct {
+		path      string
+		preflight bool
+		code      int
+		allow     []string
+	}{
+		{"/api/users", false, http.StatusNoContent, []string{"GET", "HEAD", "OPTIONS", "POST"}},
+		{"/api/users", true, http.StatusOK, nil},
+		{"/custom", false, http.StatusTeapot, nil},
+	}
+	for _, test := range tests {
+		r, _ := newRequest("OPTIONS", test.path, nil)
+		if test.preflight {
+			r.Header.Set("Access-Control-Request-Method", "POST")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.code {
+			t.Errorf("%s: got code %d, wanted %d", test.path, w.Code, test.code)
+		}
+		if allow := w.Header()["Allow"]; !reflect.DeepEqual(allow, test.allow) {
+			t.Errorf("%s: got Allow %v, wanted %v", test.path, allow, test.allow)
+		}
+	}
+
+	router.OptionsHandler = func(w http.ResponseWriter, req Request) error {
+		w.WriteHeader(http.StatusAccepted)
+		return nil
+	}
+	r, _ := newRequest("OPTIONS", "/api/users", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusAccepted {
+		t.Errorf("got code %d, wanted OptionsHandler to be called", w.Code)
+	}
+}
+
+func TestAutoOptionsWildcards(t *testing.T) {
+	cors := func(origin string) MiddlewareFunc {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(w http.ResponseWriter, req Request) error {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				return next(w, req)
+			}
+		}
+	}
+
+	for _, sharded := range []bool{false, true} {
+		router := New()
+		router.AutoOptions = true
+		router.ShardByFirstSegment = sharded
+
+		public := router.NewGroup("")
+		public.Use(cors("*"))
+		public.GET("/users/:id", simpleHandler)
+		public.PUT("/users/:id", simpleHandler)
+		public.GET("/files/*path", simpleHandler)
+
+		admin := router.NewGroup("")
+		admin.Use(cors("https://admin.example.com"))
+		admin.DELETE("/users/me", simpleHandler)
+		admin.POST("/files/upload", simpleHandler)
+
+		tests := []struct {
+			method    string
+			path      string
+			preflight string
+			code      int
+			allow     []string
+			origin    string
+		}{
+			{"OPTIONS", "/users/me", "", http.StatusNoContent,
+				[]string{"DELETE", "GET", "HEAD", "OPTIONS", "PUT"}, "https://admin.example.com"},
+			{"OPTIONS", "/users/1", "", http.StatusNoContent,
+				[]string{"GET", "HEAD", "OPTIONS", "PUT"}, "*"},
+			{"OPTIONS", "/files/upload", "", http.StatusNoContent,
+				[]string{"GET", "HEAD", "OPTIONS", "POST"}, "https://admin.example.com"},
+			{"OPTIONS", "/users/me", "PUT", http.StatusNoContent,
+				[]string{"DELETE", "GET", "HEAD", "OPTIONS", "PUT"}, "*"},
+			{"OPTIONS", "/users/me", "DELETE", http.StatusNoContent,
+				[]string{"DELETE", "GET", "HEAD", "OPTIONS", "PUT"}, "https://admin.example.com"},
+			{"OPTIONS", "/files/upload", "GET", http.StatusNoContent,
+				[]string{"GET", "HEAD", "OPTIONS", "POST"}, "*"},
+			{"PATCH", "/users/me", "", http.StatusMethodNotAllowed,
+				[]string{"DELETE", "GET", "HEAD", "OPTIONS", "PUT"}, ""},
+		}
+		for _, test := range tests {
+			r, _ := newRequest(test.method, test.path, nil)
+			if test.preflight != "" {
+				r.Header.Set("Access-Control-Request-Method", test.preflight)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			name := test.method + " " + test.path + " " + test.preflight
+			if w.Code != test.code {
+				t.Errorf("%s: got code %d, wanted %d", name, w.Code, test.code)
+			}
+			if allow := w.Header()["Allow"]; !reflect.DeepEqual(allow, test.allow) {
+				t.Errorf("%s: got Allow %v, wanted %v", name, allow, test.allow)
+			}
+			if origin := w.Header().Get("Access-Control-Allow-Origin"); origin != test.origin {
+				t.Errorf("%s: got origin %q, wanted %q", name, origin, test.origin)
+			}
+		}
+	}
+}
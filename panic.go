@@ -0,0 +1,29 @@
+package treemux
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// recoverPanic calls the effective PanicHandler of the route with the value of a panic
+// while serving the request.
+// http.ErrAbortHandler is panicked again so the server aborts the response as requested.
+func (t *TreeMux) recoverPanic(w http.ResponseWriter, req Request) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	handler := t.EffectiveHandlers(req.info).PanicHandler
+	if v == http.ErrAbortHandler || handler == nil {
+		panic(v)
+	}
+	handler(w, req, v)
+}
+
+// DefaultPanicHandler is the default TreeMux.PanicHandler. It logs the panic with the
+// stack trace and responds with 500 Internal Server Error.
+func DefaultPanicHandler(w http.ResponseWriter, req Request, recovered interface{}) {
+	log.Printf("treemux: panic serving %s %s: %v\n%s", req.Method, req.URL.Path, recovered, debug.Stack())
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
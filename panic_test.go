@@ -0,0 +1,84 @@
+package treemux
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDefaultPanicHandler(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	router := New()
+	router.GET("/panic", func(w http.ResponseWriter, req Request) error {
+		panic("boom")
+	})
+
+	r, _ := newRequest("GET", "/panic", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("got code %d", w.Code)
+	}
+	if s := buf.String(); !strings.Contains(s, "GET /panic: boom") || !strings.Contains(s, "goroutine") {
+		t.Errorf("got log %q, wanted the panic and the stack", s)
+	}
+}
+
+func TestPanicHandler(t *testing.T) {
+	var recovered []interface{}
+	router := New()
+	router.PanicHandler = func(w http.ResponseWriter, req Request, v interface{}) {
+		recovered = append(recovered, v)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	router.Use(func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			if req.URL.Path == "/middleware" {
+				panic("middleware")
+			}
+			return next(w, req)
+		}
+	})
+	router.GET("/handler", func(w http.ResponseWriter, req Request) error {
+		panic("handler")
+	})
+	router.GET("/middleware", simpleHandler)
+	router.NotFoundHandler = func(w http.ResponseWriter, r *http.Request) {
+		panic("not found")
+	}
+
+	for _, path := range []string{"/handler", "/middleware", "/missing"} {
+		r, _ := newRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s: got code %d", path, w.Code)
+		}
+	}
+	if len(recovered) != 3 || recovered[2] != "not found" {
+		t.Errorf("got recovered values %v", recovered)
+	}
+}
+
+func TestPanicHandlerAbort(t *testing.T) {
+	router := New()
+	router.GET("/abort", func(w http.ResponseWriter, req Request) error {
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("got %v, wanted http.ErrAbortHandler", v)
+		}
+	}()
+	r, _ := newRequest("GET", "/abort", nil)
+	router.ServeHTTP(httptest.NewRecorder(), r)
+}
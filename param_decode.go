@@ -0,0 +1,244 @@
+package treemux
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+var errMissingParam = errors.New("missing param")
+
+// RegisterParamType registers a custom param type. Decode must be a function of the form
+// func(s string) (T, error). The returned ParamType can be declared with Route.Param and
+// values of type T can be decoded with Request.DecodeParam and Request.BindParams.
+// Decoders are keyed by T and are only used by the router they are registered with.
+//
+//	money := router.RegisterParamType("money", func(s string) (Money, error) { ... })
+//	router.GET("/quotes/:amount", quote).Param("amount", money)
+func (t *TreeMux) RegisterParamType(name string, decode interface{}) *ParamType {
+	fn := reflect.ValueOf(decode)
+	ft := fn.Type()
+	if ft.Kind() != reflect.Func ||
+		ft.NumIn() != 1 || ft.In(0).Kind() != reflect.String ||
+		ft.NumOut() != 2 || ft.Out(1) != errorType {
+		panic(fmt.Sprintf("treemux: param decoder must be func(string) (T, error), got %s", ft))
+	}
+
+	typ := &ParamType{
+		Name: name,
+		Decode: func(s string) (interface{}, error) {
+			out := fn.Call([]reflect.Value{reflect.ValueOf(s)})
+			if err, _ := out[1].Interface().(error); err != nil {
+				return nil, err
+			}
+			return out[0].Interface(), nil
+		},
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if _, ok := t.paramTypes[name]; ok {
+		panic(fmt.Sprintf("param type %q is already registered", name))
+	}
+	if t.paramTypes == nil {
+		t.paramTypes = make(map[string]*ParamType)
+	}
+	t.paramTypes[name] = typ
+
+	decoders := make(map[reflect.Type]*ParamType, len(t.paramDecoders)+1)
+	for k, v := range t.paramDecoders {
+		decoders[k] = v
+	}
+	decoders[ft.Out(0)] = typ
+	t.paramDecoders = decoders
+
+	return typ
+}
+
+// ParamType returns the param type registered with RegisterParamType.
+func (t *TreeMux) ParamType(name string) *ParamType {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.paramTypes[name]
+}
+
+var (
+	errorType           = reflect.TypeOf((*error)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// StatusCode returns 400 Bad Request so error handlers can report param errors
+// to the client.
+func (e *ParamError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// DecodeParam decodes the param into dst like Params.Decode, but also supports the types
+// registered with TreeMux.RegisterParamType on the router serving the request.
+// If the route declares the param type with Route.Param or in the route pattern,
+// the value is decoded with the declared type, so it is validated as in the router.
+func (req Request) DecodeParam(name string, dst interface{}) error {
+	return req.Params.decode(req.paramDecoders(), req.info, name, dst)
+}
+
+// BindParams binds the params like Params.Bind, but also supports the types
+// registered with TreeMux.RegisterParamType on the router serving the request.
+// Params with a type declared for the route are decoded as in DecodeParam.
+func (req Request) BindParams(dst interface{}) error {
+	return req.Params.bind(req.paramDecoders(), req.info, dst)
+}
+
+func (req Request) paramDecoders() map[reflect.Type]*ParamType {
+	if req.mux == nil {
+		return nil
+	}
+	req.mux.mutex.RLock()
+	defer req.mux.mutex.RUnlock()
+	return req.mux.paramDecoders
+}
+
+// Decode decodes the param into dst, which must be a pointer. Types implementing
+// encoding.TextUnmarshaler, strings, bools, and numbers are supported; use
+// Request.DecodeParam for the types registered with TreeMux.RegisterParamType and
+// the types declared for the route. Decode failures are returned as *ParamError.
+func (ps Params) Decode(name string, dst interface{}) error {
+	return ps.decode(nil, nil, name, dst)
+}
+
+func (ps Params) decode(
+	decoders map[reflect.Type]*ParamType, info *RouteInfo, name string, dst interface{},
+) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("treemux: Decode(non-pointer %T)", dst)
+	}
+	s, ok := ps.Get(name)
+	if !ok {
+		return &ParamError{Param: name, Type: paramTypeOf(decoders, v.Elem().Type()), Err: errMissingParam}
+	}
+	return decodeRouteParam(decoders, info, name, s, v.Elem())
+}
+
+// Bind decodes the params into the fields of the struct pointed to by dst.
+// Fields are matched using the "param" struct tag, e.g. `param:"id"`. Params that are
+// not present are skipped. Field types are decoded as in Decode; use Request.BindParams
+// to apply the types declared for the route.
+func (ps Params) Bind(dst interface{}) error {
+	return ps.bind(nil, nil, dst)
+}
+
+func (ps Params) bind(decoders map[reflect.Type]*ParamType, info *RouteInfo, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("treemux: Bind(non-struct pointer %T)", dst)
+	}
+	v = v.Elem()
+
+	st := v.Type()
+	for i := 0; i < st.NumField(); i++ {
+		name := st.Field(i).Tag.Get("param")
+		if name == "" || name == "-" {
+			continue
+		}
+		s, ok := ps.Get(name)
+		if !ok {
+			continue
+		}
+		if err := decodeRouteParam(decoders, info, name, s, v.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeRouteParam decodes the param with the type declared for the route, if any.
+// The decoded value is stored if it fits v, e.g. an int64 in an int64 field or in
+// a named int64 type. Otherwise the validated value is decoded as usual, e.g. into
+// a string field.
+func decodeRouteParam(
+	decoders map[reflect.Type]*ParamType, info *RouteInfo, name, s string, v reflect.Value,
+) error {
+	if typ := info.ParamType(name); typ != nil {
+		decoded, err := typ.Decode(s)
+		if err != nil {
+			return &ParamError{Param: name, Value: s, Type: typ, Err: err}
+		}
+		if dv := reflect.ValueOf(decoded); dv.IsValid() {
+			switch {
+			case dv.Type().AssignableTo(v.Type()):
+				v.Set(dv)
+				return nil
+			case dv.Kind() == v.Kind() && dv.Type().ConvertibleTo(v.Type()):
+				v.Set(dv.Convert(v.Type()))
+				return nil
+			}
+		}
+	}
+	return decodeParam(decoders, name, s, v)
+}
+
+func decodeParam(decoders map[reflect.Type]*ParamType, name, s string, v reflect.Value) error {
+	err := setParam(decoders, s, v)
+	if err == nil {
+		return nil
+	}
+	return &ParamError{Param: name, Value: s, Type: paramTypeOf(decoders, v.Type()), Err: err}
+}
+
+func paramTypeOf(decoders map[reflect.Type]*ParamType, typ reflect.Type) *ParamType {
+	if pt, ok := decoders[typ]; ok {
+		return pt
+	}
+	return &ParamType{Name: typ.String()}
+}
+
+func setParam(decoders map[reflect.Type]*ParamType, s string, v reflect.Value) error {
+	if pt, ok := decoders[v.Type()]; ok {
+		decoded, err := pt.Decode(s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(decoded))
+		return nil
+	}
+
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
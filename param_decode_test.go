@@ -0,0 +1,163 @@
+package treemux
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type testMoney struct {
+	Cents    int64
+	Currency string
+}
+
+func decodeTestMoney(s string) (testMoney, error) {
+	i := strings.IndexByte(s, '-')
+	if i == -1 {
+		return testMoney{}, errors.New("expected <cents>-<currency>")
+	}
+	cents, err := strconv.ParseInt(s[:i], 10, 64)
+	if err != nil {
+		return testMoney{}, err
+	}
+	return testMoney{Cents: cents, Currency: s[i+1:]}, nil
+}
+
+func TestRegisterParamType(t *testing.T) {
+	router := New()
+	money := router.RegisterParamType("money", decodeTestMoney)
+	if router.ParamType("money") != money {
+		t.Fatal("param type is not registered")
+	}
+
+	router.GET("/quotes/:amount/:count", func(w http.ResponseWriter, req Request) error {
+		var m testMoney
+		if err := req.DecodeParam("amount", &m); err != nil {
+			return err
+		}
+		var args struct {
+			Amount testMoney `param:"amount"`
+			Count  uint8     `param:"count"`
+			Other  string
+		}
+		if err := req.BindParams(&args); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(w, "%d %s %d", m.Cents, args.Amount.Currency, args.Count)
+		return err
+	}).Param("amount", money)
+
+	r, _ := newRequest("GET", "/quotes/100-usd/3", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Body.String() != "100 usd 3" {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+
+	r, _ = newRequest("GET", "/quotes/100/3", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("got %d, wanted 400", w.Code)
+	}
+}
+
+func TestRegisterParamTypePerRouter(t *testing.T) {
+	decode := func(router *TreeMux, path string) string {
+		var got string
+		router.GET("/quotes/:amount", func(w http.ResponseWriter, req Request) error {
+			var m testMoney
+			err := req.DecodeParam("amount", &m)
+			got = fmt.Sprintf("%+v %v", m, err != nil)
+			return nil
+		})
+		r, _ := newRequest("GET", path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), r)
+		return got
+	}
+
+	first := New()
+	first.RegisterParamType("money", decodeTestMoney)
+	second := New()
+	second.RegisterParamType("money", func(s string) (testMoney, error) {
+		return testMoney{Currency: s}, nil
+	})
+	third := New()
+
+	if got := decode(first, "/quotes/100-usd"); got != "{Cents:100 Currency:usd} false" {
+		t.Errorf("first router: got %q", got)
+	}
+	if got := decode(second, "/quotes/100-usd"); got != "{Cents:0 Currency:100-usd} false" {
+		t.Errorf("second router: got %q", got)
+	}
+	if got := decode(third, "/quotes/100-usd"); got != "{Cents:0 Currency:} true" {
+		t.Errorf("router without the type: got %q", got)
+	}
+}
+
+type testItemID int64
+
+func TestBindParamsRouteTypes(t *testing.T) {
+	router := New()
+	router.GET("/items/:id/:owner", func(w http.ResponseWriter, req Request) error {
+		var args struct {
+			ID    testItemID `param:"id"`
+			Owner string     `param:"owner"`
+		}
+		if err := req.BindParams(&args); err != nil {
+			return err
+		}
+		var raw struct {
+			Owner string `param:"owner"`
+		}
+		if err := req.Params.Bind(&raw); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(w, "%d %s %s", args.ID, args.Owner, raw.Owner)
+		return err
+	}).Param("id", Int64).Param("owner", UUID)
+
+	owner := "6BA7B810-9DAD-11D1-80B4-00C04FD430C8"
+	r, _ := newRequest("GET", "/items/42/"+owner, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if wanted := "42 " + strings.ToLower(owner) + " " + owner; w.Body.String() != wanted {
+		t.Errorf("got %d %q, wanted %q", w.Code, w.Body.String(), wanted)
+	}
+
+	var m testMoney
+	req := Request{Params: Params{{"amount", "100"}}, info: &RouteInfo{
+		ParamTypes: map[string]*ParamType{"amount": router.RegisterParamType("money", decodeTestMoney)},
+	}}
+	var paramErr *ParamError
+	if err := req.DecodeParam("amount", &m); !errors.As(err, &paramErr) || paramErr.Type.Name != "money" {
+		t.Errorf("got %v, wanted a money *ParamError", err)
+	}
+}
+
+func TestParamsDecodeError(t *testing.T) {
+	params := Params{{"count", "300"}}
+
+	var n uint8
+	err := params.Decode("count", &n)
+	var paramErr *ParamError
+	if !errors.As(err, &paramErr) {
+		t.Fatalf("got %v, wanted *ParamError", err)
+	}
+	if paramErr.Param != "count" || paramErr.Type.Name != "uint8" || paramErr.StatusCode() != 400 {
+		t.Errorf("unexpected error %+v", paramErr)
+	}
+
+	if err := params.Decode("missing", &n); !errors.As(err, &paramErr) {
+		t.Errorf("got %v for a missing param", err)
+	}
+
+	var count int
+	if err := params.Decode("count", &count); err != nil || count != 300 {
+		t.Errorf("got %d, %v", count, err)
+	}
+}
@@ -0,0 +1,234 @@
+package treemux
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParamType describes the type of a route param. It is declared once per route with
+// Route.Param and used to validate requests, to decode values, and to document routes.
+type ParamType struct {
+	// Name is the type name, e.g. "int64".
+	Name string
+	// Decode parses the param value.
+	Decode func(s string) (interface{}, error)
+}
+
+// Built-in param types.
+var (
+	String = &ParamType{Name: "string", Decode: func(s string) (interface{}, error) {
+		return s, nil
+	}}
+	Int = &ParamType{Name: "int", Decode: func(s string) (interface{}, error) {
+		return strconv.Atoi(s)
+	}}
+	Int64 = &ParamType{Name: "int64", Decode: func(s string) (interface{}, error) {
+		return strconv.ParseInt(s, 10, 64)
+	}}
+	Uint64 = &ParamType{Name: "uint64", Decode: func(s string) (interface{}, error) {
+		return strconv.ParseUint(s, 10, 64)
+	}}
+	Float64 = &ParamType{Name: "float64", Decode: func(s string) (interface{}, error) {
+		return strconv.ParseFloat(s, 64)
+	}}
+	Bool = &ParamType{Name: "bool", Decode: func(s string) (interface{}, error) {
+		return strconv.ParseBool(s)
+	}}
+	UUID = &ParamType{Name: "uuid", Decode: func(s string) (interface{}, error) {
+		if !isUUID(s) {
+			return nil, errors.New("invalid UUID")
+		}
+		return strings.ToLower(s), nil
+	}}
+)
+
+// builtinParamTypes contains the param types that can be used in route patterns
+// without registration, e.g. "/posts/:id|int".
+var builtinParamTypes = map[string]*ParamType{
+	String.Name:  String,
+	Int.Name:     Int,
+	Int64.Name:   Int64,
+	Uint64.Name:  Uint64,
+	Float64.Name: Float64,
+	Bool.Name:    Bool,
+	UUID.Name:    UUID,
+}
+
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ParamError is returned when a param value does not match the declared type.
+type ParamError struct {
+	Param string
+	Value string
+	Type  *ParamType
+	Err   error
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("treemux: param %q=%q is not a valid %s: %s", e.Param, e.Value, e.Type.Name, e.Err)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// Param declares the type of the route param. Requests with values that can't be decoded
+// are rejected with 400 Bad Request before reaching the handler.
+//
+//	router.GET("/users/:id", getUser).Param("id", treemux.Int64)
+func (r *Route) Param(name string, typ *ParamType) *Route {
+	if !routeHasParam(r.info.Route, name) {
+		panic(fmt.Sprintf("route %q does not have param %q", r.info.Route, name))
+	}
+	if r.info.ParamTypes == nil {
+		r.info.ParamTypes = make(map[string]*ParamType)
+	}
+	r.info.ParamTypes[name] = typ
+	return r
+}
+
+func routeHasParam(route, name string) bool {
+	for _, seg := range strings.Split(route, "/") {
+		if len(seg) > 1 && (seg[0] == ':' || seg[0] == '*') && seg[1:] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ParamType returns the declared type of the param or nil.
+func (r *RouteInfo) ParamType(name string) *ParamType {
+	if r == nil {
+		return nil
+	}
+	return r.ParamTypes[name]
+}
+
+// ParamValue returns the param value decoded using the type declared with Route.Param
+// or in the route pattern. It returns the raw string if the param type is not declared
+// and nil if there is no such param.
+func (req Request) ParamValue(name string) interface{} {
+	if v, ok := req.paramValues[name]; ok {
+		return v
+	}
+	s, ok := req.Params.Get(name)
+	if !ok {
+		return nil
+	}
+	typ := req.info.ParamType(name)
+	if typ == nil {
+		return s
+	}
+	v, err := typ.Decode(s)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// parsePatternTypes strips param types from the route pattern, e.g. "/posts/:id|int"
+// becomes "/posts/:id", and returns the declared types keyed by param name.
+// Type names are resolved among the built-in types and the types registered
+// with TreeMux.RegisterParamType. The caller must hold t.mutex.
+func (t *TreeMux) parsePatternTypes(path string) (string, map[string]*ParamType) {
+	if strings.IndexByte(path, '|') == -1 {
+		return path, nil
+	}
+
+	var types map[string]*ParamType
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if len(seg) < 2 || (seg[0] != ':' && seg[0] != '*') {
+			continue
+		}
+		pipe := strings.IndexByte(seg, '|')
+		if pipe == -1 {
+			continue
+		}
+
+		name, typeName := seg[1:pipe], seg[pipe+1:]
+		typ := builtinParamTypes[typeName]
+		if typ == nil {
+			typ = t.paramTypes[typeName]
+		}
+		if name == "" || typ == nil {
+			panic(fmt.Sprintf("route %q has param %q of unknown type %q", path, name, typeName))
+		}
+
+		if types == nil {
+			types = make(map[string]*ParamType)
+		}
+		types[name] = typ
+		segments[i] = seg[:pipe]
+	}
+	return strings.Join(segments, "/"), types
+}
+
+// decodePatternParams decodes the params declared in the route pattern.
+// It returns false if any of the values does not match its type.
+func decodePatternParams(info *RouteInfo, params Params) (map[string]interface{}, bool) {
+	values := make(map[string]interface{}, len(info.patternTypes))
+	for _, name := range info.patternTypes {
+		v, err := info.ParamTypes[name].Decode(params.Text(name))
+		if err != nil {
+			return nil, false
+		}
+		values[name] = v
+	}
+	return values, true
+}
+
+// lookupPatternParams decodes the params declared in the route pattern during lookup.
+// The params are sanitized first, as they are before the handler is called, so
+// a value like " 1" matches an int param of a route that trims spaces.
+func lookupPatternParams(info *RouteInfo, params Params) (map[string]interface{}, bool) {
+	if len(info.Sanitize) > 0 {
+		if sanitized, err := sanitizeParams(info.Sanitize, params); err == nil {
+			params = sanitized
+		}
+	}
+	return decodePatternParams(info, params)
+}
+
+// checkParams validates the params against the types declared for the route.
+func checkParams(info *RouteInfo, params Params) *ParamError {
+	for name, typ := range info.ParamTypes {
+		if info.isPatternType(name) {
+			continue
+		}
+		s := params.Text(name)
+		if _, err := typ.Decode(s); err != nil {
+			return &ParamError{Param: name, Value: s, Type: typ, Err: err}
+		}
+	}
+	return nil
+}
+
+func (r *RouteInfo) isPatternType(name string) bool {
+	for _, s := range r.patternTypes {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package treemux
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRouteParamTypes(t *testing.T) {
+	router := New()
+	router.GET("/users/:id/files/*path", func(w http.ResponseWriter, req Request) error {
+		_, err := fmt.Fprintf(w, "%T %v %T", req.ParamValue("id"), req.ParamValue("id"), req.ParamValue("path"))
+		return err
+	}).Param("id", Int64)
+	router.GET("/orders/:id", simpleHandler).Param("id", UUID)
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/users/42/files/a/b", 200, "int64 42 string"},
+		{"/users/abc/files/a", 400, ""},
+		{"/orders/123e4567-e89b-12d3-a456-426614174000", 200, ""},
+		{"/orders/123e4567", 400, ""},
+	}
+	for _, test := range tests {
+		r, _ := newRequest("GET", test.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != test.code {
+			t.Errorf("%s: got %d, wanted %d", test.path, w.Code, test.code)
+		}
+		if test.body != "" && w.Body.String() != test.body {
+			t.Errorf("%s: got %q, wanted %q", test.path, w.Body.String(), test.body)
+		}
+	}
+}
+
+func TestRouteParamUnknown(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for an unknown param")
+		}
+	}()
+	New().GET("/users/:id", simpleHandler).Param("user", Int)
+}
+
+func TestPatternParamTypes(t *testing.T) {
+	router := New()
+	router.GET("/posts/:id|int", func(w http.ResponseWriter, req Request) error {
+		_, err := fmt.Fprintf(w, "%T %v", req.ParamValue("id"), req.ParamValue("id"))
+		return err
+	})
+	docs := router.NewGroup("/docs/:slug|uuid")
+	docs.GET("/pages/:n|uint64", func(w http.ResponseWriter, req Request) error {
+		_, err := fmt.Fprintf(w, "%v %v", req.ParamValue("slug"), req.ParamValue("n"))
+		return err
+	})
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/posts/42", 200, "int 42"},
+		{"/posts/abc", 404, ""},
+		{"/docs/123E4567-E89B-12D3-A456-426614174000/pages/3", 200, "123e4567-e89b-12d3-a456-426614174000 3"},
+		{"/docs/123e4567/pages/3", 404, ""},
+		{"/docs/123e4567-e89b-12d3-a456-426614174000/pages/-1", 404, ""},
+	}
+	for _, test := range tests {
+		r, _ := newRequest("GET", test.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != test.code {
+			t.Errorf("%s: got %d, wanted %d", test.path, w.Code, test.code)
+		}
+		if test.body != "" && w.Body.String() != test.body {
+			t.Errorf("%s: got %q, wanted %q", test.path, w.Body.String(), test.body)
+		}
+	}
+
+	info := router.routeInfos()[0]
+	if info.Route != "/docs/:slug/pages/:n" || info.ParamType("n") != Uint64 {
+		t.Errorf("got route %q with n of type %v", info.Route, info.ParamType("n"))
+	}
+}
+
+func TestPatternParamTypesBeforeMiddlewares(t *testing.T) {
+	var calls []string
+	router := New()
+	router.Use(func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			calls = append(calls, "router "+req.URL.Path)
+			return next(w, req)
+		}
+	})
+	api := router.NewGroup("/api")
+	api.Use(func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			calls = append(calls, "group "+req.URL.Path)
+			return next(w, req)
+		}
+	})
+	api.GET("/posts/:id|int", simpleHandler)
+	router.PathSyntax = BraceSyntax
+	api.GET("/codes/{code:[A-Z]{3}}", simpleHandler)
+	api.GET("/search/:n|int", simpleHandler).Sanitize(SanitizeRule{TrimSpace: true})
+
+	for _, test := range []struct {
+		path string
+		code int
+	}{
+		{"/api/posts/abc", http.StatusNotFound},
+		{"/api/codes/usd", http.StatusNotFound},
+		{"/api/posts/1", http.StatusOK},
+		{"/api/search/%201", http.StatusOK},
+	} {
+		r, _ := newRequest("GET", test.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != test.code {
+			t.Errorf("%s: got %d, wanted %d", test.path, w.Code, test.code)
+		}
+	}
+
+	wanted := []string{
+		"router /api/posts/abc", "router /api/codes/usd",
+		"router /api/posts/1", "group /api/posts/1",
+		"router /api/search/ 1", "group /api/search/ 1",
+	}
+	if !reflect.DeepEqual(calls, wanted) {
+		t.Errorf("got calls %q", calls)
+	}
+}
+
+func TestPatternParamUnknownType(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for an unknown param type")
+		}
+	}()
+	New().GET("/users/:id|money", simpleHandler)
+}
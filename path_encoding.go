@@ -0,0 +1,97 @@
+package treemux
+
+import "strings"
+
+// canonicalPath returns the canonical percent-encoding of the path used when
+// TreeMux.CanonicalPaths is enabled. Percent-encoded bytes that are allowed in a path
+// segment are decoded, all other bytes are encoded using upper case hex digits,
+// and slashes are kept as is, so "/caf%c3%a9/%7Euser" and "/café/~user" both become
+// "/caf%C3%A9/~user". Encoded slashes (%2F) stay encoded and don't split segments.
+func canonicalPath(path string) string {
+	if isCanonicalPath(path) {
+		return path
+	}
+
+	var b strings.Builder
+	b.Grow(len(path) + 8)
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c == '%' {
+			if i+2 < len(path) && ishex(path[i+1]) && ishex(path[i+2]) {
+				c = unhex(path[i+1])<<4 | unhex(path[i+2])
+				i += 2
+				if c != '/' && isPathChar(c) {
+					b.WriteByte(c)
+					continue
+				}
+			}
+			writeEscaped(&b, c)
+			continue
+		}
+		if c == '/' || isPathChar(c) {
+			b.WriteByte(c)
+		} else {
+			writeEscaped(&b, c)
+		}
+	}
+	return b.String()
+}
+
+func isCanonicalPath(path string) bool {
+	for i := 0; i < len(path); i++ {
+		if c := path[i]; c != '/' && !isPathChar(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// canonicalRoute returns the canonical form of the route template. Only static
+// segments are encoded; param segments and backslash escapes are preserved.
+func canonicalRoute(route string) string {
+	segments := strings.Split(route, "/")
+	for i, seg := range segments {
+		if seg == "" || seg[0] == ':' || seg[0] == '*' {
+			continue
+		}
+		segments[i] = escapeRouteLiteral(canonicalPath(unescapeRouteLiteral(seg)))
+	}
+	return strings.Join(segments, "/")
+}
+
+// isPathChar reports whether c is allowed unencoded in a path segment
+// (pchar in RFC 3986), excluding '%'.
+func isPathChar(c byte) bool {
+	if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+		return true
+	}
+	switch c {
+	case '-', '.', '_', '~', // unreserved
+		'!', '$', '&', '\'', '(', ')', '*', '+', ',', ';', '=', // sub-delims
+		':', '@':
+		return true
+	}
+	return false
+}
+
+func writeEscaped(b *strings.Builder, c byte) {
+	const upperhex = "0123456789ABCDEF"
+	b.WriteByte('%')
+	b.WriteByte(upperhex[c>>4])
+	b.WriteByte(upperhex[c&15])
+}
+
+func ishex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
@@ -0,0 +1,93 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCanonicalPath(t *testing.T) {
+	tests := []struct {
+		path, want string
+	}{
+		{"/users/1", "/users/1"},
+		{"/caf%c3%a9", "/caf%C3%A9"},
+		{"/café", "/caf%C3%A9"},
+		{"/%7Euser/%41bc", "/~user/Abc"},
+		{"/a%2fb", "/a%2Fb"},
+		{"/a b", "/a%20b"},
+		{"/100%", "/100%25"},
+		{"/%zz", "/%25zz"},
+		{"/%2A:x@y", "/*:x@y"},
+	}
+	for _, test := range tests {
+		if got := canonicalPath(test.path); got != test.want {
+			t.Errorf("canonicalPath(%q) = %q, wanted %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestCanonicalRoute(t *testing.T) {
+	tests := []struct {
+		route, want string
+	}{
+		{"/café/:id", "/caf%C3%A9/:id"},
+		{"/files/*path", "/files/*path"},
+		{"/\\:literal", "/\\:literal"},
+		{"/%3Aliteral", "/\\:literal"},
+		{"/\\\\:wild", "/%5C:wild"},
+	}
+	for _, test := range tests {
+		if got := canonicalRoute(test.route); got != test.want {
+			t.Errorf("canonicalRoute(%q) = %q, wanted %q", test.route, got, test.want)
+		}
+	}
+}
+
+func TestCanonicalPaths(t *testing.T) {
+	var route, param string
+	handler := func(w http.ResponseWriter, req Request) error {
+		route = req.Route()
+		param = req.Param("name")
+		return nil
+	}
+
+	router := New()
+	router.CanonicalPaths = true
+	router.GET("/café", handler)
+	router.GET("/caf%C3%A9s/:name", handler)
+	router.GET("/\\:literal", handler)
+	router.GET("/a%2Fb", handler)
+
+	tests := []struct {
+		path  string
+		code  int
+		route string
+		param string
+	}{
+		{"/café", 200, "/caf%C3%A9", ""},
+		{"/caf%C3%A9", 200, "/caf%C3%A9", ""},
+		{"/caf%c3%a9", 200, "/caf%C3%A9", ""},
+		{"/cafés/j%C3%B6rg", 200, "/caf%C3%A9s/:name", "jörg"},
+		{"/caf%C3%A9s/a%2Fb", 200, "/caf%C3%A9s/:name", "a/b"},
+		{"/:literal", 200, "/\\:literal", ""},
+		{"/%3Aliteral", 200, "/\\:literal", ""},
+		{"/a%2fb", 200, "/a%2Fb", ""},
+		{"/a/b", 404, "", ""},
+	}
+	for _, test := range tests {
+		route, param = "", ""
+		r, _ := http.NewRequest("GET", "http://example.com", nil)
+		r.RequestURI = test.path
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.code {
+			t.Errorf("%s: got code %d, wanted %d", test.path, w.Code, test.code)
+		}
+		if route != test.route || param != test.param {
+			t.Errorf("%s: got route %q and param %q, wanted %q and %q",
+				test.path, route, param, test.route, test.param)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package treemux
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PathSyntax selects the param syntax accepted in route patterns.
+type PathSyntax int
+
+const (
+	// ColonSyntax accepts ":param" and "*path" params. This is the default.
+	ColonSyntax PathSyntax = iota
+	// BraceSyntax also accepts the chi and gorilla/mux style "{param}" and
+	// "{param:regex}" params, which are converted to ":param" when the route is added.
+	// A regex restricts the values matched by the param: requests with other values
+	// are not matched by the route, as with param types in the route pattern.
+	// A "{param:.*}" or "{param:.+}" param at the end of the pattern becomes the
+	// catch-all "*param". Brace params must span a whole path segment.
+	BraceSyntax
+)
+
+// convertBraceParams converts the "{param}" and "{param:regex}" params of the route
+// pattern to the colon syntax and returns the param types that check the regexes
+// keyed by param name.
+func convertBraceParams(path string) (string, map[string]*ParamType) {
+	if strings.IndexByte(path, '{') == -1 {
+		return path, nil
+	}
+
+	var b strings.Builder
+	b.Grow(len(path))
+	var types map[string]*ParamType
+	for i := 0; i < len(path); {
+		if path[i] != '{' {
+			b.WriteByte(path[i])
+			i++
+			continue
+		}
+
+		end := closingBrace(path, i)
+		if end == -1 {
+			panic(fmt.Sprintf("route %q has an unclosed brace", path))
+		}
+		last := end+1 == len(path)
+		if i == 0 || path[i-1] != '/' || (!last && path[end+1] != '/') {
+			panic(fmt.Sprintf("param %s in route %q must be a whole path segment", path[i:end+1], path))
+		}
+
+		name, re := path[i+1:end], ""
+		if colon := strings.IndexByte(name, ':'); colon != -1 {
+			name, re = name[:colon], name[colon+1:]
+		}
+		if name == "" {
+			panic(fmt.Sprintf("param %s in route %q has no name", path[i:end+1], path))
+		}
+
+		if last && (re == ".*" || re == ".+") {
+			b.WriteString("*" + name)
+		} else {
+			b.WriteString(":" + name)
+		}
+		if re != "" && re != ".*" {
+			if types == nil {
+				types = make(map[string]*ParamType)
+			}
+			types[name] = regexpParamType(re)
+		}
+		i = end + 1
+	}
+	return b.String(), types
+}
+
+// closingBrace returns the index of the brace closing the one at i or -1.
+func closingBrace(path string, i int) int {
+	depth := 0
+	for j := i; j < len(path); j++ {
+		switch path[j] {
+		case '\\':
+			j++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+func regexpParamType(re string) *ParamType {
+	r := regexp.MustCompile("^(?:" + re + ")$")
+	return &ParamType{Name: "regexp:" + re, Decode: func(s string) (interface{}, error) {
+		if !r.MatchString(s) {
+			return nil, errors.New("does not match " + re)
+		}
+		return s, nil
+	}}
+}
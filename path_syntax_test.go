@@ -0,0 +1,90 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestBraceSyntax(t *testing.T) {
+	router := New()
+	router.PathSyntax = BraceSyntax
+	handler := func(w http.ResponseWriter, req Request) error {
+		var params []string
+		for _, p := range req.Params {
+			params = append(params, p.Name+"="+p.Value+";")
+		}
+		sort.Strings(params)
+		_, err := w.Write([]byte(strings.Join(params, "")))
+		return err
+	}
+	router.GET("/users/{id:[0-9]+}", handler)
+	router.GET("/users/{name}/posts/{slug}", handler)
+	router.GET("/codes/{code:[A-Z]{3}}", handler)
+	router.GET("/files/{path:.*}", handler)
+	router.GET("/mixed/:a/{b}", handler)
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/users/42", 200, "id=42;"},
+		{"/users/abc", 404, ""},
+		{"/users/bob/posts/hello", 200, "name=bob;slug=hello;"},
+		{"/codes/USD", 200, "code=USD;"},
+		{"/codes/USDT", 404, ""},
+		{"/files/a/b.txt", 200, "path=a/b.txt;"},
+		{"/mixed/1/2", 200, "a=1;b=2;"},
+	}
+	for _, test := range tests {
+		r, _ := newRequest("GET", test.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.code {
+			t.Errorf("%s: got code %d, wanted %d", test.path, w.Code, test.code)
+		}
+		if test.code == 200 && w.Body.String() != test.body {
+			t.Errorf("%s: got body %q, wanted %q", test.path, w.Body.String(), test.body)
+		}
+	}
+
+	var routes []string
+	for _, info := range router.Routes() {
+		routes = append(routes, info.Route)
+	}
+	want := "/codes/:code /files/*path /mixed/:a/:b /users/:id /users/:name/posts/:slug"
+	if got := strings.Join(routes, " "); got != want {
+		t.Errorf("got routes %q, wanted %q", got, want)
+	}
+}
+
+func TestBraceSyntaxDisabled(t *testing.T) {
+	router := New()
+	router.GET("/{id}", simpleHandler)
+
+	r, _ := newRequest("GET", "/{id}", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("got code %d for the literal route", w.Code)
+	}
+}
+
+func TestBraceSyntaxPanics(t *testing.T) {
+	for _, path := range []string{"/users/{id", "/users/{id}.json", "/users/x{id}", "/{}"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected a panic", path)
+				}
+			}()
+			router := New()
+			router.PathSyntax = BraceSyntax
+			router.GET(path, simpleHandler)
+		}()
+	}
+}
@@ -0,0 +1,60 @@
+package treemux
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HandlePattern registers the handler for a net/http.ServeMux pattern, e.g.
+// "GET /users/{id}" or "/files/{path...}", so handlers written for the standard
+// library mux can be moved to the router unchanged. "{name}" becomes ":name" and
+// "{name...}" becomes the catch-all "*name". As with ServeMux, a pattern ending with
+// a slash matches the whole subtree: "/static/" matches "/static/" and everything
+// below it, with the rest of the path in the "rest" param, and "/" matches every
+// path. "{$}" at the end, e.g. "/posts/{$}", matches only the path itself. Patterns
+// without a method match every method without a route of its own, including OPTIONS
+// and nonstandard methods. Host patterns are not supported, use TreeMux.Host instead.
+//
+//	router.HandlePattern("GET /users/{id}", getUser)
+//
+// HandlePattern returns a Route that can be used to attach metadata to the route.
+func (g *Group) HandlePattern(
+	pattern string, handler HandlerFunc, middlewares ...MiddlewareFunc,
+) *Route {
+	method, path, subtree := parseServeMuxPattern(pattern)
+	if method == "" {
+		method = anyMethod
+	}
+
+	g.mux.mutex.Lock()
+	defer g.mux.mutex.Unlock()
+
+	if subtree {
+		return g.handlePaths(method, []string{path + "*rest", path}, handler, middlewares)
+	}
+	return g.handle(method, path, handler, middlewares)
+}
+
+// parseServeMuxPattern splits the ServeMux pattern into the method and the route
+// in the colon syntax. subtree reports whether the pattern also matches the paths
+// below the route, which ends with a slash.
+func parseServeMuxPattern(pattern string) (method, path string, subtree bool) {
+	path = strings.TrimLeft(pattern, " \t")
+	if i := strings.IndexAny(path, " \t"); i != -1 {
+		method, path = path[:i], strings.TrimLeft(path[i:], " \t")
+	}
+	if !strings.HasPrefix(path, "/") {
+		panic(fmt.Sprintf("pattern %q must have a path starting with /", pattern))
+	}
+
+	if strings.HasSuffix(path, "{$}") {
+		path = strings.TrimSuffix(path, "{$}")
+	} else if strings.HasSuffix(path, "/") {
+		subtree = true
+	}
+	if i := strings.LastIndex(path, "...}"); i != -1 && i+4 == len(path) {
+		path = path[:i] + ":.*}"
+	}
+	path, _ = convertBraceParams(path)
+	return method, path, subtree
+}
@@ -0,0 +1,99 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandlePattern(t *testing.T) {
+	router := New()
+	router.HandlePattern("GET /users/{id}", func(w http.ResponseWriter, req Request) error {
+		_, err := w.Write([]byte("user " + req.Param("id")))
+		return err
+	})
+	router.HandlePattern("/files/{path...}", func(w http.ResponseWriter, req Request) error {
+		_, err := w.Write([]byte(req.Method + " " + req.Param("path")))
+		return err
+	})
+	router.HandlePattern("POST /posts/{$}", func(w http.ResponseWriter, req Request) error {
+		_, err := w.Write([]byte("posts"))
+		return err
+	})
+	router.HandlePattern("/static/", func(w http.ResponseWriter, req Request) error {
+		_, err := w.Write([]byte("static " + req.Param("rest")))
+		return err
+	})
+	router.HandlePattern("/", func(w http.ResponseWriter, req Request) error {
+		_, err := w.Write([]byte("root " + req.Method + " " + req.Param("rest")))
+		return err
+	}).Meta("root", true)
+
+	tests := []struct {
+		method, path string
+		code         int
+		body         string
+	}{
+		{"GET", "/users/1", http.StatusOK, "user 1"},
+		{"POST", "/users/1", http.StatusOK, "root POST users/1"},
+		{"GET", "/files/a/b.txt", http.StatusOK, "GET a/b.txt"},
+		{"DELETE", "/files/a", http.StatusOK, "DELETE a"},
+		{"POST", "/posts/", http.StatusOK, "posts"},
+		{"POST", "/posts/1", http.StatusOK, "root POST posts/1"},
+		{"GET", "/static/", http.StatusOK, "static "},
+		{"GET", "/static/css/app.css", http.StatusOK, "static css/app.css"},
+		{"GET", "/", http.StatusOK, "root GET "},
+		{"GET", "/anything", http.StatusOK, "root GET anything"},
+		{"OPTIONS", "/anything", http.StatusOK, "root OPTIONS anything"},
+		{"PURGE", "/files/a", http.StatusOK, "PURGE a"},
+	}
+	for _, test := range tests {
+		r, _ := newRequest(test.method, test.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != test.code {
+			t.Errorf("%s %s: got status %d, wanted %d", test.method, test.path, w.Code, test.code)
+			continue
+		}
+		if test.body != "" && w.Body.String() != test.body {
+			t.Errorf("%s %s: got body %q, wanted %q", test.method, test.path, w.Body.String(), test.body)
+		}
+	}
+
+	for _, path := range []string{"/", "/a/b"} {
+		r, _ := newRequest("PUT", path, nil)
+		lr, _ := router.Lookup(nil, r)
+		if ok, _ := lr.RouteInfo().Bool("root"); !ok {
+			t.Errorf("%s: got route %+v, wanted the root metadata", path, lr.RouteInfo())
+		}
+	}
+}
+
+func TestParseServeMuxPattern(t *testing.T) {
+	tests := []struct {
+		pattern, method, path string
+		subtree               bool
+	}{
+		{"GET /users/{id}", "GET", "/users/:id", false},
+		{"DELETE  /users/{id}/posts/{post}", "DELETE", "/users/:id/posts/:post", false},
+		{"/static/{path...}", "", "/static/*path", false},
+		{"/static/", "", "/static/", true},
+		{"/", "", "/", true},
+		{"/{$}", "", "/", false},
+		{"GET /posts/{$}", "GET", "/posts/", false},
+	}
+	for _, test := range tests {
+		method, path, subtree := parseServeMuxPattern(test.pattern)
+		if method != test.method || path != test.path || subtree != test.subtree {
+			t.Errorf("%q: got %q %q %t, wanted %q %q %t", test.pattern,
+				method, path, subtree, test.method, test.path, test.subtree)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a host pattern")
+		}
+	}()
+	parseServeMuxPattern("GET example.com/users")
+}
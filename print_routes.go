@@ -0,0 +1,170 @@
+package treemux
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteFormat is the output format of PrintRoutes.
+type RouteFormat int
+
+const (
+	// RouteFormatTable prints an aligned text table.
+	RouteFormatTable RouteFormat = iota
+	// RouteFormatJSON prints a JSON array.
+	RouteFormatJSON
+)
+
+// Routes returns the registered routes of all hosts sorted by path and method.
+// A GET route that also serves HEAD requests is returned once.
+func (t *TreeMux) Routes() []*RouteInfo {
+	return t.routeInfos()
+}
+
+// routeInfos returns the registered routes of all hosts sorted by path and method.
+func (t *TreeMux) routeInfos() []*RouteInfo {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	var routes []*RouteInfo
+	seen := make(map[*RouteInfo]struct{})
+	for _, root := range t.trees() {
+		root.walk(0, func(n *node, depth int) {
+			if n.handlerMap == nil {
+				return
+			}
+			for _, info := range n.handlerMap.routes {
+				if _, ok := seen[info]; ok {
+					continue
+				}
+				seen[info] = struct{}{}
+				routes = append(routes, info)
+			}
+		})
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Route != routes[j].Route {
+			return routes[i].Route < routes[j].Route
+		}
+		if routes[i].Method != routes[j].Method {
+			return routes[i].Method < routes[j].Method
+		}
+		return routes[i].Host < routes[j].Host
+	})
+	return routes
+}
+
+type printedRoute struct {
+	Method      string `json:"method"`
+	Route       string `json:"route"`
+	Handler     string `json:"handler"`
+	File        string `json:"file,omitempty"`
+	Line        int    `json:"line,omitempty"`
+	Middlewares int    `json:"middlewares"`
+}
+
+func newPrintedRoute(info *RouteInfo) printedRoute {
+	return printedRoute{
+		Method:      info.Method,
+		Route:       info.Route,
+		Handler:     info.Handler,
+		File:        info.File,
+		Line:        info.Line,
+		Middlewares: info.middlewareCount,
+	}
+}
+
+// PrintRoutes writes a report of the registered routes with the method, the path,
+// the handler name, and the number of middlewares. It is meant to be logged at startup.
+//
+//	router.PrintRoutes(os.Stdout, treemux.RouteFormatTable)
+func (t *TreeMux) PrintRoutes(w io.Writer, format RouteFormat) error {
+	infos := t.routeInfos()
+	routes := make([]printedRoute, len(infos))
+	for i, info := range infos {
+		routes[i] = newPrintedRoute(info)
+	}
+
+	switch format {
+	case RouteFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(routes)
+	case RouteFormatTable:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "METHOD\tROUTE\tHANDLER\tMIDDLEWARES")
+		for _, r := range routes {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\n", r.Method, r.Route, r.Handler, r.Middlewares)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("treemux: unknown route format %d", format)
+	}
+}
+
+// RoutesHandler returns a debug handler that responds with the registered routes and
+// the locations of their handlers in JSON. With the "path" query argument (and optionally
+// "method", GET by default) it responds only with the route that serves the path:
+//
+//	GET /debug/routes?method=POST&path=/users/1
+func (t *TreeMux) RoutesHandler() HandlerFunc {
+	return func(w http.ResponseWriter, req Request) error {
+		var routes []printedRoute
+
+		query := req.URL.Query()
+		if path := query.Get("path"); path != "" {
+			method := query.Get("method")
+			if method == "" {
+				method = http.MethodGet
+			}
+			r, err := http.NewRequest(method, path, nil)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return nil
+			}
+			r.RequestURI = path
+
+			lr, _ := t.Lookup(w, r)
+			if lr.info == nil {
+				http.Error(w, "route not found", http.StatusNotFound)
+				return nil
+			}
+			routes = append(routes, newPrintedRoute(lr.info))
+		} else {
+			for _, info := range t.routeInfos() {
+				routes = append(routes, newPrintedRoute(info))
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		return json.NewEncoder(w).Encode(routes)
+	}
+}
+
+// funcInfo returns the name of the function without the package path,
+// e.g. "api.(*Server).getUser", and the location of its declaration.
+func funcInfo(fn interface{}) (name, file string, line int) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return "", "", 0
+	}
+	f := runtime.FuncForPC(v.Pointer())
+	if f == nil {
+		return "", "", 0
+	}
+	file, line = f.FileLine(f.Entry())
+
+	name = f.Name()
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name, file, line
+}
@@ -0,0 +1,79 @@
+package treemux
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPrintRoutes(t *testing.T) {
+	router := New()
+	router.GET("/", simpleHandler)
+	api := router.NewGroup("/api")
+	api.Use(func(next HandlerFunc) HandlerFunc { return next })
+	api.GET("/users/:id", simpleHandler)
+	api.POST("/users", simpleHandler)
+
+	var buf bytes.Buffer
+	if err := router.PrintRoutes(&buf, RouteFormatTable); err != nil {
+		t.Fatal(err)
+	}
+	wanted := `METHOD  ROUTE           HANDLER                MIDDLEWARES
+GET     /               treemux.simpleHandler  0
+POST    /api/users      treemux.simpleHandler  1
+GET     /api/users/:id  treemux.simpleHandler  1
+`
+	if buf.String() != wanted {
+		t.Errorf("got\n%s\nwanted\n%s", buf.String(), wanted)
+	}
+
+	buf.Reset()
+	if err := router.PrintRoutes(&buf, RouteFormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var routes []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &routes); err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 3 || routes[2]["route"] != "/api/users/:id" ||
+		!strings.HasSuffix(routes[2]["handler"].(string), "simpleHandler") {
+		t.Errorf("got %v", routes)
+	}
+}
+
+func TestRoutesHandler(t *testing.T) {
+	router := New()
+	router.GET("/users/:id", simpleHandler)
+	router.POST("/users/:id", simpleHandler)
+	router.GET("/debug/routes", router.RoutesHandler())
+
+	info := router.routeInfos()[1]
+	if info.Handler != "treemux.simpleHandler" ||
+		!strings.HasSuffix(info.File, "router_test.go") || info.Line == 0 {
+		t.Errorf("unexpected handler info %q %s:%d", info.Handler, info.File, info.Line)
+	}
+
+	get := func(url string) (int, []printedRoute) {
+		r, _ := newRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		var routes []printedRoute
+		json.Unmarshal(w.Body.Bytes(), &routes)
+		return w.Code, routes
+	}
+
+	if _, routes := get("/debug/routes"); len(routes) != 3 {
+		t.Errorf("got %v", routes)
+	}
+	_, routes := get("/debug/routes?method=POST&path=/users/1")
+	if len(routes) != 1 || routes[0].Method != "POST" || routes[0].Route != "/users/:id" ||
+		routes[0].Line != info.Line {
+		t.Errorf("got %v", routes)
+	}
+	if code, _ := get("/debug/routes?path=/missing"); code != http.StatusNotFound {
+		t.Errorf("got %d for a missing route", code)
+	}
+}
@@ -0,0 +1,123 @@
+package treemux
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WritePrometheus writes the metrics in the Prometheus text exposition format:
+//
+//	treemux_requests_total{method,route,status}                  - counter
+//	treemux_request_errors_total{method,route}                   - counter
+//	treemux_request_duration_seconds{method,route,status}        - histogram
+//	treemux_response_size_bytes{method,route}                    - histogram
+//
+// Routes are labeled with their patterns, e.g. route="/users/:id", so the number of
+// series does not grow with the number of distinct URLs. Routes added with TreeMux.Host
+// also have the host label.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	snapshot := m.Snapshot()
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString("# HELP treemux_requests_total Number of requests served by the route.\n")
+	bw.WriteString("# TYPE treemux_requests_total counter\n")
+	for _, s := range snapshot {
+		for _, code := range sortedCodes(s.StatusCodes) {
+			writeSample(bw, "treemux_requests_total", routeLabels(s, code), "", float64(s.StatusCodes[code]))
+		}
+	}
+
+	bw.WriteString("# HELP treemux_request_errors_total Number of requests that failed with an error or a 5xx status.\n")
+	bw.WriteString("# TYPE treemux_request_errors_total counter\n")
+	for _, s := range snapshot {
+		writeSample(bw, "treemux_request_errors_total", routeLabels(s, 0), "", float64(s.Errors))
+	}
+
+	bw.WriteString("# HELP treemux_request_duration_seconds Request latency.\n")
+	bw.WriteString("# TYPE treemux_request_duration_seconds histogram\n")
+	for _, s := range snapshot {
+		codes := make([]int, 0, len(s.StatusLatency))
+		for code := range s.StatusLatency {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			writeHistogram(bw, "treemux_request_duration_seconds", routeLabels(s, code), s.StatusLatency[code])
+		}
+	}
+
+	bw.WriteString("# HELP treemux_response_size_bytes Response body size.\n")
+	bw.WriteString("# TYPE treemux_response_size_bytes histogram\n")
+	for _, s := range snapshot {
+		writeHistogram(bw, "treemux_response_size_bytes", routeLabels(s, 0), s.ResponseSize)
+	}
+
+	return bw.Flush()
+}
+
+// PrometheusHandler returns a handler that serves the metrics to Prometheus:
+//
+//	metrics := router.Metrics()
+//	router.Use(metrics.Middleware)
+//	router.GET("/metrics", metrics.PrometheusHandler())
+func (m *Metrics) PrometheusHandler() HandlerFunc {
+	return func(w http.ResponseWriter, req Request) error {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		return m.WritePrometheus(w)
+	}
+}
+
+func sortedCodes(counts map[int]uint64) []int {
+	codes := make([]int, 0, len(counts))
+	for code := range counts {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	return codes
+}
+
+// routeLabels returns the labels of the route without the braces. The host is
+// omitted for the default tree and the status is omitted if it is 0.
+func routeLabels(s RouteStats, statusCode int) string {
+	var labels string
+	if s.Host != "" {
+		labels = `host="` + escapeLabel(s.Host) + `",`
+	}
+	labels += `method="` + escapeLabel(s.Method) + `",route="` + escapeLabel(s.Route) + `"`
+	if statusCode != 0 {
+		labels += `,status="` + strconv.Itoa(statusCode) + `"`
+	}
+	return labels
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func writeHistogram(bw *bufio.Writer, name, labels string, h Histogram) {
+	var cumulative uint64
+	for i, bound := range h.Buckets {
+		cumulative += h.Counts[i]
+		le := `,le="` + strconv.FormatFloat(bound, 'g', -1, 64) + `"`
+		writeSample(bw, name+"_bucket", labels, le, float64(cumulative))
+	}
+	writeSample(bw, name+"_bucket", labels, `,le="+Inf"`, float64(h.Count))
+	writeSample(bw, name+"_sum", labels, "", h.Sum)
+	writeSample(bw, name+"_count", labels, "", float64(h.Count))
+}
+
+func writeSample(bw *bufio.Writer, name, labels, extra string, value float64) {
+	bw.WriteString(name)
+	bw.WriteByte('{')
+	bw.WriteString(labels)
+	bw.WriteString(extra)
+	bw.WriteString("} ")
+	bw.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	bw.WriteByte('\n')
+}
@@ -0,0 +1,88 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	router := New()
+	metrics := router.Metrics()
+	metrics.LatencyBuckets = []float64{1, 10}
+	metrics.SizeBuckets = []float64{100}
+	router.Use(metrics.Middleware)
+	router.GET("/users/:id", func(w http.ResponseWriter, req Request) error {
+		if req.Param("id") == "missing" {
+			return NewHTTPError(http.StatusNotFound, "")
+		}
+		_, err := w.Write([]byte("ok"))
+		return err
+	})
+	router.GET("/metrics", metrics.PrometheusHandler())
+
+	for _, id := range []string{"1", "2", "missing"} {
+		r, _ := newRequest("GET", "/users/"+id, nil)
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	r, _ := newRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("got content type %q", ct)
+	}
+
+	body := w.Body.String()
+	for _, line := range []string{
+		"# TYPE treemux_requests_total counter",
+		`treemux_requests_total{method="GET",route="/users/:id",status="200"} 2`,
+		`treemux_requests_total{method="GET",route="/users/:id",status="404"} 1`,
+		`treemux_request_errors_total{method="GET",route="/users/:id"} 1`,
+		"# TYPE treemux_request_duration_seconds histogram",
+		`treemux_request_duration_seconds_bucket{method="GET",route="/users/:id",status="200",le="1"} 2`,
+		`treemux_request_duration_seconds_bucket{method="GET",route="/users/:id",status="200",le="+Inf"} 2`,
+		`treemux_request_duration_seconds_count{method="GET",route="/users/:id",status="404"} 1`,
+		`treemux_response_size_bytes_bucket{method="GET",route="/users/:id",le="100"} 3`,
+		`treemux_response_size_bytes_sum{method="GET",route="/users/:id"} 4`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing %q in\n%s", line, body)
+		}
+	}
+}
+
+func TestWritePrometheusHosts(t *testing.T) {
+	router := New()
+	metrics := router.Metrics()
+	router.Use(metrics.Middleware)
+	router.GET("/users", simpleHandler)
+	router.Host("api.example.com").GET("/users", simpleHandler)
+
+	for _, host := range []string{"example.com", "api.example.com"} {
+		r, _ := newRequest("GET", "/users", nil)
+		r.Host = host
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	var b strings.Builder
+	if err := metrics.WritePrometheus(&b); err != nil {
+		t.Fatal(err)
+	}
+	body := b.String()
+	for _, line := range []string{
+		`treemux_requests_total{method="GET",route="/users",status="200"} 1`,
+		`treemux_requests_total{host="api.example.com",method="GET",route="/users",status="200"} 1`,
+	} {
+		if strings.Count(body, line+"\n") != 1 {
+			t.Errorf("%q is not written once in\n%s", line, body)
+		}
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if got := escapeLabel("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,443 @@
+package treemux
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriteNginxConfig writes the routes of the host tree, or of the default tree if host
+// is empty, as nginx location blocks to be included in a server block. Routes are
+// proxied to the upstream with proxy_pass and limited to their methods; the route
+// timeout set with the MetaMaxTimeout metadata becomes proxy_read_timeout. Redirects
+// registered with LoadRedirects are answered by nginx itself.
+//
+//	server {
+//		listen 80;
+//		include treemux.conf;
+//	}
+//
+// Static routes become exact locations and routes with params become regex locations,
+// which are ordered by the routing priority. Requests that match no route, including
+// trailing slash variants, are not proxied unless the server block adds a fallback
+// location. Since nginx cannot vary proxy_read_timeout by method, a path with several
+// route timeouts uses the largest one.
+func (t *TreeMux) WriteNginxConfig(w io.Writer, host, upstream string) error {
+	host = NormalizeHost(host)
+	bw := bufio.NewWriter(w)
+	for i, route := range t.proxyRoutes(host) {
+		if i > 0 {
+			fmt.Fprintln(bw)
+		}
+		route.writeNginx(bw, upstream)
+	}
+	return bw.Flush()
+}
+
+// WriteEnvoyConfig writes the routes as an Envoy RouteConfiguration in JSON. The default
+// tree becomes a virtual host matching any domain and every host tree a virtual host
+// matching the host. Routes are forwarded to the cluster with the route timeout set
+// with the MetaMaxTimeout metadata, and redirects registered with LoadRedirects are
+// answered by Envoy itself. Methods are matched with the ":method" header.
+func (t *TreeMux) WriteEnvoyConfig(w io.Writer, cluster string) error {
+	t.mutex.RLock()
+	hosts := make([]string, 0, len(t.hosts))
+	for host := range t.hosts {
+		hosts = append(hosts, host)
+	}
+	t.mutex.RUnlock()
+	sort.Strings(hosts)
+
+	cfg := envoyRouteConfig{Name: "treemux"}
+	for _, host := range append([]string{""}, hosts...) {
+		vhost := envoyVirtualHost{Name: "default", Domains: []string{"*"}}
+		if host != "" {
+			vhost.Name = host
+			vhost.Domains = []string{host}
+			if _, _, err := net.SplitHostPort(host); err != nil {
+				// Hosts without a port match requests with any port.
+				vhost.Domains = append(vhost.Domains, host+":*")
+			}
+		}
+		for _, route := range t.proxyRoutes(host) {
+			vhost.Routes = append(vhost.Routes, route.envoyRoutes(cluster)...)
+		}
+		cfg.VirtualHosts = append(cfg.VirtualHosts, vhost)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cfg)
+}
+
+// proxyRoute is a path of the routing tree with its methods grouped by how the proxy
+// handles them.
+type proxyRoute struct {
+	path     string
+	segments []proxySegment
+	methods  []string
+	actions  []proxyAction
+}
+
+type proxySegment struct {
+	// kind is 0 for static segments, ':' for wildcards, and '*' for catch-alls.
+	kind  byte
+	value string
+}
+
+type proxyAction struct {
+	methods      []string
+	redirect     string
+	redirectCode int
+	timeout      time.Duration
+}
+
+// proxyRoutes returns the routes of the host tree sorted by the routing priority,
+// so that the first matching proxy rule is the route treemux would pick.
+func (t *TreeMux) proxyRoutes(host string) []*proxyRoute {
+	var routes []*proxyRoute
+	t.Walk(func(node RouteNode) bool {
+		if node.Host != host {
+			return true
+		}
+
+		path := node.Pattern
+		if node.AddSlash {
+			path += "/"
+		}
+		route := &proxyRoute{path: path, methods: node.Methods}
+		for _, seg := range strings.Split(path, "/")[1:] {
+			switch {
+			case seg != "" && (seg[0] == ':' || seg[0] == '*'):
+				route.segments = append(route.segments, proxySegment{kind: seg[0], value: seg[1:]})
+			default:
+				route.segments = append(route.segments, proxySegment{value: unescapeRouteLiteral(seg)})
+			}
+		}
+
+		for _, method := range node.Methods {
+			action := proxyAction{methods: []string{method}}
+			if info := node.Routes[method]; info != nil {
+				action.redirect = info.redirect
+				action.redirectCode = info.redirectCode
+				if v, ok := info.Value(MetaMaxTimeout); ok {
+					action.timeout, _ = v.(time.Duration)
+				}
+			}
+			route.addAction(action)
+		}
+		routes = append(routes, route)
+		return true
+	})
+
+	sort.Slice(routes, func(i, j int) bool {
+		a, b := routes[i].segments, routes[j].segments
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k].kind != b[k].kind {
+				return a[k].kind < b[k].kind
+			}
+			if a[k].kind == 0 && a[k].value != b[k].value {
+				return a[k].value < b[k].value
+			}
+		}
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return routes[i].path < routes[j].path
+	})
+	return routes
+}
+
+func (r *proxyRoute) addAction(action proxyAction) {
+	for i := range r.actions {
+		a := &r.actions[i]
+		if a.redirect == action.redirect && a.redirectCode == action.redirectCode && a.timeout == action.timeout {
+			a.methods = append(a.methods, action.methods...)
+			return
+		}
+	}
+	r.actions = append(r.actions, action)
+}
+
+func (r *proxyRoute) isStatic() bool {
+	for _, seg := range r.segments {
+		if seg.kind != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// regexp returns the anchored regular expression matching the route. Params are
+// captured by groups named by the name function.
+func (r *proxyRoute) regexp(name func(i int) string) string {
+	var b strings.Builder
+	b.WriteByte('^')
+	param := 0
+	for _, seg := range r.segments {
+		b.WriteByte('/')
+		switch seg.kind {
+		case 0:
+			b.WriteString(regexp.QuoteMeta(seg.value))
+			continue
+		case ':':
+			b.WriteString("(" + name(param) + "[^/]+)")
+		case '*':
+			b.WriteString("(" + name(param) + ".*)")
+		}
+		param++
+	}
+	b.WriteByte('$')
+	return b.String()
+}
+
+// paramIndex returns the position of the param among the route params or -1.
+func (r *proxyRoute) paramIndex(name string) int {
+	i := 0
+	for _, seg := range r.segments {
+		if seg.kind == 0 {
+			continue
+		}
+		if seg.value == name {
+			return i
+		}
+		i++
+	}
+	return -1
+}
+
+// redirectTarget substitutes the params of the redirect target with the result of
+// the ref function, which is passed the param position.
+func (r *proxyRoute) redirectTarget(target string, ref func(i int) string) string {
+	segments := strings.Split(target, "/")
+	for i, seg := range segments {
+		if seg == "" {
+			continue
+		}
+		switch seg[0] {
+		case ':', '*':
+			segments[i] = ref(r.paramIndex(seg[1:]))
+		case '\\':
+			segments[i] = unescapeRouteLiteral(seg)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func (r *proxyRoute) writeNginx(w io.Writer, upstream string) {
+	if r.isStatic() {
+		fmt.Fprintf(w, "location = %s {\n", nginxQuote(r.staticPath()))
+	} else {
+		re := r.regexp(func(i int) string { return "?<p" + strconv.Itoa(i+1) + ">" })
+		fmt.Fprintf(w, "location ~ %s {\n", nginxQuote(re))
+	}
+
+	var proxied []string
+	var timeout time.Duration
+	for _, action := range r.actions {
+		if action.redirect == "" {
+			proxied = append(proxied, action.methods...)
+			if action.timeout > timeout {
+				timeout = action.timeout
+			}
+			continue
+		}
+
+		target := r.redirectTarget(action.redirect, func(i int) string {
+			return "$p" + strconv.Itoa(i+1)
+		})
+		if !strings.Contains(action.redirect, "?") {
+			target += "$is_args$args"
+		}
+		ret := fmt.Sprintf("return %d %s;", action.redirectCode, nginxQuote(target))
+		if len(action.methods) == len(r.methods) {
+			fmt.Fprintf(w, "\t%s\n", ret)
+			continue
+		}
+		fmt.Fprintf(w, "\tif ($request_method ~ ^(%s)$) {\n\t\t%s\n\t}\n",
+			strings.Join(action.methods, "|"), ret)
+	}
+
+	if len(proxied) > 0 {
+		fmt.Fprintf(w, "\tlimit_except %s {\n\t\tdeny all;\n\t}\n", strings.Join(r.methods, " "))
+		if timeout > 0 {
+			fmt.Fprintf(w, "\tproxy_read_timeout %s;\n", nginxDuration(timeout))
+		}
+		fmt.Fprintf(w, "\tproxy_pass http://%s;\n", upstream)
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// staticPath returns the path of a static route.
+func (r *proxyRoute) staticPath() string {
+	var b strings.Builder
+	for _, seg := range r.segments {
+		b.WriteByte('/')
+		b.WriteString(seg.value)
+	}
+	return b.String()
+}
+
+// nginxQuote quotes the string if nginx would not read it as a single token.
+func nginxQuote(s string) string {
+	if !strings.ContainsAny(s, " \t\n\"'{};\\#") {
+		return s
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+func nginxDuration(d time.Duration) string {
+	if d%time.Second == 0 {
+		return strconv.FormatInt(int64(d/time.Second), 10) + "s"
+	}
+	ms := (d + time.Millisecond - 1) / time.Millisecond
+	return strconv.FormatInt(int64(ms), 10) + "ms"
+}
+
+type envoyRouteConfig struct {
+	Name         string             `json:"name"`
+	VirtualHosts []envoyVirtualHost `json:"virtual_hosts"`
+}
+
+type envoyVirtualHost struct {
+	Name    string       `json:"name"`
+	Domains []string     `json:"domains"`
+	Routes  []envoyRoute `json:"routes"`
+}
+
+type envoyRoute struct {
+	Match    envoyRouteMatch      `json:"match"`
+	Route    *envoyRouteAction    `json:"route,omitempty"`
+	Redirect *envoyRedirectAction `json:"redirect,omitempty"`
+}
+
+type envoyRouteMatch struct {
+	Path      string               `json:"path,omitempty"`
+	SafeRegex *envoyRegex          `json:"safe_regex,omitempty"`
+	Headers   []envoyHeaderMatcher `json:"headers,omitempty"`
+}
+
+type envoyRegex struct {
+	Regex string `json:"regex"`
+}
+
+type envoyHeaderMatcher struct {
+	Name        string           `json:"name"`
+	StringMatch envoyStringMatch `json:"string_match"`
+}
+
+type envoyStringMatch struct {
+	Exact     string      `json:"exact,omitempty"`
+	SafeRegex *envoyRegex `json:"safe_regex,omitempty"`
+}
+
+type envoyRouteAction struct {
+	Cluster string `json:"cluster"`
+	Timeout string `json:"timeout,omitempty"`
+}
+
+type envoyRedirectAction struct {
+	SchemeRedirect string             `json:"scheme_redirect,omitempty"`
+	HostRedirect   string             `json:"host_redirect,omitempty"`
+	PathRedirect   string             `json:"path_redirect,omitempty"`
+	RegexRewrite   *envoyRegexRewrite `json:"regex_rewrite,omitempty"`
+	ResponseCode   string             `json:"response_code,omitempty"`
+}
+
+type envoyRegexRewrite struct {
+	Pattern      envoyRegex `json:"pattern"`
+	Substitution string     `json:"substitution"`
+}
+
+var envoyRedirectCodes = map[int]string{
+	http.StatusMovedPermanently:  "MOVED_PERMANENTLY",
+	http.StatusFound:             "FOUND",
+	http.StatusSeeOther:          "SEE_OTHER",
+	http.StatusTemporaryRedirect: "TEMPORARY_REDIRECT",
+	http.StatusPermanentRedirect: "PERMANENT_REDIRECT",
+}
+
+func (r *proxyRoute) envoyRoutes(cluster string) []envoyRoute {
+	var match envoyRouteMatch
+	if r.isStatic() {
+		match.Path = r.staticPath()
+	} else {
+		match.SafeRegex = &envoyRegex{Regex: r.regexp(func(int) string { return "" })}
+	}
+
+	routes := make([]envoyRoute, 0, len(r.actions))
+	for _, action := range r.actions {
+		route := envoyRoute{Match: match}
+		if len(action.methods) == 1 {
+			route.Match.Headers = []envoyHeaderMatcher{{
+				Name:        ":method",
+				StringMatch: envoyStringMatch{Exact: action.methods[0]},
+			}}
+		} else {
+			route.Match.Headers = []envoyHeaderMatcher{{
+				Name: ":method",
+				StringMatch: envoyStringMatch{
+					SafeRegex: &envoyRegex{Regex: strings.Join(action.methods, "|")},
+				},
+			}}
+		}
+
+		if action.redirect == "" {
+			route.Route = &envoyRouteAction{Cluster: cluster}
+			if action.timeout > 0 {
+				route.Route.Timeout = strconv.FormatFloat(action.timeout.Seconds(), 'f', -1, 64) + "s"
+			}
+		} else {
+			route.Redirect = r.envoyRedirect(action)
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+func (r *proxyRoute) envoyRedirect(action proxyAction) *envoyRedirectAction {
+	redirect := &envoyRedirectAction{ResponseCode: envoyRedirectCodes[action.redirectCode]}
+
+	target := action.redirect
+	if i := strings.Index(target, "://"); i != -1 {
+		redirect.SchemeRedirect = target[:i]
+		target = target[i+3:]
+		host := target
+		if j := strings.IndexByte(target, '/'); j != -1 {
+			host, target = target[:j], target[j:]
+		} else {
+			target = "/"
+		}
+		redirect.HostRedirect = host
+	}
+
+	if !routeHasParams(target) {
+		redirect.PathRedirect = unescapeRoute(target)
+		return redirect
+	}
+	path := r.redirectTarget(target, func(i int) string { return `\` + strconv.Itoa(i+1) })
+	redirect.RegexRewrite = &envoyRegexRewrite{
+		Pattern:      envoyRegex{Regex: r.regexp(func(int) string { return "" })},
+		Substitution: path,
+	}
+	return redirect
+}
+
+func routeHasParams(route string) bool {
+	for _, seg := range strings.Split(route, "/") {
+		if seg != "" && (seg[0] == ':' || seg[0] == '*') {
+			return true
+		}
+	}
+	return false
+}
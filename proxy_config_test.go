@@ -0,0 +1,161 @@
+package treemux
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newProxyConfigRouter() *TreeMux {
+	router := New()
+	router.GET("/users/:id", simpleHandler).Meta(MetaMaxTimeout, 1500*time.Millisecond)
+	router.GET("/users/new", simpleHandler)
+	router.POST("/users", simpleHandler)
+	router.GET("/files/*path", simpleHandler)
+	router.LoadRedirects(map[string]string{
+		"/blog/:year/:slug": "/posts/:slug",
+		"/forum/*path":      "https://forum.example.com/*path",
+	}, http.StatusMovedPermanently)
+	router.LoadRedirects(map[string]string{"/about-us": "/about"}, http.StatusFound)
+	router.POST("/about-us", simpleHandler)
+	router.Host("api.example.com").GET("/items/", simpleHandler)
+	return router
+}
+
+func TestWriteNginxConfig(t *testing.T) {
+	router := newProxyConfigRouter()
+
+	var buf bytes.Buffer
+	if err := router.WriteNginxConfig(&buf, "", "backend"); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	locations := []string{
+		"location = /about-us {\n" +
+			"\tif ($request_method ~ ^(GET|HEAD)$) {\n\t\treturn 302 /about$is_args$args;\n\t}\n" +
+			"\tlimit_except GET HEAD POST {\n\t\tdeny all;\n\t}\n" +
+			"\tproxy_pass http://backend;\n}\n",
+		"location ~ ^/blog/(?<p1>[^/]+)/(?<p2>[^/]+)$ {\n\treturn 301 /posts/$p2$is_args$args;\n}\n",
+		"location ~ ^/files/(?<p1>.*)$ {\n",
+		"location ~ ^/forum/(?<p1>.*)$ {\n\treturn 301 https://forum.example.com/$p1$is_args$args;\n}\n",
+		"location = /users {\n\tlimit_except POST {\n\t\tdeny all;\n\t}\n\tproxy_pass http://backend;\n}\n",
+		"location = /users/new {\n",
+		"location ~ ^/users/(?<p1>[^/]+)$ {\n" +
+			"\tlimit_except GET HEAD {\n\t\tdeny all;\n\t}\n" +
+			"\tproxy_read_timeout 1500ms;\n\tproxy_pass http://backend;\n}\n",
+	}
+	last := -1
+	for _, location := range locations {
+		i := strings.Index(got, location)
+		if i == -1 {
+			t.Fatalf("missing location:\n%s\nin:\n%s", location, got)
+		}
+		if i < last {
+			t.Errorf("location is out of order:\n%s", location)
+		}
+		last = i
+	}
+	if strings.Contains(got, "/items") {
+		t.Errorf("default config contains host routes:\n%s", got)
+	}
+
+	buf.Reset()
+	if err := router.WriteNginxConfig(&buf, "API.example.com", "api"); err != nil {
+		t.Fatal(err)
+	}
+	want := "location = /items/ {\n\tlimit_except GET HEAD {\n\t\tdeny all;\n\t}\n\tproxy_pass http://api;\n}\n"
+	if buf.String() != want {
+		t.Errorf("got host config:\n%s\nwanted:\n%s", buf.String(), want)
+	}
+}
+
+func TestNginxQuote(t *testing.T) {
+	tests := []struct{ in, out string }{
+		{"/users", "/users"},
+		{`^/a\.b$`, `"^/a\\.b$"`},
+		{"/a{1}", `"/a{1}"`},
+	}
+	for _, test := range tests {
+		if got := nginxQuote(test.in); got != test.out {
+			t.Errorf("nginxQuote(%q) = %q, wanted %q", test.in, got, test.out)
+		}
+	}
+}
+
+func TestWriteEnvoyConfig(t *testing.T) {
+	router := newProxyConfigRouter()
+
+	var buf bytes.Buffer
+	if err := router.WriteEnvoyConfig(&buf, "backend"); err != nil {
+		t.Fatal(err)
+	}
+	var cfg envoyRouteConfig
+	if err := json.Unmarshal(buf.Bytes(), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cfg.VirtualHosts) != 2 {
+		t.Fatalf("got %d virtual hosts, wanted 2", len(cfg.VirtualHosts))
+	}
+	api := cfg.VirtualHosts[1]
+	if api.Name != "api.example.com" ||
+		!reflect.DeepEqual(api.Domains, []string{"api.example.com", "api.example.com:*"}) {
+		t.Errorf("got host %q with domains %v", api.Name, api.Domains)
+	}
+
+	routes := cfg.VirtualHosts[0].Routes
+	var paths []string
+	for _, route := range routes {
+		if route.Match.SafeRegex != nil {
+			paths = append(paths, route.Match.SafeRegex.Regex)
+		} else {
+			paths = append(paths, route.Match.Path)
+		}
+	}
+	wantPaths := []string{
+		"/about-us",
+		"/about-us",
+		"^/blog/([^/]+)/([^/]+)$",
+		"^/files/(.*)$",
+		"^/forum/(.*)$",
+		"/users",
+		"/users/new",
+		"^/users/([^/]+)$",
+	}
+	if !reflect.DeepEqual(paths, wantPaths) {
+		t.Fatalf("got paths %q, wanted %q", paths, wantPaths)
+	}
+
+	about := routes[0]
+	if about.Match.Headers[0].StringMatch.SafeRegex.Regex != "GET|HEAD" ||
+		!reflect.DeepEqual(about.Redirect, &envoyRedirectAction{PathRedirect: "/about", ResponseCode: "FOUND"}) {
+		t.Errorf("got /about-us redirect %+v", about)
+	}
+	if routes[1].Match.Headers[0].StringMatch.Exact != "POST" || routes[1].Route.Cluster != "backend" {
+		t.Errorf("got /about-us route %+v", routes[1])
+	}
+
+	wantForum := &envoyRedirectAction{
+		SchemeRedirect: "https",
+		HostRedirect:   "forum.example.com",
+		RegexRewrite: &envoyRegexRewrite{
+			Pattern:      envoyRegex{Regex: "^/forum/(.*)$"},
+			Substitution: `/\1`,
+		},
+		ResponseCode: "MOVED_PERMANENTLY",
+	}
+	if !reflect.DeepEqual(routes[4].Redirect, wantForum) {
+		t.Errorf("got forum redirect %+v", routes[4].Redirect)
+	}
+	if got := routes[2].Redirect.RegexRewrite.Substitution; got != `/posts/\2` {
+		t.Errorf("got blog substitution %q", got)
+	}
+	if got := routes[7].Route.Timeout; got != "1.5s" {
+		t.Errorf("got timeout %q, wanted 1.5s", got)
+	}
+}
@@ -0,0 +1,58 @@
+package treemux
+
+import (
+	"context"
+	"net/http"
+)
+
+// MetaPurgeKeys is the route metadata key that lists the surrogate keys invalidated by
+// the route. The value must be a []string of templates in the CacheKey.SurrogateKeys format.
+//
+//	router.PUT("/users/:id", updateUser).Meta(treemux.MetaPurgeKeys, []string{"user-{id}"})
+const MetaPurgeKeys = "purge_keys"
+
+// Purger invalidates cached responses tagged with the surrogate keys.
+type Purger interface {
+	Purge(ctx context.Context, keys []string) error
+}
+
+// PurgerFunc is an adapter to allow the use of ordinary functions as Purger.
+type PurgerFunc func(ctx context.Context, keys []string) error
+
+func (fn PurgerFunc) Purge(ctx context.Context, keys []string) error {
+	return fn(ctx, keys)
+}
+
+// PurgeConfig configures the Purge middleware.
+type PurgeConfig struct {
+	Purger Purger
+	// OnError is called when the purger fails. The response is not affected.
+	OnError func(req Request, keys []string, err error)
+}
+
+// Purge returns a middleware that calls the purger with the surrogate keys declared
+// using the MetaPurgeKeys route metadata after a mutating request (POST, PUT, PATCH,
+// or DELETE) succeeds. Requests that fail with an error or a status >= 400 are not purged.
+func Purge(cfg PurgeConfig) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			v, _ := req.RouteInfo().Value(MetaPurgeKeys)
+			templates, _ := v.([]string)
+			if len(templates) == 0 || !isMutatingMethod(req.Method) {
+				return next(w, req)
+			}
+
+			rw := NewResponseWriter(w)
+			err := next(rw, req)
+			if err != nil || responseStatus(rw, nil) >= 400 {
+				return err
+			}
+
+			keys := expandKeys(templates, req.Params)
+			if err := cfg.Purger.Purge(req.Context(), keys); err != nil && cfg.OnError != nil {
+				cfg.OnError(req, keys, err)
+			}
+			return nil
+		}
+	}
+}
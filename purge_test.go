@@ -0,0 +1,58 @@
+package treemux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestPurge(t *testing.T) {
+	var purged [][]string
+	var failed []string
+	router := New()
+	router.Use(Purge(PurgeConfig{
+		Purger: PurgerFunc(func(ctx context.Context, keys []string) error {
+			purged = append(purged, keys)
+			if keys[0] == "user-bad" {
+				return errors.New("purge failed")
+			}
+			return nil
+		}),
+		OnError: func(req Request, keys []string, err error) {
+			failed = append(failed, err.Error())
+		},
+	}))
+	keys := []string{"user-{id}", "users"}
+	router.PUT("/users/:id", func(w http.ResponseWriter, req Request) error {
+		if req.Param("id") == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		return nil
+	}).Meta(MetaPurgeKeys, keys)
+	router.GET("/users/:id", simpleHandler).Meta(MetaPurgeKeys, keys)
+
+	serve := func(method, path string) int {
+		r, _ := newRequest(method, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	serve("PUT", "/users/1")
+	serve("PUT", "/users/missing")
+	serve("GET", "/users/2")
+	if code := serve("PUT", "/users/bad"); code != http.StatusOK {
+		t.Errorf("purge error changed the response status to %d", code)
+	}
+
+	wanted := [][]string{{"user-1", "users"}, {"user-bad", "users"}}
+	if !reflect.DeepEqual(purged, wanted) {
+		t.Errorf("got purged %v, wanted %v", purged, wanted)
+	}
+	if len(failed) != 1 {
+		t.Errorf("got errors %v", failed)
+	}
+}
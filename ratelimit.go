@@ -0,0 +1,136 @@
+package treemux
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MetaRateLimit is the route metadata key that declares the rate limit of the route.
+// The value must be a RateLimit.
+const MetaRateLimit = "rate_limit"
+
+// RateLimit declares how many requests to the route are allowed per interval.
+//
+//	router.POST("/tenants/:tenant/jobs", createJob).Meta(treemux.MetaRateLimit, treemux.RateLimit{
+//		Key:      "tenant={tenant}:ip={req.ip}",
+//		Limit:    100,
+//		Interval: time.Minute,
+//	})
+type RateLimit struct {
+	// Key is the template of the key the requests are counted by, resolved with
+	// Request.ExpandTemplate. Empty key counts all requests to the route together.
+	Key string
+	// Limit is the number of requests allowed per key and interval.
+	Limit int
+	// Interval is the rate-limiting window. The default is one minute.
+	Interval time.Duration
+}
+
+// RateLimitConfig configures a RateLimiter.
+type RateLimitConfig struct {
+	// OnLimit is called when a request is rejected, e.g. to count the rejections.
+	OnLimit func(req Request, key string)
+	// Now returns the current time. The default is time.Now.
+	Now func() time.Time
+}
+
+// RateLimiter limits requests to the routes declared with the MetaRateLimit route
+// metadata, so the limits are declared alongside the routes. Requests over the limit
+// are rejected with 429 Too Many Requests and Retry-After. The requests are counted
+// in fixed windows per method, route, and expanded key.
+type RateLimiter struct {
+	cfg RateLimitConfig
+
+	mu      sync.Mutex
+	windows map[rateLimitKey]*rateLimitWindow
+	swept   time.Time
+}
+
+type rateLimitKey struct {
+	method, route, key string
+}
+
+type rateLimitWindow struct {
+	end   time.Time
+	count int
+}
+
+func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &RateLimiter{
+		cfg:     cfg,
+		windows: make(map[rateLimitKey]*rateLimitWindow),
+		swept:   cfg.Now(),
+	}
+}
+
+// Allow counts the request and reports whether it is within the rate limit of the route.
+// If it is not, Allow also returns the time left until the window resets.
+// Requests to routes without the MetaRateLimit metadata are always allowed.
+func (l *RateLimiter) Allow(req Request) (bool, time.Duration) {
+	v, _ := req.RouteInfo().Value(MetaRateLimit)
+	limit, ok := v.(RateLimit)
+	if !ok {
+		return true, 0
+	}
+	if limit.Interval == 0 {
+		limit.Interval = time.Minute
+	}
+
+	key := rateLimitKey{method: req.Method, route: req.Route(), key: req.ExpandTemplate(limit.Key)}
+	now := l.cfg.Now()
+
+	l.mu.Lock()
+	l.sweep(now)
+
+	win := l.windows[key]
+	if win == nil || !now.Before(win.end) {
+		win = &rateLimitWindow{end: now.Add(limit.Interval)}
+		l.windows[key] = win
+	}
+	allowed := win.count < limit.Limit
+	if allowed {
+		win.count++
+	}
+	retryAfter := win.end.Sub(now)
+	l.mu.Unlock()
+
+	if allowed {
+		return true, 0
+	}
+	if l.cfg.OnLimit != nil {
+		l.cfg.OnLimit(req, key.key)
+	}
+	return false, retryAfter
+}
+
+// Middleware rejects requests over the rate limit of the route.
+func (l *RateLimiter) Middleware(next HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, req Request) error {
+		if ok, retryAfter := l.Allow(req); !ok {
+			seconds := int64((retryAfter + time.Second - 1) / time.Second)
+			w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return nil
+		}
+		return next(w, req)
+	}
+}
+
+// sweep removes expired windows at most once a minute. It must be called with
+// the lock held.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < time.Minute {
+		return
+	}
+	l.swept = now
+	for key, win := range l.windows {
+		if !now.Before(win.end) {
+			delete(l.windows, key)
+		}
+	}
+}
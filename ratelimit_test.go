@@ -0,0 +1,95 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var limited []string
+	limiter := NewRateLimiter(RateLimitConfig{
+		OnLimit: func(req Request, key string) {
+			limited = append(limited, key)
+		},
+		Now: func() time.Time { return now },
+	})
+
+	router := New()
+	router.Use(limiter.Middleware)
+	router.POST("/tenants/:tenant/jobs", simpleHandler).Meta(MetaRateLimit, RateLimit{
+		Key:      "tenant={tenant}:ip={req.ip}",
+		Limit:    2,
+		Interval: time.Minute,
+	})
+	router.GET("/plain", simpleHandler)
+
+	serve := func(method, path, addr string) *httptest.ResponseRecorder {
+		r, _ := newRequest(method, path, nil)
+		r.RemoteAddr = addr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := serve("POST", "/tenants/a/jobs", "10.0.0.1:1234"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: got status %d", i, w.Code)
+		}
+	}
+
+	now = now.Add(15 * time.Second)
+	w := serve("POST", "/tenants/a/jobs", "10.0.0.1:5678")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("got status %d, wanted 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "45" {
+		t.Errorf("got Retry-After %q", got)
+	}
+	if w := serve("POST", "/tenants/b/jobs", "10.0.0.1:1234"); w.Code != http.StatusOK {
+		t.Errorf("other tenant: got status %d", w.Code)
+	}
+	if w := serve("POST", "/tenants/a/jobs", "10.0.0.2:1234"); w.Code != http.StatusOK {
+		t.Errorf("other ip: got status %d", w.Code)
+	}
+	for i := 0; i < 3; i++ {
+		if w := serve("GET", "/plain", "10.0.0.1:1234"); w.Code != http.StatusOK {
+			t.Errorf("unlimited route: got status %d", w.Code)
+		}
+	}
+
+	now = now.Add(45 * time.Second)
+	if w := serve("POST", "/tenants/a/jobs", "10.0.0.1:1234"); w.Code != http.StatusOK {
+		t.Errorf("next window: got status %d", w.Code)
+	}
+
+	if wanted := []string{"tenant=a:ip=10.0.0.1"}; !reflect.DeepEqual(limited, wanted) {
+		t.Errorf("got limited %q", limited)
+	}
+}
+
+func TestExpandTemplate(t *testing.T) {
+	router := New()
+	var got string
+	router.GET("/users/:id/:ip", func(w http.ResponseWriter, req Request) error {
+		got = req.ExpandTemplate("{req.method} {req.route} id={id} ip={ip} req.ip={req.ip} " +
+			"host={req.host} lang={req.header.Accept-Language} page={req.query.page} " +
+			"{req.other}{missing}x {open")
+		return nil
+	})
+
+	r, _ := newRequest("GET", "/users/1/spoofed?page=2", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Host = "example.com"
+	r.Header.Set("Accept-Language", "en")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	wanted := "GET /users/:id/:ip id=1 ip=spoofed req.ip=10.0.0.1 " +
+		"host=example.com lang=en page=2 x {open"
+	if got != wanted {
+		t.Errorf("got %q, wanted %q", got, wanted)
+	}
+}
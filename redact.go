@@ -0,0 +1,106 @@
+package treemux
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// MetaRedact is the route metadata key that lists sensitive JSON fields of the route
+// response. The value must be a []string of dot-separated paths, e.g. "user.ssn".
+// Arrays are traversed transparently, so "users.ssn" matches the field of every user.
+const MetaRedact = "redact"
+
+// Redact declares sensitive JSON fields of the route response. See MetaRedact.
+//
+//	router.GET("/users/:id", getUser).Redact("user.ssn", "user.card.number")
+func (r *Route) Redact(paths ...string) *Route {
+	v, _ := r.info.Value(MetaRedact)
+	fields, _ := v.([]string)
+	return r.Meta(MetaRedact, append(fields[:len(fields):len(fields)], paths...))
+}
+
+// RedactConfig configures the Redactor response transformer.
+type RedactConfig struct {
+	// Allowed reports whether the request principal can see the sensitive fields,
+	// e.g. by checking a scope. Nil means nobody can see them.
+	Allowed func(req Request) bool
+	// Mask replaces the values of the sensitive fields. Nil removes the fields.
+	Mask interface{}
+}
+
+// Redactor returns a response transformer that strips or masks the sensitive fields
+// declared with the MetaRedact route metadata. Only JSON responses are modified:
+// responses with a JSON Content-Type, or without a Content-Type and with a body
+// starting with an object or an array.
+//
+//	api.Use(treemux.Transform(treemux.Redactor(treemux.RedactConfig{
+//		Allowed: func(req treemux.Request) bool { return hasScope(req, "pii:read") },
+//		Mask:    "***",
+//	})))
+func Redactor(cfg RedactConfig) ResponseTransformer {
+	return ResponseTransformerFunc(func(req Request, resp *BufferedResponse) error {
+		v, _ := req.RouteInfo().Value(MetaRedact)
+		fields, _ := v.([]string)
+		if len(fields) == 0 || len(resp.Body) == 0 {
+			return nil
+		}
+		if !isJSONResponse(resp) {
+			return nil
+		}
+		if cfg.Allowed != nil && cfg.Allowed(req) {
+			return nil
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(resp.Body))
+		dec.UseNumber()
+		var doc interface{}
+		if err := dec.Decode(&doc); err != nil {
+			return err
+		}
+
+		for _, field := range fields {
+			redactPath(doc, strings.Split(field, "."), cfg.Mask)
+		}
+
+		b, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		resp.Body = b
+		return nil
+	})
+}
+
+// isJSONResponse reports whether the response is JSON. Handlers often write JSON
+// without setting the Content-Type, so such bodies are sniffed.
+func isJSONResponse(resp *BufferedResponse) bool {
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		return strings.Contains(ct, "json")
+	}
+	body := bytes.TrimLeft(resp.Body, " \t\r\n")
+	return len(body) > 0 && (body[0] == '{' || body[0] == '[')
+}
+
+func redactPath(v interface{}, path []string, mask interface{}) {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, el := range v {
+			redactPath(el, path, mask)
+		}
+	case map[string]interface{}:
+		child, ok := v[path[0]]
+		if !ok {
+			return
+		}
+		if len(path) > 1 {
+			redactPath(child, path[1:], mask)
+			return
+		}
+		if mask == nil {
+			delete(v, path[0])
+		} else {
+			v[path[0]] = mask
+		}
+	}
+}
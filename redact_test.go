@@ -0,0 +1,57 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	body := `{"user":{"name":"Bob","ssn":"123","cards":[{"number":"4242","exp":"12/30"}]},"n":12345678901234567890}`
+	handler := func(w http.ResponseWriter, req Request) error {
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(body))
+		return err
+	}
+
+	router := New()
+	removed := router.NewGroup("/removed")
+	removed.Use(Transform(Redactor(RedactConfig{
+		Allowed: func(req Request) bool { return req.Header.Get("X-Scope") == "pii" },
+	})))
+	removed.GET("/user", handler).Redact("user.ssn").Redact("user.cards.number", "missing.field")
+
+	masked := router.NewGroup("/masked")
+	masked.Use(Transform(Redactor(RedactConfig{Mask: "***"})))
+	masked.GET("/user", handler).Redact("user.ssn")
+	masked.GET("/untyped", func(w http.ResponseWriter, req Request) error {
+		_, err := w.Write([]byte(` [{"ssn":"123"}]`))
+		return err
+	}).Redact("ssn")
+	masked.GET("/text", func(w http.ResponseWriter, req Request) error {
+		w.Header().Set("Content-Type", "text/plain")
+		_, err := w.Write([]byte(`{"ssn":"123"}`))
+		return err
+	}).Redact("ssn")
+
+	tests := []struct {
+		path   string
+		scope  string
+		wanted string
+	}{
+		{"/removed/user", "", `{"n":12345678901234567890,"user":{"cards":[{"exp":"12/30"}],"name":"Bob"}}`},
+		{"/removed/user", "pii", body},
+		{"/masked/user", "", `{"n":12345678901234567890,"user":{"cards":[{"exp":"12/30","number":"4242"}],"name":"Bob","ssn":"***"}}`},
+		{"/masked/untyped", "", `[{"ssn":"***"}]`},
+		{"/masked/text", "", `{"ssn":"123"}`},
+	}
+	for _, test := range tests {
+		r, _ := newRequest("GET", test.path, nil)
+		r.Header.Set("X-Scope", test.scope)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Body.String() != test.wanted {
+			t.Errorf("%s: got\n%s\nwanted\n%s", test.path, w.Body.String(), test.wanted)
+		}
+	}
+}
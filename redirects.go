@@ -0,0 +1,86 @@
+package treemux
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// LoadRedirects registers GET routes that redirect the keys of the map to the values
+// using the status code, e.g. http.StatusMovedPermanently. It is meant for loading
+// thousands of legacy URLs after a site migration: routes are inserted in sorted order
+// and each one only keeps its target.
+//
+// Keys are route patterns and can contain params, which are substituted in the target:
+//
+//	router.LoadRedirects(map[string]string{
+//		"/blog/:year/:slug": "/posts/:slug",
+//		"/about-us":         "/about",
+//		"/forum/*path":      "https://forum.example.com/*path",
+//	}, http.StatusMovedPermanently)
+//
+// The query string of the request is preserved unless the target has its own.
+func (g *Group) LoadRedirects(redirects map[string]string, code int) {
+	sources := make([]string, 0, len(redirects))
+	for from := range redirects {
+		sources = append(sources, from)
+	}
+	sort.Strings(sources)
+
+	for _, from := range sources {
+		to := redirects[from]
+		for _, seg := range strings.Split(to, "/") {
+			if len(seg) > 1 && (seg[0] == ':' || seg[0] == '*') && !routeHasParam(from, seg[1:]) {
+				panic(fmt.Sprintf("redirect target %q uses param %q that is not in %q", to, seg[1:], from))
+			}
+		}
+		info := g.GET(from, redirectTo(to, code)).info
+		info.redirect = to
+		info.redirectCode = code
+	}
+}
+
+func redirectTo(target string, code int) HandlerFunc {
+	return func(w http.ResponseWriter, req Request) error {
+		location, err := buildPath(target, req.Params)
+		if err != nil {
+			return err
+		}
+		if req.URL.RawQuery != "" && !strings.Contains(location, "?") {
+			location += "?" + req.URL.RawQuery
+		}
+		http.Redirect(w, req.Request, location, code)
+		return nil
+	}
+}
+
+// LoadRedirectsCSV is like LoadRedirects, but reads the redirects from CSV records
+// of the form "from,to".
+func (g *Group) LoadRedirectsCSV(r io.Reader, code int) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 2
+	cr.TrimLeadingSpace = true
+	cr.ReuseRecord = true
+
+	redirects := make(map[string]string)
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		from, to := record[0], record[1]
+		if _, ok := redirects[from]; ok {
+			return fmt.Errorf("treemux: duplicate redirect from %q", from)
+		}
+		redirects[from] = to
+	}
+
+	g.LoadRedirects(redirects, code)
+	return nil
+}
@@ -0,0 +1,60 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoadRedirects(t *testing.T) {
+	router := New()
+	router.LoadRedirects(map[string]string{
+		"/about-us":         "/about",
+		"/blog/:year/:slug": "/posts/:slug",
+		"/forum/*path":      "https://forum.example.com/*path",
+	}, http.StatusMovedPermanently)
+	err := router.NewGroup("/old").LoadRedirectsCSV(strings.NewReader(
+		"/a, /new/a\n/b,/new/b?from=old\n"), http.StatusFound)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path     string
+		code     int
+		location string
+	}{
+		{"/about-us?x=1", 301, "/about?x=1"},
+		{"/blog/2014/hello", 301, "/posts/hello"},
+		{"/forum/t/1", 301, "https://forum.example.com/t/1"},
+		{"/old/a", 302, "/new/a"},
+		{"/old/b?x=1", 302, "/new/b?from=old"},
+	}
+	for _, test := range tests {
+		r, _ := newRequest("GET", test.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.code {
+			t.Errorf("%s: got code %d, wanted %d", test.path, w.Code, test.code)
+		}
+		if got := w.Header().Get("Location"); got != test.location {
+			t.Errorf("%s: got location %q, wanted %q", test.path, got, test.location)
+		}
+	}
+}
+
+func TestLoadRedirectsErrors(t *testing.T) {
+	err := New().LoadRedirectsCSV(strings.NewReader("/a,/b\n/a,/c\n"), http.StatusFound)
+	if err == nil {
+		t.Error("expected an error for a duplicate redirect")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for an unknown target param")
+		}
+	}()
+	New().LoadRedirects(map[string]string{"/a/:id": "/b/:name"}, http.StatusFound)
+}
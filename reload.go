@@ -0,0 +1,72 @@
+package treemux
+
+import "fmt"
+
+// Reload rebuilds the routing table by calling build with an empty group and atomically
+// replaces the current table with the new one. The group has the same middlewares as
+// the router. If build panics, for example because of conflicting routes, Reload returns
+// the error and keeps serving the old table.
+//
+// Reload can be called from a signal handler or a file watcher to apply a new routing
+// configuration without a restart:
+//
+//	go func() {
+//		ch := make(chan os.Signal, 1)
+//		signal.Notify(ch, syscall.SIGHUP)
+//		for range ch {
+//			if err := router.Reload(loadRoutes); err != nil {
+//				log.Printf("routes were not reloaded: %s", err)
+//			}
+//		}
+//	}()
+//
+// Reloading a router that is serving requests requires SafeAddRoutesWhileRunning.
+func (t *TreeMux) Reload(build func(g *Group)) (err error) {
+	root := &node{path: "/"}
+	g := t.Group.NewGroup("")
+	g.root = root
+	shards := make(map[string]*node)
+	g.shards = shards
+	// Routes of the host trees are not reloaded and keep their names.
+	g.routeNames = t.keepRouteNames(func(info *RouteInfo) bool {
+		return info.Host != ""
+	})
+
+	defer recoverReload(&err)
+
+	build(g)
+
+	t.mutex.Lock()
+	t.root = root
+	t.shards = shards
+	t.routeNames = g.routeNames
+	t.mutex.Unlock()
+
+	return nil
+}
+
+// keepRouteNames returns a copy of the route names of the routes for which keep
+// returns true.
+func (t *TreeMux) keepRouteNames(keep func(info *RouteInfo) bool) map[string]*RouteInfo {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	names := make(map[string]*RouteInfo, len(t.routeNames))
+	for name, info := range t.routeNames {
+		if keep(info) {
+			names[name] = info
+		}
+	}
+	return names
+}
+
+// recoverReload turns a panic of a reload build function into the error.
+func recoverReload(err *error) {
+	if v := recover(); v != nil {
+		if e, ok := v.(error); ok {
+			*err = fmt.Errorf("treemux: reload failed: %w", e)
+		} else {
+			*err = fmt.Errorf("treemux: reload failed: %v", v)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReload(t *testing.T) {
+	router := New()
+	router.SafeAddRoutesWhileRunning = true
+	router.GET("/old", simpleHandler)
+
+	code := func(path string) int {
+		r, _ := newRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	err := router.Reload(func(g *Group) {
+		g.GET("/new", simpleHandler)
+		g.GET("/new", simpleHandler)
+	})
+	if err == nil {
+		t.Fatal("expected an error for duplicate routes")
+	}
+	if code("/old") != http.StatusOK || code("/new") != http.StatusNotFound {
+		t.Fatal("failed reload replaced the routing table")
+	}
+
+	err = router.Reload(func(g *Group) {
+		g.GET("/new", simpleHandler)
+		g.NewGroup("/api").GET("/users", simpleHandler)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code("/old") != http.StatusNotFound {
+		t.Error("old route is still served")
+	}
+	if code("/new") != http.StatusOK || code("/api/users") != http.StatusOK {
+		t.Error("new routes are not served")
+	}
+
+	// Routes added after reload go to the new table.
+	router.GET("/later", simpleHandler)
+	if code("/later") != http.StatusOK {
+		t.Error("route added after reload is not served")
+	}
+}
+
+func TestReloadRouteNames(t *testing.T) {
+	router := New()
+	router.GET("/old", simpleHandler).Name("old")
+	router.Host("api.example.com").GET("/status", simpleHandler).Name("status")
+
+	build := func(g *Group) {
+		g.GET("/users/:id", simpleHandler).Name("user")
+	}
+	for i := 0; i < 2; i++ {
+		if err := router.Reload(build); err != nil {
+			t.Fatalf("reload %d: %s", i, err)
+		}
+	}
+
+	if url, err := router.URL("user", map[string]string{"id": "1"}); err != nil || url != "/users/1" {
+		t.Errorf("got %q, %v", url, err)
+	}
+	if _, err := router.URL("old", nil); err == nil {
+		t.Error("name of the removed route is still resolved")
+	}
+	if url, err := router.URL("status", nil); err != nil || url != "/status" {
+		t.Errorf("host route: got %q, %v", url, err)
+	}
+
+	// A failed reload keeps the old names.
+	err := router.Reload(func(g *Group) {
+		g.GET("/a", simpleHandler).Name("a")
+		g.GET("/b", simpleHandler).Name("a")
+	})
+	if err == nil {
+		t.Fatal("expected an error for a duplicate name")
+	}
+	if _, err := router.URL("a", nil); err == nil {
+		t.Error("name from the failed reload is resolved")
+	}
+	if _, err := router.URL("user", map[string]string{"id": "1"}); err != nil {
+		t.Error(err)
+	}
+}
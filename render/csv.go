@@ -0,0 +1,130 @@
+package render
+
+import (
+	"encoding/csv"
+	"mime"
+	"net/http"
+)
+
+// CSVConfig configures a CSVWriter.
+type CSVConfig struct {
+	// Filename, if set, makes browsers download the response as an attachment.
+	Filename string
+	// Header is the first row of the file.
+	Header []string
+	// BOM writes the UTF-8 byte order mark, which makes Excel detect the encoding.
+	BOM bool
+	// Comma is the field delimiter. The default is ','.
+	Comma rune
+	// FlushEvery is the number of rows after which the response is flushed. The default is 100.
+	FlushEvery int
+}
+
+// CSVWriter streams a CSV response. Nothing is written to the response until the first row,
+// so handlers can still return an error and let the router's ErrorHandler respond if
+// the export fails before producing any rows.
+//
+//	func export(w http.ResponseWriter, req treemux.Request) error {
+//		cw := render.NewCSV(w, render.CSVConfig{Filename: "users.csv", Header: []string{"id", "name"}})
+//		for rows.Next() {
+//			if err := cw.Write([]string{id, name}); err != nil {
+//				return err
+//			}
+//		}
+//		return cw.Close()
+//	}
+type CSVWriter struct {
+	w       http.ResponseWriter
+	cfg     CSVConfig
+	csv     *csv.Writer
+	flusher http.Flusher
+	rows    int
+}
+
+// NewCSV returns a CSVWriter that writes to the response.
+func NewCSV(w http.ResponseWriter, cfg CSVConfig) *CSVWriter {
+	if cfg.FlushEvery == 0 {
+		cfg.FlushEvery = 100
+	}
+	cw := &CSVWriter{
+		w:   w,
+		cfg: cfg,
+	}
+	cw.flusher, _ = w.(http.Flusher)
+	return cw
+}
+
+func (cw *CSVWriter) start() error {
+	h := cw.w.Header()
+	h.Set("Content-Type", "text/csv; charset=utf-8")
+	h.Del("Content-Length")
+	if cw.cfg.Filename != "" {
+		h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+			"filename": cw.cfg.Filename,
+		}))
+	}
+
+	if cw.cfg.BOM {
+		if _, err := cw.w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+			return err
+		}
+	}
+
+	cw.csv = csv.NewWriter(cw.w)
+	if cw.cfg.Comma != 0 {
+		cw.csv.Comma = cw.cfg.Comma
+	}
+	if cw.cfg.Header != nil {
+		return cw.csv.Write(cw.cfg.Header)
+	}
+	return nil
+}
+
+// Started reports whether the response was started, i.e. whether errors can no longer
+// be reported with a different status code.
+func (cw *CSVWriter) Started() bool {
+	return cw.csv != nil
+}
+
+// Write writes a row.
+func (cw *CSVWriter) Write(record []string) error {
+	if cw.csv == nil {
+		if err := cw.start(); err != nil {
+			return err
+		}
+	}
+	if err := cw.csv.Write(record); err != nil {
+		return err
+	}
+
+	cw.rows++
+	if cw.rows%cw.cfg.FlushEvery == 0 {
+		return cw.Flush()
+	}
+	return nil
+}
+
+// Flush sends the buffered rows to the client.
+func (cw *CSVWriter) Flush() error {
+	if cw.csv == nil {
+		return nil
+	}
+	cw.csv.Flush()
+	if err := cw.csv.Error(); err != nil {
+		return err
+	}
+	if cw.flusher != nil {
+		cw.flusher.Flush()
+	}
+	return nil
+}
+
+// Close writes the header if no rows were written and flushes the response.
+func (cw *CSVWriter) Close() error {
+	if cw.csv == nil {
+		if err := cw.start(); err != nil {
+			return err
+		}
+	}
+	return cw.Flush()
+}
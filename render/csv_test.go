@@ -0,0 +1,52 @@
+package render_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vmihailenco/treemux"
+	"github.com/vmihailenco/treemux/render"
+)
+
+func TestCSV(t *testing.T) {
+	router := treemux.New()
+	router.ErrorHandler = func(w http.ResponseWriter, req treemux.Request, err error) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+	router.GET("/export/:fail", func(w http.ResponseWriter, req treemux.Request) error {
+		cw := render.NewCSV(w, render.CSVConfig{
+			Filename:   "отчёт.csv",
+			Header:     []string{"id", "name"},
+			BOM:        true,
+			FlushEvery: 1,
+		})
+		if req.Param("fail") == "early" {
+			return errors.New("query failed")
+		}
+		if err := cw.Write([]string{"1", "Smith, John"}); err != nil {
+			return err
+		}
+		return cw.Close()
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/export/no", nil))
+	if w.Body.String() != "\xEF\xBB\xBFid,name\n1,\"Smith, John\"\n" {
+		t.Errorf("got %q", w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "text/csv; charset=utf-8" ||
+		w.Header().Get("Content-Disposition") != "attachment; filename*=utf-8''%D0%BE%D1%82%D1%87%D1%91%D1%82.csv" {
+		t.Errorf("got headers %v", w.Header())
+	}
+	if !w.Flushed {
+		t.Error("response was not flushed")
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/export/early", nil))
+	if w.Code != http.StatusInternalServerError || w.Body.String() != "query failed\n" {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+}
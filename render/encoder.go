@@ -0,0 +1,102 @@
+package render
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Content types of binary formats that can be plugged in with RegisterEncoder.
+const (
+	MsgpackContentType = "application/msgpack"
+	CBORContentType    = "application/cbor"
+)
+
+// Encoder encodes v into w.
+type Encoder func(w io.Writer, v interface{}) error
+
+var encoders = struct {
+	sync.RWMutex
+	m map[string]Encoder
+}{
+	m: map[string]Encoder{
+		"application/json": func(w io.Writer, v interface{}) error {
+			return json.NewEncoder(w).Encode(v)
+		},
+		"application/xml": func(w io.Writer, v interface{}) error {
+			if _, err := io.WriteString(w, xml.Header); err != nil {
+				return err
+			}
+			return xml.NewEncoder(w).Encode(v)
+		},
+	},
+}
+
+// RegisterEncoder registers the encoder for the content type, which makes the format
+// available to Encode and Negotiate. For example, to add MessagePack support without
+// making treemux depend on a msgpack library:
+//
+//	render.RegisterEncoder(render.MsgpackContentType, func(w io.Writer, v interface{}) error {
+//		return msgpack.NewEncoder(w).Encode(v)
+//	})
+//	treemux.RegisterBodyDecoder(render.MsgpackContentType, func(r io.Reader, v interface{}) error {
+//		return msgpack.NewDecoder(r).Decode(v)
+//	})
+func RegisterEncoder(contentType string, enc Encoder) {
+	contentType = strings.ToLower(contentType)
+
+	encoders.Lock()
+	encoders.m[contentType] = enc
+	encoders.Unlock()
+}
+
+// Encode writes v using the encoder registered for the content type.
+// It returns an error if there is no such encoder.
+func Encode(w http.ResponseWriter, statusCode int, contentType string, v interface{}) error {
+	encoders.RLock()
+	enc := encoders.m[strings.ToLower(contentType)]
+	encoders.RUnlock()
+
+	if enc == nil {
+		return &UnsupportedTypeError{ContentType: contentType}
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(statusCode)
+	return enc(w, v)
+}
+
+// Negotiate writes v in the first format listed in the request Accept header that has
+// a registered encoder. It falls back to JSON when the request does not list any of them.
+func Negotiate(w http.ResponseWriter, r *http.Request, statusCode int, v interface{}) error {
+	return Encode(w, statusCode, negotiate(r.Header.Get("Accept")), v)
+}
+
+func negotiate(accept string) string {
+	encoders.RLock()
+	defer encoders.RUnlock()
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if _, ok := encoders.m[mediaType]; ok {
+			return mediaType
+		}
+	}
+	return "application/json"
+}
+
+// UnsupportedTypeError is returned by Encode when there is no encoder for the content type.
+type UnsupportedTypeError struct {
+	ContentType string
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return "render: no encoder registered for " + e.ContentType
+}
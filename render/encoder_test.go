@@ -0,0 +1,47 @@
+package render_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vmihailenco/treemux/render"
+)
+
+func TestNegotiate(t *testing.T) {
+	// A fake binary encoder standing in for a msgpack library.
+	render.RegisterEncoder(render.MsgpackContentType, func(w io.Writer, v interface{}) error {
+		_, err := fmt.Fprintf(w, "msgpack:%v", v)
+		return err
+	})
+
+	tests := []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"application/msgpack", render.MsgpackContentType, "msgpack:{1 bob}"},
+		{"application/cbor, application/xml;q=0.9", "application/xml",
+			xmlHeader + `<user id="1"><name>bob</name></user>`},
+		{"", "application/json", `{"id":1,"name":"bob"}` + "\n"},
+		{"text/html, */*", "application/json", `{"id":1,"name":"bob"}` + "\n"},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", test.accept)
+		w := httptest.NewRecorder()
+		if err := render.Negotiate(w, r, http.StatusOK, user{1, "bob"}); err != nil {
+			t.Fatal(err)
+		}
+		if w.Header().Get("Content-Type") != test.contentType || w.Body.String() != test.body {
+			t.Errorf("%q: got %s %q", test.accept, w.Header().Get("Content-Type"), w.Body.String())
+		}
+	}
+
+	err := render.Encode(httptest.NewRecorder(), http.StatusOK, render.CBORContentType, nil)
+	if _, ok := err.(*render.UnsupportedTypeError); !ok {
+		t.Errorf("got %v", err)
+	}
+}
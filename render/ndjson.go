@@ -0,0 +1,126 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// Iterator returns the next value to stream. It returns ok=false when there are no more values.
+type Iterator func() (v interface{}, ok bool, err error)
+
+// FlushInterval is the maximum time streamed values are buffered before
+// they are flushed to the client.
+var FlushInterval = time.Second
+
+// NDJSON streams values as newline-delimited JSON. The source is either an Iterator or
+// a receive channel of any type, which is read until it is closed. Streaming stops with
+// the request context error when the client disconnects. The response is flushed
+// periodically and whenever the channel has no values ready.
+//
+//	router.GET("/export", func(w http.ResponseWriter, req treemux.Request) error {
+//		return render.NDJSON(w, req.Request, rows)
+//	})
+func NDJSON(w http.ResponseWriter, r *http.Request, source interface{}) error {
+	s := &ndjsonStream{
+		w:         w,
+		enc:       json.NewEncoder(w),
+		lastFlush: time.Now(),
+	}
+	s.flusher, _ = w.(http.Flusher)
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Del("Content-Length")
+
+	var err error
+	switch src := source.(type) {
+	case Iterator:
+		err = s.iterate(r, src)
+	case func() (interface{}, bool, error):
+		err = s.iterate(r, src)
+	default:
+		ch := reflect.ValueOf(source)
+		if ch.Kind() != reflect.Chan || ch.Type().ChanDir()&reflect.RecvDir == 0 {
+			return fmt.Errorf("render: NDJSON source must be an Iterator or a channel, got %T", source)
+		}
+		err = s.receive(r, ch)
+	}
+
+	s.flush()
+	return err
+}
+
+type ndjsonStream struct {
+	w         http.ResponseWriter
+	enc       *json.Encoder
+	flusher   http.Flusher
+	lastFlush time.Time
+}
+
+func (s *ndjsonStream) iterate(r *http.Request, next Iterator) error {
+	ctx := r.Context()
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		v, ok, err := next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if err := s.write(v); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *ndjsonStream) receive(r *http.Request, ch reflect.Value) error {
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(r.Context().Done())},
+		{Dir: reflect.SelectRecv, Chan: ch},
+	}
+	for {
+		v, ok := ch.TryRecv()
+		if !ok && v.IsValid() {
+			// The channel is closed.
+			return nil
+		}
+		if !ok {
+			// Nothing is ready, so send what we have before blocking.
+			s.flush()
+
+			var chosen int
+			chosen, v, ok = reflect.Select(cases)
+			if chosen == 0 {
+				return r.Context().Err()
+			}
+			if !ok {
+				return nil
+			}
+		}
+		if err := s.write(v.Interface()); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *ndjsonStream) write(v interface{}) error {
+	if err := s.enc.Encode(v); err != nil {
+		return err
+	}
+	if time.Since(s.lastFlush) >= FlushInterval {
+		s.flush()
+	}
+	return nil
+}
+
+func (s *ndjsonStream) flush() {
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+	s.lastFlush = time.Now()
+}
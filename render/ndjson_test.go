@@ -0,0 +1,68 @@
+package render_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vmihailenco/treemux/render"
+)
+
+func TestNDJSONChannel(t *testing.T) {
+	ch := make(chan user)
+	go func() {
+		ch <- user{1, "a"}
+		ch <- user{2, "b"}
+		close(ch)
+	}()
+
+	w := httptest.NewRecorder()
+	if err := render.NDJSON(w, httptest.NewRequest("GET", "/", nil), ch); err != nil {
+		t.Fatal(err)
+	}
+	wanted := `{"id":1,"name":"a"}` + "\n" + `{"id":2,"name":"b"}` + "\n"
+	if w.Body.String() != wanted || w.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Errorf("got %q", w.Body.String())
+	}
+	if !w.Flushed {
+		t.Error("response was not flushed")
+	}
+}
+
+func TestNDJSONIterator(t *testing.T) {
+	n := 0
+	iter := render.Iterator(func() (interface{}, bool, error) {
+		n++
+		return n, n <= 3, nil
+	})
+
+	w := httptest.NewRecorder()
+	if err := render.NDJSON(w, httptest.NewRequest("GET", "/", nil), iter); err != nil {
+		t.Fatal(err)
+	}
+	if w.Body.String() != "1\n2\n3\n" {
+		t.Errorf("got %q", w.Body.String())
+	}
+}
+
+func TestNDJSONCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan int, 1)
+	ch <- 1
+
+	r := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	done := make(chan error)
+	go func() {
+		done <- render.NDJSON(w, r, ch)
+	}()
+	cancel()
+
+	if err := <-done; err != context.Canceled {
+		t.Errorf("got %v, wanted context.Canceled", err)
+	}
+
+	if err := render.NDJSON(httptest.NewRecorder(), r, 42); err == nil {
+		t.Error("expected an error for an invalid source")
+	}
+}
@@ -0,0 +1,82 @@
+// Package render writes responses in common formats from treemux handlers.
+//
+//	router.GET("/users/:id", func(w http.ResponseWriter, req treemux.Request) error {
+//		return render.JSON(w, http.StatusOK, user)
+//	})
+package render
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strings"
+)
+
+// JSON writes v encoded as JSON with the status code.
+func JSON(w http.ResponseWriter, statusCode int, v interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	return json.NewEncoder(w).Encode(v)
+}
+
+// XML writes v encoded as XML, including the XML header, with the status code.
+func XML(w http.ResponseWriter, statusCode int, v interface{}) error {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(statusCode)
+	if _, err := w.Write([]byte(xml.Header)); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(v)
+}
+
+// ErrorResponse is the body written by Error.
+type ErrorResponse struct {
+	XMLName xml.Name `json:"-" xml:"error"`
+	Status  int      `json:"status" xml:"status"`
+	Message string   `json:"message" xml:"message"`
+}
+
+// Error writes the error in the format preferred by the request, XML if the Accept header
+// prefers XML and JSON otherwise. The status code is taken from errors that implement
+// StatusCode() int, e.g. *treemux.ParamError, and defaults to 500. The message of 5xx
+// errors is replaced with the status text so internal details don't leak to clients.
+//
+//	router.ErrorHandler = func(w http.ResponseWriter, req treemux.Request, err error) {
+//		render.Error(w, req.Request, err)
+//	}
+func Error(w http.ResponseWriter, r *http.Request, err error) error {
+	statusCode := http.StatusInternalServerError
+	if sc, ok := err.(interface{ StatusCode() int }); ok {
+		statusCode = sc.StatusCode()
+	}
+
+	resp := &ErrorResponse{
+		Status:  statusCode,
+		Message: err.Error(),
+	}
+	if statusCode >= 500 {
+		resp.Message = http.StatusText(statusCode)
+	}
+
+	if prefersXML(r) {
+		return XML(w, statusCode, resp)
+	}
+	return JSON(w, statusCode, resp)
+}
+
+// prefersXML reports whether the Accept header lists an XML media type before JSON.
+func prefersXML(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType := strings.TrimSpace(part)
+		if i := strings.IndexByte(mediaType, ';'); i >= 0 {
+			mediaType = strings.TrimSpace(mediaType[:i])
+		}
+		switch {
+		case strings.HasSuffix(mediaType, "/xml") || strings.HasSuffix(mediaType, "+xml"):
+			return true
+		case strings.HasSuffix(mediaType, "/json") || strings.HasSuffix(mediaType, "+json"):
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package render_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vmihailenco/treemux"
+	"github.com/vmihailenco/treemux/render"
+)
+
+type user struct {
+	ID   int    `json:"id" xml:"id,attr"`
+	Name string `json:"name" xml:"name"`
+}
+
+func TestJSONAndXML(t *testing.T) {
+	w := httptest.NewRecorder()
+	if err := render.JSON(w, http.StatusCreated, user{1, "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusCreated || w.Body.String() != `{"id":1,"name":"bob"}`+"\n" ||
+		w.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+		t.Errorf("got %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+
+	w = httptest.NewRecorder()
+	if err := render.XML(w, http.StatusOK, user{1, "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	wanted := xmlHeader + `<user id="1"><name>bob</name></user>`
+	if w.Body.String() != wanted || w.Header().Get("Content-Type") != "application/xml; charset=utf-8" {
+		t.Errorf("got %q", w.Body.String())
+	}
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+
+func TestError(t *testing.T) {
+	router := treemux.New()
+	router.ErrorHandler = func(w http.ResponseWriter, req treemux.Request, err error) {
+		render.Error(w, req.Request, err)
+	}
+	router.GET("/users/:id", func(w http.ResponseWriter, req treemux.Request) error {
+		var id int
+		if err := req.Params.Decode("id", &id); err != nil {
+			return err
+		}
+		return errors.New("db password is hunter2")
+	})
+
+	tests := []struct {
+		path   string
+		accept string
+		code   int
+		body   string
+	}{
+		{"/users/x", "", 400, `{"status":400,"message":"treemux: param \"id\"=\"x\" is not a valid int: strconv.ParseInt: parsing \"x\": invalid syntax"}`},
+		{"/users/1", "application/json", 500, `{"status":500,"message":"Internal Server Error"}`},
+		{"/users/1", "text/html, application/xml;q=0.9, */*", 500,
+			xmlHeader + `<error><status>500</status><message>Internal Server Error</message></error>`},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", test.path, nil)
+		r.Header.Set("Accept", test.accept)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != test.code || strings.TrimSpace(w.Body.String()) != test.body {
+			t.Errorf("%s %s: got %d %s", test.path, test.accept, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package treemux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MetaReplayProtected is the route metadata key that marks routes protected by
+// the ReplayProtection middleware. The value must be a bool.
+const MetaReplayProtected = "replay_protected"
+
+var (
+	// ErrReplayedRequest is returned when the request nonce has already been used.
+	ErrReplayedRequest = errors.New("treemux: request nonce has already been used")
+	// ErrStaleRequest is returned when the request timestamp is outside of the replay window.
+	ErrStaleRequest = errors.New("treemux: request timestamp is outside of the replay window")
+)
+
+// NonceStore remembers the nonces of accepted requests.
+type NonceStore interface {
+	// Add records the nonce until the expiration time and reports whether it was added,
+	// i.e. the nonce has not been seen before. Expired nonces may be forgotten, because
+	// the requests using them are rejected by the timestamp check.
+	Add(ctx context.Context, nonce string, expires time.Time) (bool, error)
+}
+
+// ReplayConfig configures the ReplayProtection middleware.
+type ReplayConfig struct {
+	// Store remembers the nonces. The default is a MemoryNonceStore.
+	Store NonceStore
+	// Window is the maximum difference between the request timestamp and the server
+	// time in either direction. The default is 5 minutes.
+	Window time.Duration
+	// NonceHeader is the header with the unique request nonce. The default is "X-Nonce".
+	NonceHeader string
+	// TimestampHeader is the header with the request time in unix seconds.
+	// The default is "X-Timestamp".
+	TimestampHeader string
+	// Now returns the current time. The default is time.Now.
+	Now func() time.Time
+}
+
+// ReplayProtection returns a middleware that rejects replayed requests to the routes
+// marked with MetaReplayProtected. Every request must carry a unique nonce and a timestamp:
+// requests without them are rejected with 400 Bad Request, and requests with stale
+// timestamps or reused nonces with 401 Unauthorized. The nonce and the timestamp must be
+// covered by the request signature, which is verified by another middleware, so they
+// can't be changed by an attacker.
+//
+//	api.Use(treemux.ReplayProtection(treemux.ReplayConfig{
+//		Store: treemux.NewMemoryNonceStore(),
+//	}))
+//	api.POST("/transfers", createTransfer).Meta(treemux.MetaReplayProtected, true)
+func ReplayProtection(cfg ReplayConfig) MiddlewareFunc {
+	if cfg.Window == 0 {
+		cfg.Window = 5 * time.Minute
+	}
+	if cfg.NonceHeader == "" {
+		cfg.NonceHeader = "X-Nonce"
+	}
+	if cfg.TimestampHeader == "" {
+		cfg.TimestampHeader = "X-Timestamp"
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if cfg.Store == nil {
+		store := NewMemoryNonceStore()
+		store.now = cfg.Now
+		cfg.Store = store
+	}
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			if protected, _ := req.RouteInfo().Bool(MetaReplayProtected); !protected {
+				return next(w, req)
+			}
+
+			nonce := req.Header.Get(cfg.NonceHeader)
+			sec, err := strconv.ParseInt(req.Header.Get(cfg.TimestampHeader), 10, 64)
+			if nonce == "" || err != nil {
+				http.Error(w, "treemux: request nonce and timestamp are required", http.StatusBadRequest)
+				return nil
+			}
+
+			timestamp := time.Unix(sec, 0)
+			if d := cfg.Now().Sub(timestamp); d > cfg.Window || d < -cfg.Window {
+				http.Error(w, ErrStaleRequest.Error(), http.StatusUnauthorized)
+				return nil
+			}
+
+			added, err := cfg.Store.Add(req.Context(), nonce, timestamp.Add(cfg.Window))
+			if err != nil {
+				return err
+			}
+			if !added {
+				http.Error(w, ErrReplayedRequest.Error(), http.StatusUnauthorized)
+				return nil
+			}
+
+			return next(w, req)
+		}
+	}
+}
+
+// MemoryNonceStore is a NonceStore that keeps nonces in memory. It is only suitable
+// for a single process; use a shared store, e.g. Redis SET NX, with several replicas.
+type MemoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+	now    func() time.Time
+	pruned time.Time
+}
+
+var _ NonceStore = (*MemoryNonceStore)(nil)
+
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{
+		nonces: make(map[string]time.Time),
+		now:    time.Now,
+	}
+}
+
+func (s *MemoryNonceStore) Add(ctx context.Context, nonce string, expires time.Time) (bool, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.pruned) > time.Minute {
+		for k, exp := range s.nonces {
+			if !exp.After(now) {
+				delete(s.nonces, k)
+			}
+		}
+		s.pruned = now
+	}
+
+	if exp, ok := s.nonces[nonce]; ok && exp.After(now) {
+		return false, nil
+	}
+	s.nonces[nonce] = expires
+	return true, nil
+}
+
+// Len returns the number of remembered nonces.
+func (s *MemoryNonceStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.nonces)
+}
@@ -0,0 +1,83 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestReplayProtection(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	store := NewMemoryNonceStore()
+	store.now = func() time.Time { return now }
+
+	router := New()
+	router.Use(ReplayProtection(ReplayConfig{
+		Store:  store,
+		Window: time.Minute,
+		Now:    func() time.Time { return now },
+	}))
+	router.POST("/transfers", simpleHandler).Meta(MetaReplayProtected, true)
+	router.POST("/search", simpleHandler)
+
+	post := func(path, nonce string, timestamp time.Time) int {
+		r, _ := newRequest("POST", path, nil)
+		if nonce != "" {
+			r.Header.Set("X-Nonce", nonce)
+			r.Header.Set("X-Timestamp", strconv.FormatInt(timestamp.Unix(), 10))
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	tests := []struct {
+		path, nonce string
+		timestamp   time.Time
+		wanted      int
+	}{
+		{"/search", "", now, http.StatusOK},
+		{"/transfers", "", now, http.StatusBadRequest},
+		{"/transfers", "a", now, http.StatusOK},
+		{"/transfers", "a", now, http.StatusUnauthorized},
+		{"/transfers", "b", now.Add(-2 * time.Minute), http.StatusUnauthorized},
+		{"/transfers", "b", now.Add(2 * time.Minute), http.StatusUnauthorized},
+		{"/transfers", "b", now.Add(-30 * time.Second), http.StatusOK},
+	}
+	for i, test := range tests {
+		if got := post(test.path, test.nonce, test.timestamp); got != test.wanted {
+			t.Errorf("test %d: got code %d, wanted %d", i, got, test.wanted)
+		}
+	}
+
+	// Expired nonces are forgotten.
+	now = now.Add(2 * time.Minute)
+	if got := post("/transfers", "c", now); got != http.StatusOK {
+		t.Errorf("got code %d", got)
+	}
+	if store.Len() != 1 {
+		t.Errorf("got %d nonces, wanted 1", store.Len())
+	}
+}
+
+func TestReplayProtectionDefaultStore(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	router := New()
+	router.Use(ReplayProtection(ReplayConfig{
+		Now: func() time.Time { return now },
+	}))
+	router.POST("/transfers", simpleHandler).Meta(MetaReplayProtected, true)
+
+	for _, wanted := range []int{http.StatusOK, http.StatusUnauthorized} {
+		r, _ := newRequest("POST", "/transfers", nil)
+		r.Header.Set("X-Nonce", "n1")
+		r.Header.Set("X-Timestamp", strconv.FormatInt(now.Unix(), 10))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != wanted {
+			t.Errorf("got %d, wanted %d", w.Code, wanted)
+		}
+	}
+}
@@ -7,9 +7,18 @@ import (
 )
 
 type Request struct {
+	mux *TreeMux
 	ctx context.Context
 	*http.Request
 	route string
+	info  *RouteInfo
+	// paramValues contains decoded values of the params typed in the route pattern.
+	paramValues map[string]interface{}
+	// handler is the matched handler served by the middlewares added with TreeMux.Use.
+	// Requests that did not match a route carry the lookup result instead, and so do
+	// automatic OPTIONS requests matching several routes.
+	handler HandlerFunc
+	lookup  *LookupResult
 
 	Params Params
 }
@@ -23,10 +32,33 @@ func (req Request) WithContext(ctx context.Context) Request {
 	return req
 }
 
+// httpRequest returns the underlying *http.Request with the request context.
+func (req Request) httpRequest() *http.Request {
+	if req.ctx == req.Request.Context() {
+		return req.Request
+	}
+	return req.Request.WithContext(req.ctx)
+}
+
 func (req Request) Route() string {
 	return req.route
 }
 
+// RouteName returns the name of the matched route set with Route.Name.
+// It is empty if the route is not named.
+func (req Request) RouteName() string {
+	if req.info == nil {
+		return ""
+	}
+	return req.info.Name
+}
+
+// RouteInfo returns the description of the matched route. It is nil for requests
+// that are not served by a registered route, e.g. redirects.
+func (req Request) RouteInfo() *RouteInfo {
+	return req.info
+}
+
 func (req Request) Param(key string) string {
 	return req.Params.Text(key)
 }
@@ -47,6 +79,35 @@ func (ps Params) Get(name string) (string, bool) {
 	return "", false
 }
 
+// All returns all values of the param in the order they appear in the path. A route
+// can repeat a param name, e.g. "/:id/compare/:id" or "/tags/:tag/*tag", in which case
+// Get returns the last value.
+func (ps Params) All(name string) []string {
+	var values []string
+	// Params are stored from the last path segment to the first.
+	for i := len(ps) - 1; i >= 0; i-- {
+		if ps[i].Name == name {
+			values = append(values, ps[i].Value)
+		}
+	}
+	return values
+}
+
+// At returns the i-th param in the order of the route template, e.g. for the route
+// "/users/:user/posts/:post" At(0) is the user param. It panics if i is out of range.
+func (ps Params) At(i int) Param {
+	return ps[len(ps)-1-i]
+}
+
+// Names returns the param names in the order of the route template.
+func (ps Params) Names() []string {
+	names := make([]string, len(ps))
+	for i := range ps {
+		names[i] = ps[len(ps)-1-i].Name
+	}
+	return names
+}
+
 func (ps Params) Text(name string) string {
 	s, _ := ps.Get(name)
 	return s
@@ -0,0 +1,88 @@
+package treemux
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// Response is returned by a ResponseHandlerFunc and written to the client by the router.
+type Response struct {
+	// StatusCode defaults to http.StatusOK.
+	StatusCode int
+	Header     http.Header
+	// Body is streamed to the client after the header and closed if it is an io.Closer.
+	// Content-Length is set for bodies with a Len method, e.g. *bytes.Reader and
+	// *strings.Reader. A nil Body sends an empty body.
+	Body io.Reader
+}
+
+// ResponseHandlerFunc is a handler that returns the response instead of writing it,
+// so it can be unit tested by calling it and inspecting the result.
+type ResponseHandlerFunc func(req Request) (*Response, error)
+
+// ResponseHook is called with the response returned by a ResponseHandlerFunc before
+// it is written. It can change the status and header or wrap the body, e.g. to
+// transform the response while it is streamed.
+type ResponseHook func(req Request, resp *Response) error
+
+// ResponseHandler adapts fn to a HandlerFunc that passes the returned response through
+// the hooks in order and writes it. Errors returned by fn or the hooks are returned
+// before anything is written, so the router's ErrorHandler can respond instead.
+// A nil response is written as 204 No Content.
+//
+//	router.GET("/users/:id", treemux.ResponseHandler(func(req treemux.Request) (*treemux.Response, error) {
+//		return &treemux.Response{Body: strings.NewReader(req.Param("id"))}, nil
+//	}))
+func ResponseHandler(fn ResponseHandlerFunc, hooks ...ResponseHook) HandlerFunc {
+	return func(w http.ResponseWriter, req Request) error {
+		resp, err := fn(req)
+		if err != nil {
+			if resp != nil {
+				resp.close()
+			}
+			return err
+		}
+		if resp == nil {
+			resp = &Response{StatusCode: http.StatusNoContent}
+		}
+
+		for _, hook := range hooks {
+			if err := hook(req, resp); err != nil {
+				resp.close()
+				return err
+			}
+		}
+		return resp.write(w)
+	}
+}
+
+func (resp *Response) write(w http.ResponseWriter) error {
+	defer resp.close()
+
+	h := w.Header()
+	for k, v := range resp.Header {
+		h[k] = v
+	}
+	if l, ok := resp.Body.(interface{ Len() int }); ok && h.Get("Content-Length") == "" {
+		h.Set("Content-Length", strconv.Itoa(l.Len()))
+	}
+
+	statusCode := resp.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	w.WriteHeader(statusCode)
+
+	if resp.Body == nil {
+		return nil
+	}
+	_, err := io.Copy(w, resp.Body)
+	return err
+}
+
+func (resp *Response) close() {
+	if c, ok := resp.Body.(io.Closer); ok {
+		c.Close()
+	}
+}
@@ -0,0 +1,129 @@
+package treemux
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+)
+
+// ErrResponseTooLarge is returned by the response writer when the response exceeds
+// the limit set by the ResponseLimit middleware.
+var ErrResponseTooLarge = errors.New("treemux: response is too large")
+
+// MetaMaxResponseSize is the route metadata key that overrides the ResponseLimit
+// size limit for the route. The value must be an int64.
+const MetaMaxResponseSize = "max_response_size"
+
+// ResponseTruncatedHeader is set on responses truncated by the ResponseLimit middleware.
+const ResponseTruncatedHeader = "X-Response-Truncated"
+
+// ResponseLimitConfig configures the ResponseLimit middleware.
+type ResponseLimitConfig struct {
+	// MaxSize is the maximum size of the response body in bytes.
+	MaxSize int64
+	// Truncate truncates responses that exceed the limit and flags them with
+	// the X-Response-Truncated header. By default such responses are replaced
+	// with 500 Internal Server Error.
+	Truncate bool
+	// OnExceeded is called when a response exceeds the limit.
+	OnExceeded func(req Request, limit int64)
+}
+
+// ResponseLimit returns a middleware that caps the size of response bodies to protect
+// against runaway serializations. Responses are buffered up to the limit, so the middleware
+// is not suitable for streaming routes. Writes past the limit fail with ErrResponseTooLarge
+// unless Truncate is set. The limit can be changed per route with the MetaMaxResponseSize
+// route metadata.
+func ResponseLimit(cfg ResponseLimitConfig) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			limit := cfg.MaxSize
+			if v, ok := req.RouteInfo().Value(MetaMaxResponseSize); ok {
+				if n, ok := v.(int64); ok {
+					limit = n
+				}
+			}
+			if limit <= 0 {
+				return next(w, req)
+			}
+
+			lw := &limitWriter{
+				ResponseWriter: NewResponseWriter(w),
+				limit:          limit,
+				truncate:       cfg.Truncate,
+			}
+			err := next(lw, req)
+
+			if lw.exceeded {
+				if cfg.OnExceeded != nil {
+					cfg.OnExceeded(req, limit)
+				}
+				if !cfg.Truncate {
+					if errors.Is(err, ErrResponseTooLarge) {
+						err = nil
+					}
+					w.Header().Del("Content-Length")
+					http.Error(w, ErrResponseTooLarge.Error(), http.StatusInternalServerError)
+					return err
+				}
+				w.Header().Del("Content-Length")
+				w.Header().Set(ResponseTruncatedHeader, "true")
+			}
+
+			if lw.statusCode != 0 {
+				lw.ResponseWriter.WriteHeader(lw.statusCode)
+			}
+			if lw.buf.Len() > 0 {
+				if _, werr := lw.ResponseWriter.Write(lw.buf.Bytes()); werr != nil && err == nil {
+					err = werr
+				}
+			}
+			return err
+		}
+	}
+}
+
+// limitWriter buffers the response until the handler returns so the response can be
+// replaced when it exceeds the limit.
+type limitWriter struct {
+	*ResponseWriter
+
+	limit      int64
+	truncate   bool
+	statusCode int
+	buf        bytes.Buffer
+	exceeded   bool
+}
+
+func (w *limitWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+}
+
+func (w *limitWriter) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	if w.exceeded {
+		if w.truncate {
+			return len(b), nil
+		}
+		return 0, ErrResponseTooLarge
+	}
+
+	room := w.limit - int64(w.buf.Len())
+	if int64(len(b)) <= room {
+		return w.buf.Write(b)
+	}
+
+	w.exceeded = true
+	if w.truncate {
+		w.buf.Write(b[:room])
+		return len(b), nil
+	}
+	return 0, ErrResponseTooLarge
+}
+
+// Flush is a no-op because the response is buffered.
+func (w *limitWriter) Flush() {}
@@ -0,0 +1,80 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseLimit(t *testing.T) {
+	var exceeded []int64
+	body := strings.Repeat("x", 100)
+	handler := func(w http.ResponseWriter, req Request) error {
+		w.WriteHeader(http.StatusCreated)
+		for i := 0; i < len(body); i += 10 {
+			if _, err := w.Write([]byte(body[i : i+10])); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	onExceeded := func(req Request, limit int64) {
+		exceeded = append(exceeded, limit)
+	}
+
+	router := New()
+	abort := router.NewGroup("/abort")
+	abort.Use(ResponseLimit(ResponseLimitConfig{MaxSize: 50, OnExceeded: onExceeded}))
+	abort.GET("/small", handler)
+	abort.GET("/big", handler).Meta(MetaMaxResponseSize, int64(100))
+
+	truncate := router.NewGroup("/truncate")
+	truncate.Use(ResponseLimit(ResponseLimitConfig{MaxSize: 25, Truncate: true, OnExceeded: onExceeded}))
+	truncate.GET("/small", handler)
+
+	tests := []struct {
+		path      string
+		code      int
+		body      string
+		truncated string
+	}{
+		{"/abort/small", http.StatusInternalServerError, ErrResponseTooLarge.Error() + "\n", ""},
+		{"/abort/big", http.StatusCreated, body, ""},
+		{"/truncate/small", http.StatusCreated, body[:25], "true"},
+	}
+	for _, test := range tests {
+		r, _ := newRequest("GET", test.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != test.code || w.Body.String() != test.body ||
+			w.Header().Get(ResponseTruncatedHeader) != test.truncated {
+			t.Errorf("%s: got %d %q %v", test.path, w.Code, w.Body.String(), w.Header())
+		}
+	}
+
+	if len(exceeded) != 2 || exceeded[0] != 50 || exceeded[1] != 25 {
+		t.Errorf("got OnExceeded calls %v", exceeded)
+	}
+}
+
+func TestResponseLimitWrapErrors(t *testing.T) {
+	var handled error
+	router := New()
+	router.WrapErrors = true
+	router.ErrorHandler = func(w http.ResponseWriter, req Request, err error) {
+		handled = err
+	}
+	router.Use(ResponseLimit(ResponseLimitConfig{MaxSize: 5}))
+	router.GET("/big", func(w http.ResponseWriter, req Request) error {
+		_, err := w.Write([]byte("too large"))
+		return err
+	})
+
+	r, _ := newRequest("GET", "/big", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusInternalServerError || handled != nil {
+		t.Errorf("got %d, handled error %v", w.Code, handled)
+	}
+}
@@ -0,0 +1,115 @@
+package treemux
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (r *closeRecorder) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestResponseHandler(t *testing.T) {
+	getUser := func(req Request) (*Response, error) {
+		switch id := req.Param("id"); id {
+		case "fail":
+			return nil, errors.New("not found")
+		case "empty":
+			return nil, nil
+		default:
+			return &Response{
+				StatusCode: http.StatusCreated,
+				Header:     http.Header{"X-Id": {id}},
+				Body:       strings.NewReader("user " + id),
+			}, nil
+		}
+	}
+
+	// Handlers can be tested without a ResponseWriter.
+	r, _ := newRequest("GET", "/users/1", nil)
+	resp, err := getUser(Request{Request: r, Params: Params{{Name: "id", Value: "1"}}})
+	if err != nil || resp.StatusCode != http.StatusCreated {
+		t.Fatalf("got %v, %v", resp, err)
+	}
+
+	upper := func(req Request, resp *Response) error {
+		if req.Param("id") == "hookfail" {
+			return errors.New("hook failed")
+		}
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		resp.Body = strings.NewReader(strings.ToUpper(string(b)))
+		resp.Header.Set("X-Hook", "1")
+		return nil
+	}
+
+	var handled error
+	router := New()
+	router.ErrorHandler = func(w http.ResponseWriter, req Request, err error) {
+		handled = err
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	router.GET("/users/:id", ResponseHandler(getUser, upper))
+	router.GET("/raw/:id", ResponseHandler(getUser))
+
+	tests := []struct {
+		path, body string
+		code       int
+		err        string
+	}{
+		{"/users/1", "USER 1", http.StatusCreated, ""},
+		{"/raw/2", "user 2", http.StatusCreated, ""},
+		{"/raw/empty", "", http.StatusNoContent, ""},
+		{"/raw/fail", "", http.StatusInternalServerError, "not found"},
+	}
+	for _, test := range tests {
+		handled = nil
+		r, _ := newRequest("GET", test.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.code || w.Body.String() != test.body {
+			t.Errorf("%s: got %d %q, wanted %d %q", test.path, w.Code, w.Body.String(), test.code, test.body)
+		}
+		if (handled == nil && test.err != "") || (handled != nil && handled.Error() != test.err) {
+			t.Errorf("%s: got error %v, wanted %q", test.path, handled, test.err)
+		}
+	}
+
+	r, _ = newRequest("GET", "/raw/2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Header().Get("Content-Length") != "6" || w.Header().Get("X-Id") != "2" {
+		t.Errorf("got header %v", w.Header())
+	}
+}
+
+func TestResponseHandlerClosesBody(t *testing.T) {
+	body := &closeRecorder{Reader: strings.NewReader("data")}
+	handler := ResponseHandler(func(req Request) (*Response, error) {
+		return &Response{Body: body}, nil
+	}, func(req Request, resp *Response) error {
+		return errors.New("hook failed")
+	})
+
+	r, _ := newRequest("GET", "/", nil)
+	if err := handler(httptest.NewRecorder(), Request{Request: r}); err == nil {
+		t.Error("expected the hook error")
+	}
+	if !body.closed {
+		t.Error("body was not closed")
+	}
+}
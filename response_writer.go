@@ -0,0 +1,115 @@
+package treemux
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter wraps http.ResponseWriter and records the status code and
+// the number of bytes written to the response body.
+type ResponseWriter struct {
+	http.ResponseWriter
+
+	statusCode int
+	written    int64
+}
+
+// NewResponseWriter wraps w with a ResponseWriter. If w is already a *ResponseWriter,
+// it is returned as is so nested middlewares share the same counters.
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	if rw, ok := w.(*ResponseWriter); ok {
+		return rw
+	}
+	return &ResponseWriter{ResponseWriter: w}
+}
+
+// StatusCode returns the status code written so far or 0 if nothing was written.
+func (w *ResponseWriter) StatusCode() int {
+	return w.statusCode
+}
+
+// Written returns the number of body bytes written so far.
+func (w *ResponseWriter) Written() int64 {
+	return w.written
+}
+
+// WroteHeader reports whether the response header was already written.
+func (w *ResponseWriter) WroteHeader() bool {
+	return w.statusCode != 0
+}
+
+func (w *ResponseWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher if the underlying writer supports it.
+func (w *ResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.statusCode == 0 {
+			w.statusCode = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker if the underlying writer supports it.
+func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("treemux: http.Hijacker is not supported")
+}
+
+// Unwrap returns the underlying http.ResponseWriter for http.ResponseController.
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// responseStatus returns the status code of the response served by a handler.
+// Handlers that return an error without writing a response are considered to have failed
+// with the ErrorStatusCode of the error, because the final response is written later by
+// the router's ErrorHandler.
+func responseStatus(w *ResponseWriter, err error) int {
+	if w.statusCode != 0 {
+		return w.statusCode
+	}
+	if err != nil && !errors.As(err, new(servedError)) {
+		return ErrorStatusCode(err)
+	}
+	return http.StatusOK
+}
+
+// servedError is an error that was already served with the ErrorHandler.
+type servedError struct {
+	error
+}
+
+func (e servedError) Unwrap() error {
+	return e.error
+}
+
+// serveError serves the error returned by a handler without writing a response with
+// the ErrorHandler of the route, so middlewares that record the response status see
+// the status the client receives. The returned error is marked as served, so the router
+// doesn't serve it again.
+func serveError(w *ResponseWriter, req Request, err error) error {
+	if err == nil || w.WroteHeader() || req.mux == nil || errors.As(err, new(servedError)) {
+		return err
+	}
+	req.mux.handleError(w, req, err)
+	return servedError{err}
+}
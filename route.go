@@ -0,0 +1,129 @@
+package treemux
+
+import "net/http"
+
+// RouteInfo describes a route registered for a single HTTP method.
+// It is available to handlers and middlewares via Request.RouteInfo.
+type RouteInfo struct {
+	// Name is the route name set with Route.Name.
+	Name string
+	// Host is the host passed to TreeMux.Host or empty for the default tree.
+	Host   string
+	Method string
+	// Route is the route template, e.g. "/users/:id".
+	Route string
+	// Meta contains metadata attached to the route with Route.Meta.
+	Meta map[string]interface{}
+	// ParamTypes contains the param types declared with Route.Param or in the route
+	// pattern, e.g. "/posts/:id|int".
+	ParamTypes map[string]*ParamType
+	// Sanitize contains the param sanitization rules declared with Route.Sanitize.
+	Sanitize []SanitizeRule
+	// Middlewares contains names of the middlewares added with Group.UseNamed,
+	// from the outermost to the innermost.
+	Middlewares []string
+	// Handler is the name of the handler function, e.g. "api.(*Server).getUser".
+	Handler string
+	// File and Line locate the handler function declaration.
+	File string
+	Line int
+	// Caller is the file:line of the code that registered the route.
+	// It is only recorded when TreeMux.RecordCallers is enabled.
+	Caller string
+
+	middlewareCount int
+	// trailingSlash reports whether the route was registered with a trailing slash.
+	trailingSlash bool
+	// patternTypes contains names of the params typed in the route pattern.
+	patternTypes []string
+	// redirect and redirectCode describe the routes registered with LoadRedirects.
+	redirect     string
+	redirectCode int
+	// handlers are the handlers of the group of the route. The handlers set on the
+	// route override them.
+	handlers        *groupHandlers
+	errorHandler    func(w http.ResponseWriter, req Request, err error)
+	panicHandler    func(w http.ResponseWriter, req Request, recovered interface{})
+	notFoundHandler func(w http.ResponseWriter, r *http.Request)
+}
+
+// Value returns the metadata value for the key. It is safe to call on a nil RouteInfo.
+func (r *RouteInfo) Value(key string) (interface{}, bool) {
+	if r == nil {
+		return nil, false
+	}
+	v, ok := r.Meta[key]
+	return v, ok
+}
+
+// Bool returns the metadata value for the key if it is a bool.
+func (r *RouteInfo) Bool(key string) (value bool, ok bool) {
+	v, _ := r.Value(key)
+	value, ok = v.(bool)
+	return value, ok
+}
+
+// Route is returned by Group.Handle and allows to configure the registered route.
+// Routes should be configured before the router starts serving requests.
+//
+//	router.POST("/login", loginHandler).Meta(treemux.MetaAudit, false)
+type Route struct {
+	mux  *TreeMux
+	info *RouteInfo
+	// names contains the route names of the table the route is added to.
+	// Nil means the mux names.
+	names map[string]*RouteInfo
+}
+
+// Info returns the route description.
+func (r *Route) Info() *RouteInfo {
+	return r.info
+}
+
+// Meta attaches a metadata value to the route.
+func (r *Route) Meta(key string, value interface{}) *Route {
+	if r.info.Meta == nil {
+		r.info.Meta = make(map[string]interface{})
+	}
+	r.info.Meta[key] = value
+	return r
+}
+
+// routeHandler wraps the route handler with the checks and error handling configured
+// for the route. It runs after all middlewares.
+func routeHandler(t *TreeMux, info *RouteInfo, next HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, req Request) error {
+		span := startPhase()
+		if len(info.Sanitize) > 0 {
+			params, err := sanitizeParams(info.Sanitize, req.Params)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return nil
+			}
+			req.Params = params
+		}
+		if len(info.patternTypes) > 0 && req.paramValues == nil {
+			values, ok := decodePatternParams(info, req.Params)
+			if !ok {
+				t.EffectiveHandlers(info).NotFoundHandler(w, req.Request)
+				return nil
+			}
+			req.paramValues = values
+		}
+		if len(info.ParamTypes) > 0 {
+			if err := checkParams(info, req.Params); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return nil
+			}
+		}
+		span.end(phaseParams)
+
+		span = startPhase()
+		err := next(w, req)
+		span.end(phaseHandler)
+		if err != nil && t.WrapErrors {
+			err = newRouteError(req, err)
+		}
+		return err
+	}
+}
@@ -0,0 +1,146 @@
+package treemux
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// RouteBuilder registers several methods with shared middlewares and metadata on one path.
+// It is returned by Group.Route. Unlike Group.Use, the order of the calls does not matter:
+// middlewares and metadata apply to the methods registered before and after them.
+// Builders must be configured before the router starts serving requests.
+type RouteBuilder struct {
+	group  *Group
+	path   string
+	name   string
+	meta   map[string]interface{}
+	stack  []MiddlewareFunc
+	routes []*builtRoute
+}
+
+type builtRoute struct {
+	route *Route
+	// next is the route handler, which is wrapped by the builder middlewares in handler.
+	next    HandlerFunc
+	handler HandlerFunc
+}
+
+// Route returns a builder for the routes of the path:
+//
+//	router.Route("/users/:id").
+//		GET(getUser).
+//		PUT(updateUser).
+//		Name("users.show").
+//		Use(auth).
+//		Meta(treemux.MetaOwner, "identity")
+func (g *Group) Route(path string) *RouteBuilder {
+	return &RouteBuilder{group: g, path: path}
+}
+
+// Handle registers the handler for the method.
+func (b *RouteBuilder) Handle(method string, handler HandlerFunc) *RouteBuilder {
+	br := new(builtRoute)
+	middleware := func(next HandlerFunc) HandlerFunc {
+		br.next = next
+		br.handler = handlerWithMiddlewares(next, b.stack)
+		return func(w http.ResponseWriter, req Request) error {
+			return br.handler(w, req)
+		}
+	}
+	br.route = b.group.Handle(method, b.path, handler, middleware)
+	br.route.info.middlewareCount += len(b.stack) - 1
+	for key, value := range b.meta {
+		br.route.Meta(key, value)
+	}
+	b.routes = append(b.routes, br)
+	if b.name != "" {
+		b.setName(br.route)
+	}
+	return b
+}
+
+func (b *RouteBuilder) GET(handler HandlerFunc) *RouteBuilder {
+	return b.Handle(http.MethodGet, handler)
+}
+
+func (b *RouteBuilder) POST(handler HandlerFunc) *RouteBuilder {
+	return b.Handle(http.MethodPost, handler)
+}
+
+func (b *RouteBuilder) PUT(handler HandlerFunc) *RouteBuilder {
+	return b.Handle(http.MethodPut, handler)
+}
+
+func (b *RouteBuilder) DELETE(handler HandlerFunc) *RouteBuilder {
+	return b.Handle(http.MethodDelete, handler)
+}
+
+func (b *RouteBuilder) PATCH(handler HandlerFunc) *RouteBuilder {
+	return b.Handle(http.MethodPatch, handler)
+}
+
+func (b *RouteBuilder) HEAD(handler HandlerFunc) *RouteBuilder {
+	return b.Handle(http.MethodHead, handler)
+}
+
+func (b *RouteBuilder) OPTIONS(handler HandlerFunc) *RouteBuilder {
+	return b.Handle(http.MethodOptions, handler)
+}
+
+// Use adds a middleware to every method of the builder. It runs after the middlewares
+// of the group.
+func (b *RouteBuilder) Use(fn MiddlewareFunc) *RouteBuilder {
+	b.stack = append(b.stack, fn)
+	for _, br := range b.routes {
+		br.handler = handlerWithMiddlewares(br.next, b.stack)
+		br.route.info.middlewareCount++
+	}
+	return b
+}
+
+// Meta attaches a metadata value to every method of the builder.
+func (b *RouteBuilder) Meta(key string, value interface{}) *RouteBuilder {
+	if b.meta == nil {
+		b.meta = make(map[string]interface{})
+	}
+	b.meta[key] = value
+	for _, br := range b.routes {
+		br.route.Meta(key, value)
+	}
+	return b
+}
+
+// Name names the path so URLs can be generated for it with TreeMux.URL. Every method
+// of the builder reports the name in RouteInfo.Name.
+func (b *RouteBuilder) Name(name string) *RouteBuilder {
+	if b.name != "" {
+		panic(fmt.Sprintf("route %q is already named %q", b.path, b.name))
+	}
+	b.name = name
+	for _, br := range b.routes {
+		b.setName(br.route)
+	}
+	return b
+}
+
+func (b *RouteBuilder) setName(r *Route) {
+	if r.info.Name != "" {
+		return
+	}
+	if len(b.routes) > 0 && r != b.routes[0].route {
+		// The name resolves to the first route, which has the same path.
+		r.info.Name = b.name
+		return
+	}
+	r.Name(b.name)
+}
+
+// Routes returns the routes registered by the builder in order, e.g. to declare
+// param types with Route.Param.
+func (b *RouteBuilder) Routes() []*Route {
+	routes := make([]*Route, len(b.routes))
+	for i, br := range b.routes {
+		routes[i] = br.route
+	}
+	return routes
+}
@@ -0,0 +1,77 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouteBuilder(t *testing.T) {
+	var trace []string
+	mw := func(name string) MiddlewareFunc {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(w http.ResponseWriter, req Request) error {
+				trace = append(trace, name)
+				return next(w, req)
+			}
+		}
+	}
+	handler := func(name string) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			trace = append(trace, name+" "+req.RouteName())
+			return nil
+		}
+	}
+
+	router := New()
+	router.Use(mw("global"))
+	b := router.Route("/users/:id").
+		GET(handler("get")).
+		Name("users.show").
+		Use(mw("auth")).
+		Meta(MetaOwner, "identity").
+		PUT(handler("put")).
+		Use(mw("audit"))
+
+	tests := []struct {
+		method string
+		trace  []string
+	}{
+		{"GET", []string{"global", "auth", "audit", "get users.show"}},
+		{"PUT", []string{"global", "auth", "audit", "put users.show"}},
+	}
+	for _, test := range tests {
+		trace = nil
+		r, _ := newRequest(test.method, "/users/1", nil)
+		router.ServeHTTP(httptest.NewRecorder(), r)
+		if len(trace) != len(test.trace) {
+			t.Errorf("%s: got trace %v, wanted %v", test.method, trace, test.trace)
+			continue
+		}
+		for i := range trace {
+			if trace[i] != test.trace[i] {
+				t.Errorf("%s: got trace %v, wanted %v", test.method, trace, test.trace)
+				break
+			}
+		}
+	}
+
+	routes := b.Routes()
+	if len(routes) != 2 {
+		t.Fatalf("got %d routes, wanted 2", len(routes))
+	}
+	for _, route := range routes {
+		info := route.Info()
+		if owner, _ := info.Value(MetaOwner); owner != "identity" {
+			t.Errorf("%s: got owner %v", info.Method, owner)
+		}
+		if info.middlewareCount != 2 {
+			t.Errorf("%s: got %d middlewares, wanted 2", info.Method, info.middlewareCount)
+		}
+	}
+
+	url, err := router.URL("users.show", map[string]string{"id": "42"})
+	if err != nil || url != "/users/42" {
+		t.Errorf("got URL %q, %v", url, err)
+	}
+}
@@ -0,0 +1,50 @@
+package treemux
+
+import "errors"
+
+// RequestIDHeader is the request header that carries the request ID.
+const RequestIDHeader = "X-Request-Id"
+
+// RouteError wraps an error returned by a handler with the route context.
+// See TreeMux.WrapErrors.
+type RouteError struct {
+	Method string
+	Route  string
+	// RequestID is the value of the X-Request-Id request header.
+	RequestID string
+	Err       error
+}
+
+func newRouteError(req Request, err error) *RouteError {
+	if routeErr, ok := err.(*RouteError); ok {
+		return routeErr
+	}
+	return &RouteError{
+		Method:    req.Method,
+		Route:     req.Route(),
+		RequestID: req.Header.Get(RequestIDHeader),
+		Err:       err,
+	}
+}
+
+func (e *RouteError) Error() string {
+	s := e.Method + " " + e.Route
+	if e.RequestID != "" {
+		s += " (request " + e.RequestID + ")"
+	}
+	return s + ": " + e.Err.Error()
+}
+
+func (e *RouteError) Unwrap() error {
+	return e.Err
+}
+
+// RouteFromError returns the route context of the error wrapped by the router,
+// looking through the error chain.
+func RouteFromError(err error) (*RouteError, bool) {
+	var routeErr *RouteError
+	if errors.As(err, &routeErr) {
+		return routeErr, true
+	}
+	return nil, false
+}
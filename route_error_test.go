@@ -0,0 +1,51 @@
+package treemux
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWrapErrors(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	var middlewareErr, handlerErr error
+	router := New()
+	router.WrapErrors = true
+	router.ErrorHandler = func(w http.ResponseWriter, req Request, err error) {
+		handlerErr = err
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	router.Use(func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			middlewareErr = next(w, req)
+			return fmt.Errorf("middleware: %w", middlewareErr)
+		}
+	})
+	router.GET("/users/:id", func(w http.ResponseWriter, req Request) error {
+		return errFailed
+	})
+
+	r, _ := newRequest("GET", "/users/1", nil)
+	r.Header.Set(RequestIDHeader, "abc")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	wanted := "GET /users/:id (request abc): failed"
+	if middlewareErr == nil || middlewareErr.Error() != wanted {
+		t.Errorf("middleware got %v, wanted %q", middlewareErr, wanted)
+	}
+
+	routeErr, ok := RouteFromError(handlerErr)
+	if !ok || routeErr.Route != "/users/:id" || routeErr.RequestID != "abc" {
+		t.Fatalf("got %#v", routeErr)
+	}
+	if !errors.Is(handlerErr, errFailed) {
+		t.Error("wrapped error does not unwrap to the handler error")
+	}
+
+	if _, ok := RouteFromError(errFailed); ok {
+		t.Error("unwrapped error has a route")
+	}
+}
@@ -0,0 +1,65 @@
+package treemux
+
+import "strings"
+
+// unescapeRoute resolves backslash escapes in the static segments of the route.
+// A backslash followed by ':', '*', or '\' can appear anywhere in a segment and
+// produces the literal character, e.g. `/v1\:batch` matches "/v1:batch". Since
+// ':' and '*' are only special at the start of a segment, the result only keeps
+// the escapes that are needed there, so every route has a single canonical template.
+func unescapeRoute(route string) string {
+	if strings.IndexByte(route, '\\') == -1 {
+		return route
+	}
+
+	segments := strings.Split(route, "/")
+	for i, seg := range segments {
+		if seg == "" || seg[0] == ':' || seg[0] == '*' {
+			continue
+		}
+		segments[i] = escapeRouteLiteral(unescapeSegment(seg))
+	}
+	return strings.Join(segments, "/")
+}
+
+func unescapeSegment(seg string) string {
+	if strings.IndexByte(seg, '\\') == -1 {
+		return seg
+	}
+
+	var b strings.Builder
+	b.Grow(len(seg))
+	for i := 0; i < len(seg); i++ {
+		c := seg[i]
+		if c == '\\' && i+1 < len(seg) && isRouteMeta(seg[i+1]) {
+			i++
+			c = seg[i]
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// escapeRouteLiteral escapes the literal path segment so it is not mistaken
+// for a param or an escape sequence when added to the tree.
+func escapeRouteLiteral(s string) string {
+	if s == "" {
+		return s
+	}
+	if s[0] == ':' || s[0] == '*' || (s[0] == '\\' && len(s) > 1 && isRouteMeta(s[1])) {
+		return `\` + s
+	}
+	return s
+}
+
+// unescapeRouteLiteral is the inverse of escapeRouteLiteral.
+func unescapeRouteLiteral(s string) string {
+	if len(s) > 1 && s[0] == '\\' && isRouteMeta(s[1]) {
+		return s[1:]
+	}
+	return s
+}
+
+func isRouteMeta(c byte) bool {
+	return c == ':' || c == '*' || c == '\\'
+}
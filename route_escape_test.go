@@ -0,0 +1,92 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUnescapeRoute(t *testing.T) {
+	tests := []struct {
+		route, want string
+	}{
+		{"/users/:id", "/users/:id"},
+		{`/v1\:batch`, "/v1:batch"},
+		{`/\:literal/a\*b`, `/\:literal/a*b`},
+		{`/a\\b`, `/a\b`},
+		{`/\\\:x`, `/\\:x`},
+		{`/files/*path`, `/files/*path`},
+		{`/a\b`, `/a\b`},
+	}
+	for _, test := range tests {
+		got := unescapeRoute(test.route)
+		if got != test.want {
+			t.Errorf("unescapeRoute(%q) = %q, wanted %q", test.route, got, test.want)
+		}
+		if again := unescapeRoute(escapeRouteLiterals(got)); again != got {
+			t.Errorf("%q does not round-trip: got %q", got, again)
+		}
+	}
+}
+
+// escapeRouteLiterals escapes every metacharacter in the static segments.
+func escapeRouteLiterals(route string) string {
+	segments := strings.Split(route, "/")
+	for i, seg := range segments {
+		if seg == "" || seg[0] == ':' || seg[0] == '*' {
+			continue
+		}
+		seg = unescapeRouteLiteral(seg)
+		var b strings.Builder
+		for j := 0; j < len(seg); j++ {
+			if isRouteMeta(seg[j]) {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(seg[j])
+		}
+		segments[i] = b.String()
+	}
+	return strings.Join(segments, "/")
+}
+
+func TestRouteEscapes(t *testing.T) {
+	var route string
+	handler := func(w http.ResponseWriter, req Request) error {
+		route = req.Route()
+		return nil
+	}
+
+	router := New()
+	router.GET(`/v1\:batch`, handler).Name("batch")
+	router.GET(`/v1/:id`, handler)
+	router.GET(`/files/a\*b`, handler)
+
+	tests := []struct {
+		path  string
+		route string
+	}{
+		{"/v1:batch", "/v1:batch"},
+		{"/v1/42", "/v1/:id"},
+		{"/files/a*b", "/files/a*b"},
+	}
+	for _, test := range tests {
+		route = ""
+		r, _ := newRequest("GET", test.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != http.StatusOK || route != test.route {
+			t.Errorf("%s: got code %d and route %q, wanted %q", test.path, w.Code, route, test.route)
+		}
+	}
+
+	if got, err := router.URL("batch", nil); err != nil || got != "/v1:batch" {
+		t.Errorf("got URL %q and error %v", got, err)
+	}
+
+	router = New()
+	router.GET(`/\:literal`, handler)
+	if dump := router.Dump(); !strings.Contains(dump, `\:literal`) {
+		t.Errorf("dump does not escape the literal:\n%s", dump)
+	}
+}
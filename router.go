@@ -7,7 +7,10 @@ package treemux
 import (
 	"net/http"
 	"net/url"
+	"reflect"
+	"sort"
 	"sync"
+	"time"
 )
 
 type HandlerFunc func(http.ResponseWriter, Request) error
@@ -52,20 +55,67 @@ type LookupResult struct {
 	// will also be used in the case
 	StatusCode int
 	route      string
+	info       *RouteInfo
 	handler    HandlerFunc
 	params     Params
-	handlerMap *handlerMap // Only has a value when StatusCode is MethodNotAllowed.
+	// paramValues contains decoded values of the params typed in the route pattern.
+	paramValues map[string]interface{}
+	// handlerMap contains the methods of the routes matching the path when StatusCode
+	// is MethodNotAllowed, and of automatic OPTIONS requests that match several routes.
+	handlerMap *handlerMap
+	// req replaces the served request when the path was normalized.
+	req *http.Request
+}
+
+// RouteInfo returns the description of the matched route. It is nil if the lookup
+// did not find a route, e.g. for 404 responses and redirects.
+func (lr LookupResult) RouteInfo() *RouteInfo {
+	return lr.info
 }
 
 type TreeMux struct {
 	root  *node
 	mutex sync.RWMutex
 
+	paramTypes map[string]*ParamType
+	// paramDecoders contains the param types registered with RegisterParamType keyed by
+	// the Go type they produce. It is replaced, never modified, on registration.
+	paramDecoders map[reflect.Type]*ParamType
+	metrics       *Metrics
+	// routeNames contains routes named with Route.Name.
+	routeNames map[string]*RouteInfo
+	// hosts contains the routing trees added with Host keyed by the normalized host.
+	hosts map[string]*node
+	// shards contains the shard trees of the default tree keyed by the first segment
+	// when ShardByFirstSegment is enabled.
+	shards map[string]*node
+	// notFoundGroups contains the groups with a NotFoundHandler sorted by descending
+	// path length.
+	notFoundGroups []*groupHandlers
+	// scopedPanicHandlers is set when a group or a route has a PanicHandler.
+	scopedPanicHandlers bool
+	// batching is set while Batch.Commit adds routes without sorting the trees.
+	batching bool
+	// now returns the current time for MiddlewareTimer. Nil means time.Now.
+	now func() time.Time
+	// middlewares are added with TreeMux.Use and wrap serveLookup in serve.
+	middlewares []MiddlewareFunc
+	serve       HandlerFunc
+
 	Group
 
+	// ErrorHandler is called with the errors returned by handlers. The default
+	// DefaultErrorHandler responds with the status code and the message of HTTPError.
 	ErrorHandler func(w http.ResponseWriter, req Request, err error)
 
-	// The default NotFoundHandler is http.NotFound.
+	// PanicHandler is called with the value of a panic in a handler, a middleware, or
+	// the NotFoundHandler and MethodNotAllowedHandler, so a panic does not silently
+	// close the connection. The default DefaultPanicHandler logs the stack and responds
+	// with 500. Set it to nil to let panics propagate to the http.Server.
+	PanicHandler func(w http.ResponseWriter, req Request, recovered interface{})
+
+	// The default NotFoundHandler is http.NotFound. Use LookupMissFrom to find out
+	// why the request did not match.
 	NotFoundHandler func(w http.ResponseWriter, r *http.Request)
 
 	// Any OPTIONS request that matches a path without its own OPTIONS handler will use this handler,
@@ -77,10 +127,22 @@ type TreeMux struct {
 	// handler just writes the status code http.StatusMethodNotAllowed and adds
 	// the required Allowed header.
 	// The methods parameter contains the map of each method to the corresponding
-	// handler function.
+	// handler function. Use LookupMissFrom to get more details about the request.
 	MethodNotAllowedHandler func(w http.ResponseWriter, r *http.Request,
 		methods map[string]HandlerFunc)
 
+	// AutoOptions responds to OPTIONS requests for routes without their own OPTIONS
+	// handler with 204 No Content and the Allow header listing the methods served for
+	// the path, including the methods of the wildcard and catch-all routes that match it.
+	// The response is produced by the middlewares of the group that registered the first
+	// method of the route, so a CORS middleware can answer preflight requests. Preflight
+	// requests for a method served by a wildcard or catch-all route go to the middlewares
+	// of that route instead. If
+	// OptionsHandler is set, it is called instead of writing the default response.
+	// Registering an OPTIONS handler for the route overrides the automatic one.
+	// It must be set before adding routes and is disabled by default.
+	AutoOptions bool
+
 	// HeadCanUseGet allows the router to use the GET handler to respond to
 	// HEAD requests if no explicit HEAD handler has been added for the
 	// matching pattern. This is true by default.
@@ -118,20 +180,103 @@ type TreeMux struct {
 	// library that modify the Request before passing it to the router.
 	PathSource PathSource
 
+	// PathSyntax selects the param syntax of route patterns. Set it to BraceSyntax to
+	// also accept chi and gorilla/mux style "{param}" and "{param:regex}" params.
+	// It must be set before adding routes.
+	PathSyntax PathSyntax
+
+	// ShardByFirstSegment splits the routes of the default tree into independent trees
+	// keyed by their first static path segment, e.g. "users" for "/users/:id". Routes
+	// whose first segment is a param or a catch-all stay in the default tree. Lookups
+	// are only faster for very large tables, but shards can be rebuilt on their own with
+	// ReloadShard. Matching priority is the same as without sharding. It must be set
+	// before adding routes and is disabled by default.
+	ShardByFirstSegment bool
+
+	// NodeArenaSize, if set, allocates the nodes of the routing trees in slabs of this
+	// many nodes, which speeds up registering tables with 100k+ routes and improves
+	// lookup locality. Routes added later use the remaining nodes of the last slab.
+	// Reload rebuilds the table into new slabs and releases the old ones once the old
+	// table is no longer used. It must be set before adding routes; 1024 is a good
+	// size for large tables.
+	NodeArenaSize int
+
 	// EscapeAddedRoutes controls URI escaping behavior when adding a route to the tree.
 	// If set to true, the router will add both the route as originally passed, and
 	// a version passed through URL.EscapedPath. This behavior is disabled by default.
 	EscapeAddedRoutes bool
 
+	// CanonicalPaths stores routes in a single canonical percent-encoded form and
+	// canonicalizes request paths before matching, so a route matches regardless of how
+	// the request path is encoded, e.g. "/café", "/caf%C3%A9", and "/caf%c3%a9" are
+	// the same path. Encoded slashes (%2F) never match a slash in the route.
+	// EscapeAddedRoutes has no effect when this is enabled. Disabled by default.
+	CanonicalPaths bool
+
+	// MiddlewareTimer, if set, is called after each request with the time spent in every
+	// middleware added with Group.UseNamed. The duration excludes the time spent in the
+	// inner middlewares and the handler.
+	MiddlewareTimer func(req Request, name string, d time.Duration)
+
+	// Settings is a registry of settings that can be changed at runtime,
+	// for example, using an admin endpoint created with Settings.Handler.
+	Settings Settings
+
+	// CaseInsensitive matches the static segments of routes regardless of case using
+	// Unicode case folding, e.g. "/Users/ÉMILE" matches the route "/users/émile".
+	// Percent-encoded characters are compared as is. Route templates in RouteInfo are
+	// folded. It must be set before adding routes and is disabled by default.
+	CaseInsensitive bool
+
+	// FoldParams also case-folds param values when CaseInsensitive is enabled, so
+	// handlers see the same value for "/users/ÉMILE" and "/users/émile". By default
+	// param values are passed as they appear in the request path.
+	FoldParams bool
+
+	// WrapErrors wraps errors returned by handlers with a *RouteError that identifies
+	// the route, so errors logged by the middlewares and the ErrorHandler can be traced
+	// to the endpoint. Use RouteFromError to extract it.
+	WrapErrors bool
+
+	// PathUTF8 controls how paths with invalid UTF-8 are handled. By default they are
+	// matched as is.
+	PathUTF8 UTF8Policy
+
+	// RecordCallers records the file:line of the code that registers every route in
+	// RouteInfo.Caller, so registration conflicts report where both routes come from.
+	// It makes registration slower and is disabled by default.
+	RecordCallers bool
+
 	// SafeAddRoutesWhileRunning tells the router to protect all accesses to the tree with an RWMutex. This is only needed
 	// if you are going to add routes after the router has already begun serving requests. There is a potential
 	// performance penalty at high load.
 	SafeAddRoutesWhileRunning bool
 }
 
-// Dump returns a text representation of the routing tree.
+// Use adds a middleware that wraps every request served by the router: the routes
+// registered before and after the call, as well as NotFoundHandler, MethodNotAllowedHandler,
+// and redirects. The middlewares run before the middlewares of the groups, and
+// Request.RouteInfo returns nil for requests that did not match a route. Middlewares
+// must be added before the router starts serving requests.
+//
+// Unlike Group.Use, which the method shadows, it is not affected by the registration order.
+// To add a middleware only to the routes registered later, use router.NewGroup("").Use.
+func (t *TreeMux) Use(fn MiddlewareFunc) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.middlewares = append(t.middlewares, fn)
+	t.serve = handlerWithMiddlewares(t.serveLookup, t.middlewares)
+}
+
+// Dump returns a text representation of the routing tree, followed by the shard trees
+// when ShardByFirstSegment is enabled.
 func (t *TreeMux) Dump() string {
-	return t.root.dumpTree("", "")
+	dump := t.root.dumpTree("", "")
+	for _, key := range t.shardKeys() {
+		dump += t.shards[key].dumpTree("", "")
+	}
+	return dump
 }
 
 func (t *TreeMux) redirectStatusCode(method string) (int, bool) {
@@ -173,6 +318,17 @@ func redirect(w http.ResponseWriter, req Request, newPath string, statusCode int
 }
 
 func (t *TreeMux) lookup(w http.ResponseWriter, r *http.Request) (LookupResult, bool) {
+	if r2 := t.checkUTF8(r); r2 == nil {
+		return LookupResult{
+			StatusCode: http.StatusBadRequest,
+			handler:    badPathHandler,
+		}, false
+	} else if r2 != r {
+		lr, found := t.lookup(w, r2)
+		lr.req = r2
+		return lr, found
+	}
+
 	path := r.RequestURI
 	unescapedPath := r.URL.Path
 	pathLen := len(path)
@@ -191,19 +347,37 @@ func (t *TreeMux) lookup(w http.ResponseWriter, r *http.Request) (LookupResult,
 		pathLen = len(path)
 	}
 
+	if t.CanonicalPaths {
+		path = canonicalPath(path)
+		pathLen = len(path)
+	}
+
 	trailingSlash := path[pathLen-1] == '/' && pathLen > 1
 	if trailingSlash && t.RedirectTrailingSlash {
 		path = path[:pathLen-1]
 		unescapedPath = unescapedPath[:len(unescapedPath)-1]
 	}
 
-	n, handler, params := t.root.search(r.Method, path[1:])
+	searchPath := path
+	if t.CaseInsensitive {
+		searchPath = foldPath(path)
+	}
+
+	root := t.hostTree(r)
+	n, handler, params := t.find(root, r.Method, searchPath[1:])
 	if n == nil {
 		if t.RedirectCleanPath {
 			// Path was not found. Try cleaning it up and search again.
 			// TODO Test this
 			cleanPath := Clean(unescapedPath)
-			n, handler, params = t.root.search(r.Method, cleanPath[1:])
+			searchPath := cleanPath
+			if t.CanonicalPaths {
+				searchPath = canonicalPath(searchPath)
+			}
+			if t.CaseInsensitive {
+				searchPath = foldPath(searchPath)
+			}
+			n, handler, params = t.find(root, r.Method, searchPath[1:])
 			if n == nil {
 				return LookupResult{
 					StatusCode: http.StatusNotFound,
@@ -231,11 +405,19 @@ func (t *TreeMux) lookup(w http.ResponseWriter, r *http.Request) (LookupResult,
 		if handler == nil {
 			return LookupResult{
 				StatusCode: http.StatusMethodNotAllowed,
-				handlerMap: n.handlerMap,
+				handlerMap: t.allowedMethods(root, searchPath[1:], n),
 			}, false
 		}
 	}
 
+	var allowed *handlerMap
+	if r.Method == http.MethodOptions && n.handlerMap.isImplicit(http.MethodOptions) {
+		n, handler, params = t.preflightTarget(root, r, searchPath[1:], n, handler, params)
+		if m := t.allowedMethods(root, searchPath[1:], n); m != n.handlerMap {
+			allowed = m
+		}
+	}
+
 	if !n.isCatchAll || t.RemoveCatchAllTrailingSlash {
 		if trailingSlash != n.addSlash && t.RedirectTrailingSlash {
 			if statusCode, ok := t.redirectStatusCode(r.Method); ok {
@@ -259,11 +441,31 @@ func (t *TreeMux) lookup(w http.ResponseWriter, r *http.Request) (LookupResult,
 		}
 	}
 
+	if t.CaseInsensitive {
+		params = caseParams(n.route, path, params, t.FoldParams)
+	}
+
+	// Params typed in the route pattern are part of matching, so mismatches are
+	// rejected before any middleware runs.
+	info := n.handlerMap.Route(r.Method)
+	var values map[string]interface{}
+	if info != nil && len(info.patternTypes) > 0 {
+		var ok bool
+		if values, ok = lookupPatternParams(info, params); !ok {
+			return LookupResult{
+				StatusCode: http.StatusNotFound,
+			}, false
+		}
+	}
+
 	lr := LookupResult{
-		StatusCode: http.StatusOK,
-		route:      n.route,
-		handler:    handler,
-		params:     params,
+		StatusCode:  http.StatusOK,
+		route:       n.route,
+		info:        info,
+		handler:     handler,
+		params:      params,
+		paramValues: values,
+		handlerMap:  allowed,
 	}
 
 	return lr, true
@@ -294,32 +496,84 @@ func (t *TreeMux) Lookup(w http.ResponseWriter, r *http.Request) (LookupResult,
 
 // ServeLookupResult serves a request, given a lookup result from the Lookup function.
 func (t *TreeMux) ServeLookupResult(w http.ResponseWriter, req *http.Request, lr LookupResult) {
-	if lr.handler == nil {
-		if lr.StatusCode == http.StatusMethodNotAllowed && lr.handlerMap != nil {
-			if t.SafeAddRoutesWhileRunning {
-				t.mutex.RLock()
-			}
-
-			t.MethodNotAllowedHandler(w, req, lr.handlerMap.Map())
-
-			if t.SafeAddRoutesWhileRunning {
-				t.mutex.RUnlock()
-			}
-			return
-		}
-
-		t.NotFoundHandler(w, req)
-		return
+	if lr.req != nil {
+		req = lr.req
 	}
 
 	reqWrapper := Request{
+		mux:     t,
 		ctx:     req.Context(),
 		Request: req,
 		route:   lr.route,
+		info:    lr.info,
 		Params:  lr.params,
+
+		paramValues: lr.paramValues,
+	}
+	if t.PanicHandler != nil || t.scopedPanicHandlers {
+		defer t.recoverPanic(w, reqWrapper)
+	}
+	if debugBuild {
+		defer startPhase().end(phaseDispatch)
+	}
+	if t.serve != nil {
+		// Only misses and automatic OPTIONS requests carry the lookup result: taking
+		// the address of lr would move it to the heap for every request.
+		reqWrapper.handler = lr.handler
+		if lr.handler == nil || lr.handlerMap != nil {
+			miss := lr
+			reqWrapper.lookup = &miss
+		}
+		if err := t.serve(w, reqWrapper); err != nil {
+			t.handleError(w, reqWrapper, err)
+		}
+		return
+	}
+
+	if lr.handler == nil {
+		miss := lr
+		t.serveMiss(w, req, &miss)
+		return
+	}
+	if lr.handlerMap != nil {
+		options := lr
+		reqWrapper.lookup = &options
 	}
 	if err := lr.handler(w, reqWrapper); err != nil {
-		t.ErrorHandler(w, reqWrapper, err)
+		t.handleError(w, reqWrapper, err)
+	}
+}
+
+// serveLookup serves the handler or the miss carried by the request. It is wrapped
+// by the middlewares added with TreeMux.Use.
+func (t *TreeMux) serveLookup(w http.ResponseWriter, req Request) error {
+	if req.handler == nil {
+		t.serveMiss(w, req.httpRequest(), req.lookup)
+		return nil
+	}
+	return req.handler(w, req)
+}
+
+// serveMiss serves requests that did not match a route with NotFoundHandler
+// or MethodNotAllowedHandler.
+func (t *TreeMux) serveMiss(w http.ResponseWriter, req *http.Request, lr *LookupResult) {
+	if t.SafeAddRoutesWhileRunning {
+		t.mutex.RLock()
+	}
+
+	req = t.withLookupMiss(req, lr)
+	notAllowed := lr.StatusCode == http.StatusMethodNotAllowed && lr.handlerMap != nil
+	if notAllowed {
+		t.MethodNotAllowedHandler(w, req, lr.handlerMap.Map())
+	}
+	notFound := t.notFoundHandler(req)
+
+	if t.SafeAddRoutesWhileRunning {
+		t.mutex.RUnlock()
+	}
+
+	if !notAllowed {
+		notFound(w, req)
 	}
 }
 
@@ -330,7 +584,9 @@ func (t *TreeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		t.mutex.RLock()
 	}
 
+	span := startPhase()
 	result, _ := t.lookup(w, r)
+	span.end(phaseLookup)
 
 	if t.SafeAddRoutesWhileRunning {
 		t.mutex.RUnlock()
@@ -342,10 +598,16 @@ func (t *TreeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // MethodNotAllowedHandler is the default handler for TreeMux.MethodNotAllowedHandler,
 // which is called for patterns that match, but do not have a handler installed for the
 // requested method. It simply writes the status code http.StatusMethodNotAllowed and fills
-// in the `Allow` header value appropriately.
+// in the `Allow` header value appropriately. Methods are listed in sorted order so
+// responses are deterministic.
 func MethodNotAllowedHandler(w http.ResponseWriter, r *http.Request,
 	methods map[string]HandlerFunc) {
+	allowed := make([]string, 0, len(methods))
 	for m := range methods {
+		allowed = append(allowed, m)
+	}
+	sort.Strings(allowed)
+	for _, m := range allowed {
 		w.Header().Add("Allow", m)
 	}
 
@@ -355,6 +617,8 @@ func MethodNotAllowedHandler(w http.ResponseWriter, r *http.Request,
 func New() *TreeMux {
 	tm := &TreeMux{
 		root:                    &node{path: "/"},
+		ErrorHandler:            DefaultErrorHandler,
+		PanicHandler:            DefaultPanicHandler,
 		NotFoundHandler:         http.NotFound,
 		MethodNotAllowedHandler: MethodNotAllowedHandler,
 		HeadCanUseGet:           true,
@@ -231,6 +231,53 @@ func TestMethodNotAllowedHandler(t *testing.T) {
 	}
 }
 
+func TestMethodNotAllowedSortedAllow(t *testing.T) {
+	router := New()
+	router.PUT("/user", simpleHandler)
+	router.DELETE("/user", simpleHandler)
+	router.GET("/user", simpleHandler)
+
+	for i := 0; i < 10; i++ {
+		w := httptest.NewRecorder()
+		r, _ := newRequest("POST", "/user", nil)
+		router.ServeHTTP(w, r)
+
+		expected := []string{"DELETE", "GET", "HEAD", "PUT"}
+		if allowed := w.Header()["Allow"]; !reflect.DeepEqual(allowed, expected) {
+			t.Fatalf("Expected Allow header %v, saw %v", expected, allowed)
+		}
+	}
+}
+
+func TestWebDAVMethods(t *testing.T) {
+	router := New()
+	router.AutoOptions = true
+	router.PROPFIND("/dav/*path", simpleHandler)
+	router.MKCOL("/dav/*path", simpleHandler)
+	router.COPY("/dav/*path", simpleHandler)
+	router.MOVE("/dav/*path", simpleHandler)
+	router.LOCK("/dav/*path", simpleHandler)
+	router.UNLOCK("/dav/*path", simpleHandler)
+	router.GET("/dav/*path", simpleHandler)
+
+	w := httptest.NewRecorder()
+	r, _ := newRequest("PROPFIND", "/dav/a/b", nil)
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PROPFIND: got code %d, wanted 200", w.Code)
+	}
+
+	expected := []string{"COPY", "GET", "HEAD", "LOCK", "MKCOL", "MOVE", "OPTIONS", "PROPFIND", "UNLOCK"}
+	for _, method := range []string{"PUT", "OPTIONS"} {
+		w := httptest.NewRecorder()
+		r, _ := newRequest(method, "/dav/a", nil)
+		router.ServeHTTP(w, r)
+		if allowed := w.Header()["Allow"]; !reflect.DeepEqual(allowed, expected) {
+			t.Errorf("%s: expected Allow header %v, saw %v", method, expected, allowed)
+		}
+	}
+}
+
 func TestOptionsHandler(t *testing.T) {
 	optionsHandler := func(w http.ResponseWriter, r Request) error {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -1090,7 +1137,7 @@ func TestMiddleware(t *testing.T) {
 		assertExecLog([]string{"h1"})
 	}
 
-	// Test route with and without middleware.
+	// Router middlewares wrap routes registered before and after them.
 	{
 		execLog = nil
 		router.Use(newMiddleware("m1"))
@@ -1102,7 +1149,28 @@ func TestMiddleware(t *testing.T) {
 		req, _ = newRequest("GET", "/h2", nil)
 		router.ServeHTTP(w, req)
 
-		assertExecLog([]string{"h1", "m1", "h2"})
+		assertExecLog([]string{"m1", "h1", "m1", "h2"})
+	}
+
+	// Router middlewares wrap not found responses and redirects.
+	{
+		execLog = nil
+
+		req, _ := newRequest("GET", "/missing", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("got %d, wanted %d", w.Code, http.StatusNotFound)
+		}
+
+		req, _ = newRequest("GET", "/h2/", nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusMovedPermanently {
+			t.Fatalf("got %d, wanted %d", w.Code, http.StatusMovedPermanently)
+		}
+
+		assertExecLog([]string{"m1", "m1"})
 	}
 
 	// NewGroup inherits middlewares but has its own stack.
@@ -1149,6 +1217,23 @@ func TestMiddleware(t *testing.T) {
 		assertExecLog([]string{"m1", "m4", "h6"})
 	}
 
+	// Route middlewares run after the group middlewares and only for the route.
+	{
+		execLog = nil
+		g := router.NewGroup("/g3")
+		g.Use(newMiddleware("m5"))
+		g.GET("/h7", newHandler("h7"), newMiddleware("r1"), newMiddleware("r2"))
+		g.GET("/h8", newHandler("h8"))
+
+		req, _ := newRequest("GET", "/g3/h7", nil)
+		router.ServeHTTP(w, req)
+
+		req, _ = newRequest("GET", "/g3/h8", nil)
+		router.ServeHTTP(w, req)
+
+		assertExecLog([]string{"m1", "m5", "r1", "r2", "h7", "m1", "m5", "h8"})
+	}
+
 	// Middleware can serve request without calling next.
 	{
 		execLog = nil
@@ -1218,3 +1303,130 @@ func BenchmarkRouterLongParams(b *testing.B) {
 
 	benchRequest(b, router, r)
 }
+
+func BenchmarkRouterUse(b *testing.B) {
+	router := New()
+	router.Use(func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			return next(w, req)
+		}
+	})
+
+	router.GET("/", simpleHandler)
+	router.GET("/user/:name/:resource", simpleHandler)
+
+	r, _ := newRequest("GET", "/user/aaaabbbbccccddddeeeeffff/asdfghjkl", nil)
+
+	benchRequest(b, router, r)
+}
+
+func TestParamsAll(t *testing.T) {
+	var params Params
+	router := New()
+	router.GET("/:id/compare/:id", func(w http.ResponseWriter, r Request) error {
+		params = r.Params
+		return nil
+	})
+	router.GET("/tags/:tag/*tag", func(w http.ResponseWriter, r Request) error {
+		params = r.Params
+		return nil
+	})
+
+	tests := []struct {
+		path string
+		name string
+		want []string
+	}{
+		{"/1/compare/2", "id", []string{"1", "2"}},
+		{"/tags/go/web/http", "tag", []string{"go", "web/http"}},
+		{"/tags/go/web", "missing", nil},
+	}
+	for _, test := range tests {
+		r, _ := newRequest("GET", test.path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), r)
+		if got := params.All(test.name); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, wanted %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestParamsAt(t *testing.T) {
+	var params Params
+	router := New()
+	router.GET("/users/:user/posts/:post/*path", func(w http.ResponseWriter, r Request) error {
+		params = r.Params
+		return nil
+	})
+
+	r, _ := newRequest("GET", "/users/u1/posts/p2/a/b", nil)
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	if names := params.Names(); !reflect.DeepEqual(names, []string{"user", "post", "path"}) {
+		t.Errorf("got names %q", names)
+	}
+	want := []Param{{"user", "u1"}, {"post", "p2"}, {"path", "a/b"}}
+	for i, p := range want {
+		if got := params.At(i); got != p {
+			t.Errorf("At(%d) = %v, wanted %v", i, got, p)
+		}
+	}
+}
+
+func TestStaticRoutes(t *testing.T) {
+	var route string
+	handler := func(w http.ResponseWriter, r Request) error {
+		route = r.Route()
+		return nil
+	}
+
+	router := New()
+	router.GET("/users", handler)
+	router.GET("/users/new", handler)
+	router.GET("/users/:id", handler)
+	router.POST("/users/*path", handler)
+	router.GET(`/\:literal`, handler)
+
+	if n := len(router.root.staticRoutes); n != 2 {
+		t.Errorf("got %d static routes, wanted 2", n)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		route  string
+	}{
+		{"GET", "/users", 200, "/users"},
+		{"GET", "/users/new", 200, "/users/new"},
+		{"GET", "/users/1", 200, "/users/:id"},
+		{"POST", "/users/new", 200, "/users/*path"},
+		{"PUT", "/users/new", 405, ""},
+		{"GET", "/:literal", 200, `/\:literal`},
+	}
+	for _, test := range tests {
+		route = ""
+		r, _ := newRequest(test.method, test.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != test.code || route != test.route {
+			t.Errorf("%s %s: got code %d and route %q, wanted %d and %q",
+				test.method, test.path, w.Code, route, test.code, test.route)
+		}
+	}
+}
+
+func BenchmarkRouterStatic(b *testing.B) {
+	router := New()
+	for _, route := range createRoutes(1000) {
+		router.GET(route, simpleHandler)
+	}
+	router.GET("/api/v1/users/list", simpleHandler)
+
+	r, _ := newRequest("GET", "/api/v1/users/list", nil)
+	w := new(mockResponseWriter)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		router.ServeHTTP(w, r)
+	}
+}
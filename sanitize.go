@@ -0,0 +1,94 @@
+package treemux
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// SanitizeRule declares how the router sanitizes route params before calling the handler.
+type SanitizeRule struct {
+	// Param is the name of the param. Empty string applies the rule to all params.
+	Param string
+	// StripNulls removes NUL characters.
+	StripNulls bool
+	// TrimSpace removes leading and trailing white space.
+	TrimSpace bool
+	// CollapseSpace replaces runs of white space with a single space.
+	CollapseSpace bool
+	// Charset, if set, reports whether the character is allowed. Requests with params
+	// containing other characters are rejected with 400 Bad Request.
+	Charset func(r rune) bool
+}
+
+// Sanitize declares sanitization rules for the route params. The rules are applied in order
+// by the router after the middlewares and before the handler.
+//
+//	router.GET("/search/:query", search).Sanitize(
+//		treemux.SanitizeRule{StripNulls: true, TrimSpace: true, CollapseSpace: true},
+//		treemux.SanitizeRule{Param: "query", Charset: unicode.IsPrint},
+//	)
+func (r *Route) Sanitize(rules ...SanitizeRule) *Route {
+	for _, rule := range rules {
+		if rule.Param != "" && !routeHasParam(r.info.Route, rule.Param) {
+			panic(fmt.Sprintf("route %q does not have param %q", r.info.Route, rule.Param))
+		}
+	}
+	r.info.Sanitize = append(r.info.Sanitize, rules...)
+	return r
+}
+
+// sanitizeParams returns a sanitized copy of the params or an error
+// if a param contains characters outside of the charset.
+func sanitizeParams(rules []SanitizeRule, params Params) (Params, error) {
+	params = append(Params(nil), params...)
+	for _, rule := range rules {
+		for i := range params {
+			p := &params[i]
+			if rule.Param != "" && rule.Param != p.Name {
+				continue
+			}
+			p.Value = rule.apply(p.Value)
+			if rule.Charset != nil {
+				for _, r := range p.Value {
+					if !rule.Charset(r) {
+						return nil, fmt.Errorf("treemux: param %q contains a forbidden character %q",
+							p.Name, r)
+					}
+				}
+			}
+		}
+	}
+	return params, nil
+}
+
+func (rule *SanitizeRule) apply(s string) string {
+	if rule.StripNulls {
+		s = strings.Replace(s, "\x00", "", -1)
+	}
+	if rule.CollapseSpace {
+		s = collapseSpace(s)
+	}
+	if rule.TrimSpace {
+		s = strings.TrimSpace(s)
+	}
+	return s
+}
+
+func collapseSpace(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	inSpace := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			if !inSpace {
+				b.WriteByte(' ')
+			}
+			inSpace = true
+			continue
+		}
+		inSpace = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
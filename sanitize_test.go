@@ -0,0 +1,37 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"unicode"
+)
+
+func TestRouteSanitize(t *testing.T) {
+	var got Params
+	router := New()
+	router.GET("/search/:query/:tag", func(w http.ResponseWriter, req Request) error {
+		got = req.Params
+		return nil
+	}).Sanitize(
+		SanitizeRule{StripNulls: true, TrimSpace: true},
+		SanitizeRule{Param: "query", CollapseSpace: true},
+		SanitizeRule{Param: "tag", Charset: func(r rune) bool {
+			return unicode.IsLetter(r) || r == '-'
+		}},
+	)
+
+	r, _ := newRequest("GET", "/search/%20go%00%20%20%09router%20/go-lang%00", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || got.Text("query") != "go router" || got.Text("tag") != "go-lang" {
+		t.Errorf("got %d %q", w.Code, got)
+	}
+
+	r, _ = newRequest("GET", "/search/go/go_lang", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("got %d for a forbidden character", w.Code)
+	}
+}
@@ -0,0 +1,87 @@
+package treemux
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MetaSchedule is the route metadata key that declares when the route is available.
+// The value must be a Schedule.
+const MetaSchedule = "schedule"
+
+// Schedule is an availability window of a route.
+type Schedule struct {
+	// From is the activation time. Zero means the route is available immediately.
+	From time.Time
+	// Until is the deactivation time. Zero means the route never expires.
+	Until time.Time
+}
+
+// Active reports whether the schedule is active at the time.
+func (s Schedule) Active(t time.Time) bool {
+	if !s.From.IsZero() && t.Before(s.From) {
+		return false
+	}
+	if !s.Until.IsZero() && !t.Before(s.Until) {
+		return false
+	}
+	return true
+}
+
+// ScheduleConfig configures the Scheduled middleware.
+type ScheduleConfig struct {
+	// Schedule applies to routes without the MetaSchedule metadata, which is useful
+	// to schedule a whole group, e.g. an API version that goes live at a timestamp.
+	Schedule Schedule
+	// Unavailable handles requests outside of the schedule. The default handler
+	// responds with 404 Not Found and sets Retry-After for routes that are not active yet.
+	Unavailable HandlerFunc
+	// Now returns the current time. The default is time.Now.
+	Now func() time.Time
+}
+
+// Scheduled returns a middleware that serves requests only within the availability window
+// of the route.
+//
+//	v2 := router.NewGroup("/v2")
+//	v2.Use(treemux.Scheduled(treemux.ScheduleConfig{
+//		Schedule: treemux.Schedule{From: launchTime},
+//	}))
+func Scheduled(cfg ScheduleConfig) MiddlewareFunc {
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if cfg.Unavailable == nil {
+		cfg.Unavailable = scheduleUnavailable(cfg.Schedule, cfg.Now)
+	}
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			if !routeSchedule(req, cfg.Schedule).Active(cfg.Now()) {
+				return cfg.Unavailable(w, req)
+			}
+			return next(w, req)
+		}
+	}
+}
+
+func routeSchedule(req Request, schedule Schedule) Schedule {
+	if v, ok := req.RouteInfo().Value(MetaSchedule); ok {
+		if s, ok := v.(Schedule); ok {
+			return s
+		}
+	}
+	return schedule
+}
+
+func scheduleUnavailable(schedule Schedule, now func() time.Time) HandlerFunc {
+	return func(w http.ResponseWriter, req Request) error {
+		s := routeSchedule(req, schedule)
+		if wait := s.From.Sub(now()); wait > 0 {
+			secs := int64((wait + time.Second - 1) / time.Second)
+			w.Header().Set("Retry-After", strconv.FormatInt(secs, 10))
+		}
+		http.NotFound(w, req.Request)
+		return nil
+	}
+}
@@ -0,0 +1,39 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestScheduled(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	router := New()
+	v2 := router.NewGroup("/v2")
+	v2.Use(Scheduled(ScheduleConfig{
+		Schedule: Schedule{From: now.Add(time.Minute)},
+		Now:      func() time.Time { return now },
+	}))
+	v2.GET("/users", simpleHandler)
+	v2.GET("/legacy", simpleHandler).Meta(MetaSchedule, Schedule{Until: now})
+	v2.GET("/early", simpleHandler).Meta(MetaSchedule, Schedule{From: now.Add(-time.Hour)})
+
+	tests := []struct {
+		path       string
+		code       int
+		retryAfter string
+	}{
+		{"/v2/users", http.StatusNotFound, "60"},
+		{"/v2/legacy", http.StatusNotFound, ""},
+		{"/v2/early", http.StatusOK, ""},
+	}
+	for _, test := range tests {
+		r, _ := newRequest("GET", test.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != test.code || w.Header().Get("Retry-After") != test.retryAfter {
+			t.Errorf("%s: got %d Retry-After %q", test.path, w.Code, w.Header().Get("Retry-After"))
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package treemux
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// groupHandlers contains the handlers set on a group. Handlers that are not set are
+// inherited from the parent group and finally from the TreeMux.
+type groupHandlers struct {
+	parent *groupHandlers
+	// source identifies the group in EffectiveHandlers, e.g. "group /api".
+	source string
+	host   string
+	path   string
+
+	errorHandler    func(w http.ResponseWriter, req Request, err error)
+	panicHandler    func(w http.ResponseWriter, req Request, recovered interface{})
+	notFoundHandler func(w http.ResponseWriter, r *http.Request)
+}
+
+func newGroupHandlers(parent *groupHandlers, host, path string) *groupHandlers {
+	source := host + path
+	if path == "" {
+		source += "/"
+	}
+	return &groupHandlers{
+		parent: parent,
+		source: "group " + source,
+		host:   host,
+		path:   path,
+	}
+}
+
+// EffectiveHandlers describes the handlers used for a route. Every handler is taken
+// from the first of the route, its group, the parent groups, and the TreeMux that
+// sets it. The sources are "route", "group <host><path>", or "mux".
+type EffectiveHandlers struct {
+	ErrorHandler       func(w http.ResponseWriter, req Request, err error)
+	ErrorHandlerSource string
+
+	PanicHandler       func(w http.ResponseWriter, req Request, recovered interface{})
+	PanicHandlerSource string
+
+	// NotFoundHandler is used for requests with param values that don't match the
+	// param types of the route.
+	NotFoundHandler       func(w http.ResponseWriter, r *http.Request)
+	NotFoundHandlerSource string
+}
+
+// EffectiveHandlers returns the handlers used for the route, e.g. one returned by
+// TreeMux.Routes or Request.RouteInfo. For a nil route, it returns the handlers of
+// the TreeMux.
+func (t *TreeMux) EffectiveHandlers(info *RouteInfo) EffectiveHandlers {
+	h := EffectiveHandlers{
+		ErrorHandler:          t.ErrorHandler,
+		ErrorHandlerSource:    "mux",
+		PanicHandler:          t.PanicHandler,
+		PanicHandlerSource:    "mux",
+		NotFoundHandler:       t.NotFoundHandler,
+		NotFoundHandlerSource: "mux",
+	}
+	if info == nil {
+		return h
+	}
+
+	var errorSet, panicSet, notFoundSet bool
+	if info.errorHandler != nil {
+		h.ErrorHandler, h.ErrorHandlerSource, errorSet = info.errorHandler, "route", true
+	}
+	if info.panicHandler != nil {
+		h.PanicHandler, h.PanicHandlerSource, panicSet = info.panicHandler, "route", true
+	}
+	if info.notFoundHandler != nil {
+		h.NotFoundHandler, h.NotFoundHandlerSource, notFoundSet = info.notFoundHandler, "route", true
+	}
+	for g := info.handlers; g != nil; g = g.parent {
+		if !errorSet && g.errorHandler != nil {
+			h.ErrorHandler, h.ErrorHandlerSource, errorSet = g.errorHandler, g.source, true
+		}
+		if !panicSet && g.panicHandler != nil {
+			h.PanicHandler, h.PanicHandlerSource, panicSet = g.panicHandler, g.source, true
+		}
+		if !notFoundSet && g.notFoundHandler != nil {
+			h.NotFoundHandler, h.NotFoundHandlerSource, notFoundSet = g.notFoundHandler, g.source, true
+		}
+	}
+	return h
+}
+
+// SetErrorHandler sets the ErrorHandler of the routes of the group and its sub-groups,
+// including the routes registered before the call. On the TreeMux itself, it sets
+// TreeMux.ErrorHandler.
+func (g *Group) SetErrorHandler(fn func(w http.ResponseWriter, req Request, err error)) {
+	g.mux.mutex.Lock()
+	defer g.mux.mutex.Unlock()
+
+	if g.handlers == nil {
+		g.mux.ErrorHandler = fn
+		return
+	}
+	g.handlers.errorHandler = fn
+}
+
+// SetPanicHandler sets the PanicHandler of the routes of the group and its sub-groups,
+// including the routes registered before the call. On the TreeMux itself, it sets
+// TreeMux.PanicHandler.
+func (g *Group) SetPanicHandler(fn func(w http.ResponseWriter, req Request, recovered interface{})) {
+	g.mux.mutex.Lock()
+	defer g.mux.mutex.Unlock()
+
+	if g.handlers == nil {
+		g.mux.PanicHandler = fn
+		return
+	}
+	g.handlers.panicHandler = fn
+	g.mux.scopedPanicHandlers = true
+}
+
+// SetNotFoundHandler sets the NotFoundHandler for the requests that did not match a
+// route and whose path starts with the group path, e.g. "/api" and "/api/x" for the
+// group "/api". The group with the longest path wins. It is also used for the routes
+// of the group with param values that don't match the param types. On the TreeMux
+// itself, it sets TreeMux.NotFoundHandler.
+func (g *Group) SetNotFoundHandler(fn func(w http.ResponseWriter, r *http.Request)) {
+	g.mux.mutex.Lock()
+	defer g.mux.mutex.Unlock()
+
+	if g.handlers == nil {
+		g.mux.NotFoundHandler = fn
+		return
+	}
+	if g.handlers.notFoundHandler == nil {
+		groups := append(g.mux.notFoundGroups, g.handlers)
+		sort.SliceStable(groups, func(i, j int) bool {
+			return len(groups[i].path) > len(groups[j].path)
+		})
+		g.mux.notFoundGroups = groups
+	}
+	g.handlers.notFoundHandler = fn
+}
+
+// ErrorHandler sets the ErrorHandler of the route, overriding the handlers of the
+// groups and the TreeMux.
+func (r *Route) ErrorHandler(fn func(w http.ResponseWriter, req Request, err error)) *Route {
+	r.info.errorHandler = fn
+	return r
+}
+
+// PanicHandler sets the PanicHandler of the route, overriding the handlers of the
+// groups and the TreeMux.
+func (r *Route) PanicHandler(fn func(w http.ResponseWriter, req Request, recovered interface{})) *Route {
+	r.mux.mutex.Lock()
+	r.mux.scopedPanicHandlers = true
+	r.mux.mutex.Unlock()
+
+	r.info.panicHandler = fn
+	return r
+}
+
+// NotFoundHandler sets the handler for requests with param values that don't match
+// the param types of the route.
+func (r *Route) NotFoundHandler(fn func(w http.ResponseWriter, req *http.Request)) *Route {
+	r.info.notFoundHandler = fn
+	return r
+}
+
+// handleError calls the effective ErrorHandler of the request route, unless a middleware
+// already served the error.
+func (t *TreeMux) handleError(w http.ResponseWriter, req Request, err error) {
+	if errors.As(err, new(servedError)) {
+		return
+	}
+	if req.info == nil {
+		t.ErrorHandler(w, req, err)
+		return
+	}
+	t.EffectiveHandlers(req.info).ErrorHandler(w, req, err)
+}
+
+// notFoundHandler returns the NotFoundHandler of the group with the longest path that
+// contains the request path. The caller must hold t.mutex in the concurrency safe mode.
+func (t *TreeMux) notFoundHandler(req *http.Request) func(w http.ResponseWriter, r *http.Request) {
+	if len(t.notFoundGroups) == 0 {
+		return t.NotFoundHandler
+	}
+
+	path := req.URL.Path
+	for _, g := range t.notFoundGroups {
+		if g.host != "" && t.hostTree(req) != t.hosts[g.host] {
+			continue
+		}
+		if strings.HasPrefix(path, g.path) && (len(path) == len(g.path) || path[len(g.path)] == '/') {
+			return g.notFoundHandler
+		}
+	}
+	return t.NotFoundHandler
+}
@@ -0,0 +1,114 @@
+package treemux
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestScopedHandlers(t *testing.T) {
+	router := New()
+	failing := func(w http.ResponseWriter, req Request) error {
+		return errors.New("failed")
+	}
+	statusHandler := func(code int) func(w http.ResponseWriter, req Request, err error) {
+		return func(w http.ResponseWriter, req Request, err error) {
+			w.WriteHeader(code)
+		}
+	}
+	notFound := func(code int) func(w http.ResponseWriter, r *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(code)
+		}
+	}
+
+	api := router.NewGroup("/api")
+	v1 := api.NewGroup("/v1")
+	v1.GET("/inherited", failing)
+	v1.GET("/route", failing).ErrorHandler(statusHandler(http.StatusTeapot))
+	v1.GET("/typed/:id|int", simpleHandler)
+	router.GET("/mux", failing)
+
+	// Handlers set after the routes were registered apply to them.
+	api.SetErrorHandler(statusHandler(http.StatusBadGateway))
+	api.SetNotFoundHandler(notFound(http.StatusGone))
+	v1.SetNotFoundHandler(notFound(http.StatusNotImplemented))
+	router.SetErrorHandler(statusHandler(http.StatusServiceUnavailable))
+
+	tests := []struct {
+		path string
+		code int
+	}{
+		{"/api/v1/inherited", http.StatusBadGateway},
+		{"/api/v1/route", http.StatusTeapot},
+		{"/mux", http.StatusServiceUnavailable},
+		{"/api/v1/typed/x", http.StatusNotImplemented},
+		{"/api/v1/missing", http.StatusNotImplemented},
+		{"/api/v1", http.StatusNotImplemented},
+		{"/api/v2", http.StatusGone},
+		{"/apix", http.StatusNotFound},
+		{"/missing", http.StatusNotFound},
+	}
+	for _, test := range tests {
+		r, _ := newRequest("GET", test.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != test.code {
+			t.Errorf("%s: got code %d, wanted %d", test.path, w.Code, test.code)
+		}
+	}
+}
+
+func TestScopedPanicHandler(t *testing.T) {
+	router := New()
+	router.PanicHandler = nil
+	api := router.NewGroup("/api")
+	api.SetPanicHandler(func(w http.ResponseWriter, req Request, recovered interface{}) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	api.GET("/panic", func(w http.ResponseWriter, req Request) error {
+		panic("boom")
+	})
+	router.GET("/panic", func(w http.ResponseWriter, req Request) error {
+		panic("boom")
+	})
+
+	r, _ := newRequest("GET", "/api/panic", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("got code %d", w.Code)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("panic of a route without a PanicHandler was recovered")
+		}
+	}()
+	r, _ = newRequest("GET", "/panic", nil)
+	router.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+func TestEffectiveHandlers(t *testing.T) {
+	router := New()
+	api := router.Host("api.example.com").NewGroup("/api")
+	api.SetErrorHandler(func(w http.ResponseWriter, req Request, err error) {})
+	route := api.NewGroup("/v1").GET("/users", simpleHandler).
+		PanicHandler(func(w http.ResponseWriter, req Request, recovered interface{}) {})
+
+	h := router.EffectiveHandlers(route.Info())
+	if h.ErrorHandlerSource != "group api.example.com/api" || h.ErrorHandler == nil {
+		t.Errorf("got error handler source %q", h.ErrorHandlerSource)
+	}
+	if h.PanicHandlerSource != "route" || h.PanicHandler == nil {
+		t.Errorf("got panic handler source %q", h.PanicHandlerSource)
+	}
+	if h.NotFoundHandlerSource != "mux" || h.NotFoundHandler == nil {
+		t.Errorf("got not found handler source %q", h.NotFoundHandlerSource)
+	}
+
+	if h := router.EffectiveHandlers(nil); h.ErrorHandlerSource != "mux" {
+		t.Errorf("got %q for a nil route", h.ErrorHandlerSource)
+	}
+}
@@ -0,0 +1,245 @@
+package treemux
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Setting is a router setting that can be changed at runtime.
+// It has the same method set as flag.Value.
+type Setting interface {
+	String() string
+	Set(value string) error
+}
+
+// Settings is a registry of settings that can be changed at runtime, for example,
+// maintenance flags, log sampling rules, or rate limits.
+type Settings struct {
+	mu sync.RWMutex
+	m  map[string]Setting
+}
+
+// Register adds the setting to the registry. It panics if the name is already registered.
+func (s *Settings) Register(name string, setting Setting) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.m[name]; ok {
+		panic(fmt.Sprintf("setting %q is already registered", name))
+	}
+	if s.m == nil {
+		s.m = make(map[string]Setting)
+	}
+	s.m[name] = setting
+}
+
+// Get returns the setting registered under the name.
+func (s *Settings) Get(name string) (Setting, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	setting, ok := s.m[name]
+	return setting, ok
+}
+
+// Set changes the value of the setting registered under the name.
+func (s *Settings) Set(name, value string) error {
+	return s.setAll(map[string]string{name: value})
+}
+
+// setAll changes the values of the settings as one update: unknown names are rejected
+// before any setting changes, and if a value is invalid, the settings changed before
+// it are restored.
+func (s *Settings) setAll(values map[string]string) error {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings := make([]Setting, len(names))
+	for i, name := range names {
+		setting, ok := s.m[name]
+		if !ok {
+			return fmt.Errorf("treemux: unknown setting %q", name)
+		}
+		settings[i] = setting
+	}
+
+	old := make([]string, len(settings))
+	for i, setting := range settings {
+		old[i] = setting.String()
+		if err := setting.Set(values[names[i]]); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				_ = settings[j].Set(old[j])
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Values returns the current values of all settings.
+func (s *Settings) Values() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	m := make(map[string]string, len(s.m))
+	for name, setting := range s.m {
+		m[name] = setting.String()
+	}
+	return m
+}
+
+// Handler returns an admin handler that exposes the settings over HTTP.
+//
+// GET requests return a JSON object with the current values. POST, PUT, and PATCH requests
+// accept a JSON object with new values, e.g. {"maintenance": "true"}, and respond with the
+// updated values. The values are applied together: if one of them is invalid, none
+// is changed. The auth function is called before serving every request and the request
+// is rejected with 403 Forbidden if it returns an error. Handler panics if auth is nil,
+// since the settings must not be changeable by anyone.
+func (s *Settings) Handler(auth func(req Request) error) HandlerFunc {
+	if auth == nil {
+		panic("treemux: Settings.Handler requires an auth function")
+	}
+	return func(w http.ResponseWriter, req Request) error {
+		if err := auth(req); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return nil
+		}
+
+		switch req.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			var values map[string]string
+			if err := json.NewDecoder(req.Body).Decode(&values); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return nil
+			}
+			if err := s.setAll(values); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return nil
+			}
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST, PUT, PATCH")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return nil
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		return json.NewEncoder(w).Encode(s.Values())
+	}
+}
+
+// BoolSetting is a bool Setting that is safe for concurrent use.
+type BoolSetting struct {
+	v int32
+}
+
+var _ Setting = (*BoolSetting)(nil)
+
+// Value returns the current value.
+func (s *BoolSetting) Value() bool {
+	return atomic.LoadInt32(&s.v) == 1
+}
+
+// Store changes the current value.
+func (s *BoolSetting) Store(value bool) {
+	var v int32
+	if value {
+		v = 1
+	}
+	atomic.StoreInt32(&s.v, v)
+}
+
+func (s *BoolSetting) String() string {
+	return strconv.FormatBool(s.Value())
+}
+
+func (s *BoolSetting) Set(value string) error {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	s.Store(b)
+	return nil
+}
+
+// SamplingSetting returns a Setting that controls the logger sampling rules.
+// Rules are separated by semicolons and have the format "[route] class=rate",
+// where class is a status class like "5xx" or "*" for any status.
+// For example, "5xx=1; /api/feed 2xx=0.01".
+func (l *AccessLogger) SamplingSetting() Setting {
+	return samplingSetting{l}
+}
+
+type samplingSetting struct {
+	l *AccessLogger
+}
+
+func (s samplingSetting) String() string {
+	rules := s.l.Sampling()
+	parts := make([]string, len(rules))
+	for i, rule := range rules {
+		class := "*"
+		if rule.StatusClass != 0 {
+			class = strconv.Itoa(rule.StatusClass) + "xx"
+		}
+		part := class + "=" + strconv.FormatFloat(rule.Rate, 'g', -1, 64)
+		if rule.Route != "" {
+			part = rule.Route + " " + part
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (s samplingSetting) Set(value string) error {
+	var rules []SamplingRule
+	for _, part := range strings.Split(value, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var rule SamplingRule
+		if i := strings.LastIndexByte(part, ' '); i >= 0 {
+			rule.Route = strings.TrimSpace(part[:i])
+			part = part[i+1:]
+		}
+
+		i := strings.IndexByte(part, '=')
+		if i == -1 {
+			return fmt.Errorf("treemux: invalid sampling rule %q", part)
+		}
+		class, rate := part[:i], part[i+1:]
+
+		if class != "*" {
+			if len(class) != 3 || class[1:] != "xx" || class[0] < '1' || class[0] > '5' {
+				return fmt.Errorf("treemux: invalid status class %q", class)
+			}
+			rule.StatusClass = int(class[0] - '0')
+		}
+
+		var err error
+		rule.Rate, err = strconv.ParseFloat(rate, 64)
+		if err != nil {
+			return fmt.Errorf("treemux: invalid sampling rate %q", rate)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	s.l.SetSampling(rules...)
+	return nil
+}
@@ -0,0 +1,90 @@
+package treemux
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSettingsHandler(t *testing.T) {
+	logger := NewAccessLogger(func(*AccessLogEntry) {})
+	maintenance := new(BoolSetting)
+
+	router := New()
+	router.Settings.Register("maintenance", maintenance)
+	router.Settings.Register("log.sampling", logger.SamplingSetting())
+	router.Settings.Register("readonly", new(BoolSetting))
+	router.Handle("GET", "/admin/settings", router.Settings.Handler(func(Request) error { return nil }))
+	router.Handle("POST", "/admin/settings", router.Settings.Handler(func(req Request) error {
+		if req.Header.Get("X-Admin") == "" {
+			return errors.New("forbidden")
+		}
+		return nil
+	}))
+
+	post := func(body string, admin bool) *httptest.ResponseRecorder {
+		r, _ := newRequest("POST", "/admin/settings", strings.NewReader(body))
+		if admin {
+			r.Header.Set("X-Admin", "1")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := post(`{"maintenance": "true"}`, false); w.Code != http.StatusForbidden {
+		t.Errorf("got %d, wanted %d", w.Code, http.StatusForbidden)
+	}
+
+	w := post(`{"maintenance": "true", "log.sampling": "5xx=1; /api/feed 2xx=0.01"}`, true)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", w.Code, w.Body.String())
+	}
+	if !maintenance.Value() {
+		t.Error("maintenance was not enabled")
+	}
+	wanted := []SamplingRule{
+		{StatusClass: 5, Rate: 1},
+		{Route: "/api/feed", StatusClass: 2, Rate: 0.01},
+	}
+	if got := logger.Sampling(); !reflect.DeepEqual(got, wanted) {
+		t.Errorf("got rules %v, wanted %v", got, wanted)
+	}
+
+	if w := post(`{"unknown": "1"}`, true); w.Code != http.StatusBadRequest {
+		t.Errorf("got %d, wanted %d", w.Code, http.StatusBadRequest)
+	}
+	if w := post(`{"log.sampling": "6xx=1"}`, true); w.Code != http.StatusBadRequest {
+		t.Errorf("got %d, wanted %d", w.Code, http.StatusBadRequest)
+	}
+	// readonly is applied after maintenance, which must be restored.
+	if w := post(`{"maintenance": "false", "readonly": "maybe"}`, true); w.Code != http.StatusBadRequest {
+		t.Errorf("got %d, wanted %d", w.Code, http.StatusBadRequest)
+	}
+	if w := post(`{"maintenance": "false", "unknown": "1"}`, true); w.Code != http.StatusBadRequest {
+		t.Errorf("got %d, wanted %d", w.Code, http.StatusBadRequest)
+	}
+	if !maintenance.Value() {
+		t.Error("maintenance was changed by a rejected update")
+	}
+
+	r, _ := newRequest("GET", "/admin/settings", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	body := strings.TrimSpace(w.Body.String())
+	if body != `{"log.sampling":"5xx=1; /api/feed 2xx=0.01","maintenance":"true","readonly":"false"}` {
+		t.Errorf("got %s", body)
+	}
+}
+
+func TestSettingsHandlerNilAuth(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a nil auth function")
+		}
+	}()
+	new(Settings).Handler(nil)
+}
@@ -0,0 +1,140 @@
+package treemux
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// shardTree returns the tree of the default table the route is added to: the shard
+// of its first segment if the segment is static and the default tree otherwise.
+// The caller must hold t.mutex.
+func (g *Group) shardTree(route string) *node {
+	key, ok := routeShardKey(route)
+	if g.shard != "" {
+		if !ok || key != g.shard {
+			panic(fmt.Sprintf("route %q is not in the shard %q", route, g.shard))
+		}
+		return g.root
+	}
+
+	root := g.root
+	if root == nil {
+		root = g.mux.root
+	}
+	if !ok {
+		return root
+	}
+
+	shards := g.shards
+	if shards == nil {
+		if g.mux.shards == nil {
+			g.mux.shards = make(map[string]*node)
+		}
+		shards = g.mux.shards
+	}
+	shard := shards[key]
+	if shard == nil {
+		shard = &node{path: "/"}
+		shards[key] = shard
+	}
+	return shard
+}
+
+// routeShardKey returns the first segment of the route if it is static. Segments with
+// escaped param characters stay in the default tree.
+func routeShardKey(route string) (string, bool) {
+	seg := route[1:]
+	if i := strings.IndexByte(seg, '/'); i != -1 {
+		seg = seg[:i]
+	}
+	if seg == "" || strings.ContainsAny(seg, ":*") {
+		return "", false
+	}
+	return unescapeRouteLiteral(seg), true
+}
+
+// find looks up the path, without the leading slash, in the tree. When the default
+// tree is sharded, the shard of the first path segment is searched before it. Like in
+// a single tree, a static match wins unless it has no handler for the method.
+func (t *TreeMux) find(root *node, method, path string) (*node, HandlerFunc, []Param) {
+	var n *node
+	var handler HandlerFunc
+	var params []Param
+	if root == t.root && len(t.shards) > 0 {
+		seg := path
+		if i := strings.IndexByte(path, '/'); i != -1 {
+			seg = path[:i]
+		}
+		if shard, ok := t.shards[seg]; ok {
+			n, handler, params = searchTree(shard, method, path)
+			if handler != nil {
+				return n, handler, params
+			}
+		}
+	}
+
+	rootNode, rootHandler, rootParams := searchTree(root, method, path)
+	if rootHandler != nil || n == nil {
+		return rootNode, rootHandler, rootParams
+	}
+	return n, handler, params
+}
+
+func searchTree(root *node, method, path string) (*node, HandlerFunc, []Param) {
+	if n, handler := root.lookupStatic(method, path); handler != nil {
+		return n, handler, nil
+	}
+	return root.search(method, path)
+}
+
+// ReloadShard is like Reload, but only rebuilds the routes of the default tree whose
+// first path segment is the segment, e.g. "users" for "/users/:id". The other routes
+// are not affected, so large tables can be updated one part at a time. Every route
+// added by build must start with the segment. It requires ShardByFirstSegment.
+//
+//	err := router.ReloadShard("users", func(g *treemux.Group) {
+//		g.GET("/users/:id", getUser)
+//	})
+func (t *TreeMux) ReloadShard(segment string, build func(g *Group)) (err error) {
+	if !t.ShardByFirstSegment {
+		return fmt.Errorf("treemux: ReloadShard requires ShardByFirstSegment")
+	}
+
+	root := &node{path: "/"}
+	g := t.Group.NewGroup("")
+	g.root = root
+	g.shard = segment
+	g.routeNames = t.keepRouteNames(func(info *RouteInfo) bool {
+		key, ok := routeShardKey(info.Route)
+		return info.Host != "" || !ok || key != segment
+	})
+
+	defer recoverReload(&err)
+	build(g)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	shards := make(map[string]*node, len(t.shards)+1)
+	for key, shard := range t.shards {
+		shards[key] = shard
+	}
+	if root.handlerMap == nil && len(root.staticChild) == 0 {
+		delete(shards, segment)
+	} else {
+		shards[segment] = root
+	}
+	t.shards = shards
+	t.routeNames = g.routeNames
+	return nil
+}
+
+// shardKeys returns the sorted segments of the shards.
+func (t *TreeMux) shardKeys() []string {
+	keys := make([]string, 0, len(t.shards))
+	for key := range t.shards {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,179 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestShardByFirstSegment(t *testing.T) {
+	newRouter := func(sharded bool) *TreeMux {
+		router := New()
+		router.ShardByFirstSegment = sharded
+		router.GET("/", simpleHandler)
+		router.GET("/:page", simpleHandler)
+		router.POST("/:page/edit", simpleHandler)
+		router.GET("/users/:id", simpleHandler)
+		router.PUT("/users/:id", simpleHandler)
+		router.GET("/users/me", simpleHandler)
+		router.DELETE("/users", simpleHandler)
+		router.GET("/files/*path", simpleHandler)
+		router.GET("/*all", simpleHandler)
+		return router
+	}
+	plain, sharded := newRouter(false), newRouter(true)
+
+	if got := len(sharded.shards); got != 2 {
+		t.Fatalf("got %d shards, wanted 2", got)
+	}
+
+	tests := []struct {
+		method, path string
+	}{
+		{"GET", "/"},
+		{"GET", "/about"},
+		{"POST", "/about/edit"},
+		{"GET", "/users"},
+		{"DELETE", "/users"},
+		{"POST", "/users"},
+		{"POST", "/users/edit"},
+		{"GET", "/users/me"},
+		{"GET", "/users/1"},
+		{"DELETE", "/users/1"},
+		{"GET", "/users/1/posts"},
+		{"GET", "/files/a/b"},
+		{"POST", "/files/a/b"},
+		{"GET", "/files"},
+		{"GET", "/a/b/c"},
+	}
+	for _, test := range tests {
+		want, wantFound := plain.Lookup(nil, mustRequest(test.method, test.path))
+		got, found := sharded.Lookup(nil, mustRequest(test.method, test.path))
+		if found != wantFound || got.StatusCode != want.StatusCode || lookupRoute(got) != lookupRoute(want) {
+			t.Errorf("%s %s: got %d %q, wanted %d %q", test.method, test.path,
+				got.StatusCode, lookupRoute(got), want.StatusCode, lookupRoute(want))
+		}
+		if len(got.params) != len(want.params) {
+			t.Errorf("%s %s: got params %v, wanted %v", test.method, test.path, got.params, want.params)
+		}
+	}
+
+	if got, want := len(sharded.Routes()), len(plain.Routes()); got != want {
+		t.Errorf("got %d routes, wanted %d", got, want)
+	}
+}
+
+func TestReloadShard(t *testing.T) {
+	router := New()
+	router.ShardByFirstSegment = true
+	router.SafeAddRoutesWhileRunning = true
+	router.GET("/users/:id", simpleHandler)
+	router.GET("/posts/:id", simpleHandler)
+
+	code := func(path string) int {
+		r, _ := newRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	err := router.ReloadShard("users", func(g *Group) {
+		g.GET("/users/:id/posts", simpleHandler)
+		g.GET("/posts", simpleHandler)
+	})
+	if err == nil {
+		t.Fatal("expected an error for a route outside of the shard")
+	}
+	if code("/users/1") != http.StatusOK {
+		t.Fatal("failed reload replaced the shard")
+	}
+
+	err = router.ReloadShard("users", func(g *Group) {
+		g.GET("/users/:id/posts", simpleHandler)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code("/users/1") != http.StatusNotFound || code("/users/1/posts") != http.StatusOK {
+		t.Error("shard was not reloaded")
+	}
+	if code("/posts/1") != http.StatusOK {
+		t.Error("other shard was affected by the reload")
+	}
+
+	if err := router.ReloadShard("users", func(g *Group) {}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := router.shards["users"]; ok {
+		t.Error("empty shard was not removed")
+	}
+
+	if err := New().ReloadShard("users", func(g *Group) {}); err == nil {
+		t.Error("expected an error for a router without shards")
+	}
+}
+
+func TestReloadShardRouteNames(t *testing.T) {
+	router := New()
+	router.ShardByFirstSegment = true
+	router.GET("/users/:id", simpleHandler).Name("user")
+	router.GET("/posts/:id", simpleHandler).Name("post")
+
+	build := func(g *Group) {
+		g.GET("/users/:id/posts", simpleHandler).Name("user_posts")
+	}
+	for i := 0; i < 2; i++ {
+		if err := router.ReloadShard("users", build); err != nil {
+			t.Fatalf("reload %d: %s", i, err)
+		}
+	}
+
+	if _, err := router.URL("user", map[string]string{"id": "1"}); err == nil {
+		t.Error("name of the removed route is still resolved")
+	}
+	for _, name := range []string{"user_posts", "post"} {
+		if _, err := router.URL(name, map[string]string{"id": "1"}); err != nil {
+			t.Error(err)
+		}
+	}
+
+	err := router.ReloadShard("users", func(g *Group) {
+		g.GET("/users", simpleHandler).Name("post")
+	})
+	if err == nil {
+		t.Error("expected an error for a name used outside of the shard")
+	}
+}
+
+func lookupRoute(lr LookupResult) string {
+	if info := lr.RouteInfo(); info != nil {
+		return info.Route
+	}
+	return ""
+}
+
+func mustRequest(method, path string) *http.Request {
+	r, err := newRequest(method, path, nil)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func BenchmarkRouterLargeTable(b *testing.B) {
+	for _, sharded := range []bool{false, true} {
+		b.Run("sharded="+strconv.FormatBool(sharded), func(b *testing.B) {
+			router := New()
+			router.ShardByFirstSegment = sharded
+			for i := 0; i < 1000; i++ {
+				prefix := "/resource" + strconv.Itoa(i)
+				router.GET(prefix+"/:id", simpleHandler)
+				router.GET(prefix+"/:id/items/:item", simpleHandler)
+			}
+
+			r, _ := newRequest("GET", "/resource500/1/items/2", nil)
+			benchRequest(b, router, r)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestURLSigner(t *testing.T) {
+	signer := &URLSigner{Key: []byte("secret")}
+
+	router := New()
+	g := router.NewGroup("/download")
+	g.Use(signer.Middleware)
+	g.GET("/:id/*file", func(w http.ResponseWriter, req Request) error {
+		_, err := w.Write([]byte(req.Param("id") + " " + req.Param("file")))
+		return err
+	})
+
+	get := func(url string) *httptest.ResponseRecorder {
+		r, _ := newRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	signed, err := signer.SignRoute("/download/:id/*file",
+		Params{{"id", "a b"}, {"file", "docs/report.pdf"}}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(signed, "/download/a%20b/docs/report.pdf?expires=") {
+		t.Fatalf("unexpected signed URL %s", signed)
+	}
+
+	if w := get(signed); w.Code != http.StatusOK || w.Body.String() != "a b docs/report.pdf" {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+	if w := get(strings.Replace(signed, "report", "secret", 1)); w.Code != http.StatusForbidden {
+		t.Errorf("got %d for a tampered path", w.Code)
+	}
+	if w := get(signed + "&inline=1"); w.Code != http.StatusForbidden {
+		t.Errorf("got %d for a tampered query", w.Code)
+	}
+	if w := get("/download/1/file"); w.Code != http.StatusForbidden {
+		t.Errorf("got %d for an unsigned URL", w.Code)
+	}
+
+	expired, _ := signer.Sign("/download/1/file?inline=1", time.Now().Add(-time.Minute))
+	if w := get(expired); w.Code != http.StatusForbidden ||
+		!strings.Contains(w.Body.String(), ErrURLExpired.Error()) {
+		t.Errorf("got %d %q for an expired URL", w.Code, w.Body.String())
+	}
+
+	special, err := signer.SignRoute("/download/:id/*file",
+		Params{{"id", "1"}, {"file", "q?a#b/100% done.pdf"}}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := get(special); w.Code != http.StatusOK || w.Body.String() != "1 q?a#b/100% done.pdf" {
+		t.Errorf("got %d %q for %s", w.Code, w.Body.String(), special)
+	}
+
+	if _, err := signer.SignRoute("/download/:id", nil, time.Now()); err == nil {
+		t.Error("expected an error for a missing param")
+	}
+}
@@ -0,0 +1,162 @@
+package treemux
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MetaSLO is the route metadata key that declares the route SLO. The value must be an SLO.
+const MetaSLO = "slo"
+
+// SLO is a service level objective for a route.
+type SLO struct {
+	// Target is the fraction of requests that must succeed, e.g. 0.999.
+	Target float64
+	// Latency is the maximum duration of a successful request. Zero means no limit.
+	Latency time.Duration
+}
+
+// BurnEvent reports that a route is consuming its error budget too fast.
+type BurnEvent struct {
+	Method string
+	Route  string
+	SLO    SLO
+	// Total and Bad are the number of requests and the number of requests that failed or
+	// were too slow during the window.
+	Total int
+	Bad   int
+	// BurnRate is the ratio between the observed error rate and the error rate allowed
+	// by the SLO. A burn rate of 1 consumes the error budget exactly at the end of the SLO
+	// period.
+	BurnRate float64
+}
+
+// SLOConfig configures the SLOMonitor middleware.
+type SLOConfig struct {
+	// Window is the duration of a measurement window. The default is one minute.
+	Window time.Duration
+	// BurnRate is the burn rate threshold. The default is 1.
+	BurnRate float64
+	// MinRequests is the minimal number of requests in a window to evaluate the burn rate.
+	MinRequests int
+	// OnBurn is called for every window in which the burn rate exceeded the threshold.
+	// Windows are evaluated lazily by the first request to the route after the window
+	// is over, so the event is delayed until the route receives another request.
+	// OnBurn is required.
+	OnBurn func(event *BurnEvent)
+	// Now returns the current time. The default is time.Now.
+	Now func() time.Time
+}
+
+// SLOMonitor returns a middleware that tracks routes with an SLO declared using
+// the MetaSLO route metadata and reports burn-rate events. A request is bad if
+// the handler returns an error, the response status is 5xx, or the request takes
+// longer than the SLO latency.
+//
+//	router.Use(treemux.SLOMonitor(treemux.SLOConfig{OnBurn: alert}))
+//	router.GET("/checkout", checkout).Meta(treemux.MetaSLO, treemux.SLO{
+//		Target:  0.999,
+//		Latency: 300 * time.Millisecond,
+//	})
+func SLOMonitor(cfg SLOConfig) MiddlewareFunc {
+	if cfg.OnBurn == nil {
+		panic("treemux: SLOConfig.OnBurn is required")
+	}
+	if cfg.Window == 0 {
+		cfg.Window = time.Minute
+	}
+	if cfg.BurnRate == 0 {
+		cfg.BurnRate = 1
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	var mu sync.Mutex
+	windows := make(map[*RouteInfo]*sloWindow)
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			info := req.RouteInfo()
+			v, _ := info.Value(MetaSLO)
+			slo, ok := v.(SLO)
+			if !ok {
+				return next(w, req)
+			}
+
+			start := cfg.Now()
+			rw := NewResponseWriter(w)
+			err := next(rw, req)
+			elapsed := cfg.Now().Sub(start)
+
+			bad := responseStatus(rw, err) >= 500 ||
+				(slo.Latency > 0 && elapsed > slo.Latency)
+
+			mu.Lock()
+			win := windows[info]
+			if win == nil {
+				win = &sloWindow{start: start}
+				windows[info] = win
+			}
+			event := win.add(info, slo, &cfg, start, bad)
+			mu.Unlock()
+
+			if event != nil {
+				cfg.OnBurn(event)
+			}
+			return err
+		}
+	}
+}
+
+type sloWindow struct {
+	start time.Time
+	total int
+	bad   int
+}
+
+// add records a request and returns a burn event if the previous window is over
+// and its burn rate exceeded the threshold.
+func (w *sloWindow) add(
+	info *RouteInfo, slo SLO, cfg *SLOConfig, now time.Time, bad bool,
+) *BurnEvent {
+	var event *BurnEvent
+	if now.Sub(w.start) >= cfg.Window {
+		event = w.evaluate(info, slo, cfg)
+		*w = sloWindow{start: now}
+	}
+
+	w.total++
+	if bad {
+		w.bad++
+	}
+	return event
+}
+
+func (w *sloWindow) evaluate(info *RouteInfo, slo SLO, cfg *SLOConfig) *BurnEvent {
+	if w.total == 0 || w.total < cfg.MinRequests {
+		return nil
+	}
+
+	errorRate := float64(w.bad) / float64(w.total)
+	budget := 1 - slo.Target
+	var burnRate float64
+	if budget > 0 {
+		burnRate = errorRate / budget
+	} else if w.bad > 0 {
+		burnRate = float64(w.bad)
+	}
+	if burnRate < cfg.BurnRate || w.bad == 0 {
+		return nil
+	}
+
+	return &BurnEvent{
+		Method:   info.Method,
+		Route:    info.Route,
+		SLO:      slo,
+		Total:    w.total,
+		Bad:      w.bad,
+		BurnRate: burnRate,
+	}
+}
@@ -0,0 +1,91 @@
+package treemux
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSLOMonitor(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	var events []*BurnEvent
+	router := New()
+	router.ErrorHandler = func(w http.ResponseWriter, req Request, err error) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	router.Use(SLOMonitor(SLOConfig{
+		Window:   time.Minute,
+		BurnRate: 2,
+		OnBurn: func(event *BurnEvent) {
+			events = append(events, event)
+		},
+		Now: func() time.Time { return now },
+	}))
+
+	fail := false
+	router.GET("/checkout", func(w http.ResponseWriter, req Request) error {
+		if fail {
+			return errors.New("failed")
+		}
+		return nil
+	}).Meta(MetaSLO, SLO{Target: 0.9})
+	router.GET("/search", func(w http.ResponseWriter, req Request) error {
+		now = now.Add(time.Duration(len(req.URL.RawQuery)) * 100 * time.Millisecond)
+		return nil
+	}).Meta(MetaSLO, SLO{Target: 0.9, Latency: 300 * time.Millisecond})
+	router.GET("/untracked", func(w http.ResponseWriter, req Request) error {
+		return errors.New("failed")
+	})
+
+	serve := func(path string, n int) {
+		for i := 0; i < n; i++ {
+			r, _ := newRequest("GET", path, nil)
+			router.ServeHTTP(httptest.NewRecorder(), r)
+		}
+	}
+
+	// 1 bad request out of 10 is within the budget.
+	serve("/checkout", 9)
+	fail = true
+	serve("/checkout", 1)
+	serve("/untracked", 10)
+	now = now.Add(time.Minute)
+
+	// 5 bad requests out of 10 burn the budget 5 times faster.
+	serve("/checkout", 5)
+	fail = false
+	serve("/checkout", 5)
+	now = now.Add(time.Minute)
+	serve("/checkout", 1)
+
+	// Requests slower than the SLO latency are bad.
+	serve("/search?ab", 2)
+	serve("/search?abcd", 2)
+	now = now.Add(time.Minute)
+	serve("/search?ab", 1)
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, wanted 2", len(events))
+	}
+	if event := events[1]; event.Route != "/search" || event.Total != 4 || event.Bad != 2 {
+		t.Errorf("unexpected latency event %+v", event)
+	}
+	event := events[0]
+	if event.Route != "/checkout" || event.Total != 10 || event.Bad != 5 {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if event.BurnRate < 4.99 || event.BurnRate > 5.01 {
+		t.Errorf("got burn rate %f, wanted 5", event.BurnRate)
+	}
+}
+
+func TestSLOMonitorNilOnBurn(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a nil OnBurn")
+		}
+	}()
+	SLOMonitor(SLOConfig{})
+}
@@ -0,0 +1,82 @@
+package treemux
+
+// TreeSnapshot is a machine-readable copy of the routing trees. It can be marshaled
+// to JSON, e.g. to be served by a debug endpoint or consumed by tooling.
+type TreeSnapshot struct {
+	Root *NodeSnapshot `json:"root"`
+	// Hosts contains the trees of the hosts added with TreeMux.Host.
+	Hosts map[string]*NodeSnapshot `json:"hosts,omitempty"`
+	// Shards contains the shard trees of the default tree keyed by the first segment
+	// when TreeMux.ShardByFirstSegment is enabled.
+	Shards map[string]*NodeSnapshot `json:"shards,omitempty"`
+}
+
+// NodeSnapshot describes a node of the routing tree.
+type NodeSnapshot struct {
+	// Type is "static", "wildcard", or "catch-all".
+	Type string `json:"type"`
+	// Path is the path segment matched by a static node or the param name
+	// of a catch-all node.
+	Path     string `json:"path"`
+	Priority int    `json:"priority"`
+	// Route is the route template of the nodes that end a route.
+	Route string `json:"route,omitempty"`
+	// Methods contains the sorted methods handled by the node.
+	Methods []string `json:"methods,omitempty"`
+	// Wildcards contains the param names of the route in path order.
+	Wildcards []string `json:"wildcards,omitempty"`
+	AddSlash  bool     `json:"add_slash,omitempty"`
+	// Children are ordered as they are checked during lookups: static children
+	// by priority, then the wildcard child, then the catch-all child.
+	Children []*NodeSnapshot `json:"children,omitempty"`
+}
+
+// Snapshot returns a copy of the routing trees.
+func (t *TreeMux) Snapshot() *TreeSnapshot {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	s := &TreeSnapshot{
+		Root: t.root.snapshot("static"),
+	}
+	if len(t.hosts) > 0 {
+		s.Hosts = make(map[string]*NodeSnapshot, len(t.hosts))
+		for host, root := range t.hosts {
+			s.Hosts[host] = root.snapshot("static")
+		}
+	}
+	if len(t.shards) > 0 {
+		s.Shards = make(map[string]*NodeSnapshot, len(t.shards))
+		for key, root := range t.shards {
+			s.Shards[key] = root.snapshot("static")
+		}
+	}
+	return s
+}
+
+func (n *node) snapshot(typ string) *NodeSnapshot {
+	s := &NodeSnapshot{
+		Type:     typ,
+		Path:     n.path,
+		Priority: n.priority,
+		Route:    n.route,
+		AddSlash: n.addSlash,
+	}
+	if n.handlerMap.Len() > 0 {
+		s.Methods = n.handlerMap.Methods()
+	}
+	if len(n.leafWildcardNames) > 0 {
+		s.Wildcards = append([]string(nil), n.leafWildcardNames...)
+	}
+
+	for _, child := range n.staticChild {
+		s.Children = append(s.Children, child.snapshot("static"))
+	}
+	if n.wildcardChild != nil {
+		s.Children = append(s.Children, n.wildcardChild.snapshot("wildcard"))
+	}
+	if n.catchAllChild != nil {
+		s.Children = append(s.Children, n.catchAllChild.snapshot("catch-all"))
+	}
+	return s
+}
@@ -0,0 +1,48 @@
+package treemux
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	router := New()
+	router.GET("/users/:id", simpleHandler)
+	router.POST("/users", simpleHandler)
+	router.GET("/files/*path", simpleHandler)
+	router.Host("api.example.com").GET("/items/", simpleHandler)
+
+	s := router.Snapshot()
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got TreeSnapshot
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&got, s) {
+		t.Errorf("snapshot does not survive the JSON round trip:\n%s", b)
+	}
+
+	users := s.Root.Children[0]
+	if users.Path != "users" || users.Route != "/users" || !reflect.DeepEqual(users.Methods, []string{"POST"}) {
+		t.Errorf("got %+v", users)
+	}
+	id := users.Children[0].Children[0]
+	if id.Type != "wildcard" || id.Route != "/users/:id" ||
+		!reflect.DeepEqual(id.Methods, []string{"GET", "HEAD"}) ||
+		!reflect.DeepEqual(id.Wildcards, []string{"id"}) {
+		t.Errorf("got %+v", id)
+	}
+	files := s.Root.Children[1].Children[0].Children[0]
+	if files.Type != "catch-all" || files.Path != "path" || !reflect.DeepEqual(files.Wildcards, []string{"path"}) {
+		t.Errorf("got %+v", files)
+	}
+
+	items := s.Hosts["api.example.com"].Children[0]
+	if items.Route != "/items" || !items.AddSlash {
+		t.Errorf("got %+v", items)
+	}
+}
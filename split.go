@@ -0,0 +1,191 @@
+package treemux
+
+import (
+	"context"
+	"hash/fnv"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Variant is one of the handlers of a split route.
+type Variant struct {
+	// Name identifies the variant, e.g. "v2".
+	Name    string
+	Handler HandlerFunc
+	// Weight is the relative share of requests served by the variant. The default is 1.
+	Weight int
+	// Healthy reports whether the variant can serve requests. Unhealthy variants are
+	// excluded until they recover. It is called for every request, so it must be cheap,
+	// e.g. read a flag updated by a background health check. Nil means always healthy.
+	Healthy func() bool
+}
+
+func (v *Variant) healthy() bool {
+	return v.Healthy == nil || v.Healthy()
+}
+
+// SplitConfig configures a Split.
+type SplitConfig struct {
+	Variants []Variant
+	// Cookie is the name of the cookie that makes the split sticky. The cookie stores
+	// the variant name and is set when it is absent or names a variant that is unknown
+	// or unhealthy, so a user keeps hitting the same variant across requests.
+	Cookie string
+	// CookieMaxAge is the lifetime of the cookie. Zero means a session cookie.
+	CookieMaxAge time.Duration
+	// Header is the name of the request header, e.g. "X-User-Id", whose value is hashed
+	// to choose the variant, so requests with the same value hit the same variant as long
+	// as the set of healthy variants does not change. Requests without the header are
+	// distributed using round-robin.
+	Header string
+}
+
+// Split distributes the requests of a route between several handlers, e.g. two backend
+// client implementations, using smooth weighted round-robin over the healthy variants.
+// Requests are rejected with 503 Service Unavailable when no variant is healthy.
+//
+//	split := treemux.NewSplit(treemux.SplitConfig{
+//		Variants: []treemux.Variant{
+//			{Name: "primary", Handler: searchPrimary, Weight: 9},
+//			{Name: "secondary", Handler: searchSecondary, Weight: 1, Healthy: secondary.Up},
+//		},
+//	})
+//	router.GET("/search", split.Serve)
+//
+// The split can be made sticky with a cookie or a header, so a user consistently
+// hits the same variant.
+type Split struct {
+	cfg SplitConfig
+
+	mu      sync.Mutex
+	current []int
+}
+
+func NewSplit(cfg SplitConfig) *Split {
+	cfg.Variants = append([]Variant(nil), cfg.Variants...)
+	for i := range cfg.Variants {
+		if cfg.Variants[i].Weight == 0 {
+			cfg.Variants[i].Weight = 1
+		}
+	}
+	return &Split{
+		cfg:     cfg,
+		current: make([]int, len(cfg.Variants)),
+	}
+}
+
+type splitVariantKey struct{}
+
+// SplitVariant returns the name of the variant serving the request.
+func SplitVariant(req Request) string {
+	name, _ := req.Context().Value(splitVariantKey{}).(string)
+	return name
+}
+
+// Serve serves the request with the sticky variant or the next variant.
+func (s *Split) Serve(w http.ResponseWriter, req Request) error {
+	v := s.sticky(req)
+	if v == nil {
+		v = s.next()
+	}
+	if v == nil {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return nil
+	}
+
+	if s.cfg.Cookie != "" {
+		if c, err := req.Cookie(s.cfg.Cookie); err != nil || c.Value != v.Name {
+			cookie := &http.Cookie{
+				Name:     s.cfg.Cookie,
+				Value:    v.Name,
+				Path:     "/",
+				HttpOnly: true,
+			}
+			if s.cfg.CookieMaxAge > 0 {
+				cookie.MaxAge = int(s.cfg.CookieMaxAge / time.Second)
+			}
+			http.SetCookie(w, cookie)
+		}
+	}
+
+	req = req.WithContext(context.WithValue(req.Context(), splitVariantKey{}, v.Name))
+	return v.Handler(w, req)
+}
+
+// sticky returns the variant named by the cookie or chosen by hashing the header.
+func (s *Split) sticky(req Request) *Variant {
+	if s.cfg.Cookie != "" {
+		if c, err := req.Cookie(s.cfg.Cookie); err == nil {
+			for i := range s.cfg.Variants {
+				if v := &s.cfg.Variants[i]; v.Name == c.Value && v.healthy() {
+					return v
+				}
+			}
+		}
+	}
+
+	if s.cfg.Header != "" {
+		if value := req.Header.Get(s.cfg.Header); value != "" {
+			return s.bucket(value)
+		}
+	}
+	return nil
+}
+
+// bucket maps the value to a healthy variant in proportion to the variant weights.
+func (s *Split) bucket(value string) *Variant {
+	total := 0
+	for i := range s.cfg.Variants {
+		if s.cfg.Variants[i].healthy() {
+			total += s.cfg.Variants[i].Weight
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(value))
+	n := int(h.Sum32() % uint32(total))
+	for i := range s.cfg.Variants {
+		v := &s.cfg.Variants[i]
+		if !v.healthy() {
+			continue
+		}
+		if n < v.Weight {
+			return v
+		}
+		n -= v.Weight
+	}
+	return nil
+}
+
+// next picks a healthy variant using the smooth weighted round-robin algorithm,
+// which interleaves the variants instead of serving them in bursts.
+func (s *Split) next() *Variant {
+	healthy := make([]bool, len(s.cfg.Variants))
+	for i := range s.cfg.Variants {
+		healthy[i] = s.cfg.Variants[i].healthy()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	best, total := -1, 0
+	for i := range s.cfg.Variants {
+		if !healthy[i] {
+			continue
+		}
+		s.current[i] += s.cfg.Variants[i].Weight
+		total += s.cfg.Variants[i].Weight
+		if best == -1 || s.current[i] > s.current[best] {
+			best = i
+		}
+	}
+	if best == -1 {
+		return nil
+	}
+	s.current[best] -= total
+	return &s.cfg.Variants[best]
+}
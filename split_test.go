@@ -0,0 +1,124 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSplit(t *testing.T) {
+	var served []string
+	handler := func(w http.ResponseWriter, req Request) error {
+		served = append(served, SplitVariant(req))
+		return nil
+	}
+	var bUp int32 = 1
+
+	split := NewSplit(SplitConfig{
+		Variants: []Variant{
+			{Name: "a", Handler: handler, Weight: 2},
+			{Name: "b", Handler: handler, Healthy: func() bool { return atomic.LoadInt32(&bUp) == 1 }},
+		},
+	})
+	router := New()
+	router.GET("/search", split.Serve)
+
+	serve := func(n int) int {
+		var code int
+		for i := 0; i < n; i++ {
+			r, _ := newRequest("GET", "/search", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			code = w.Code
+		}
+		return code
+	}
+
+	serve(6)
+	if got := strings.Join(served, ","); got != "a,b,a,a,b,a" {
+		t.Errorf("got %s", got)
+	}
+
+	served = nil
+	atomic.StoreInt32(&bUp, 0)
+	serve(3)
+	if got := strings.Join(served, ","); got != "a,a,a" {
+		t.Errorf("got %s with b unhealthy", got)
+	}
+
+	down := NewSplit(SplitConfig{
+		Variants: []Variant{{Name: "a", Handler: handler, Healthy: func() bool { return false }}},
+	})
+	router.GET("/down", down.Serve)
+	r, _ := newRequest("GET", "/down", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("got code %d, wanted 503", w.Code)
+	}
+}
+
+func TestSplitSticky(t *testing.T) {
+	var variant string
+	handler := func(w http.ResponseWriter, req Request) error {
+		variant = SplitVariant(req)
+		return nil
+	}
+	var bUp int32 = 1
+
+	split := NewSplit(SplitConfig{
+		Variants: []Variant{
+			{Name: "a", Handler: handler},
+			{Name: "b", Handler: handler, Healthy: func() bool { return atomic.LoadInt32(&bUp) == 1 }},
+		},
+		Cookie:       "variant",
+		CookieMaxAge: time.Hour,
+		Header:       "X-User-Id",
+	})
+	router := New()
+	router.GET("/search", split.Serve)
+
+	serve := func(cookie, user string) *httptest.ResponseRecorder {
+		r, _ := newRequest("GET", "/search", nil)
+		if cookie != "" {
+			r.AddCookie(&http.Cookie{Name: "variant", Value: cookie})
+		}
+		if user != "" {
+			r.Header.Set("X-User-Id", user)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("", "")
+	first := variant
+	if got := w.Header().Get("Set-Cookie"); got != "variant="+first+"; Path=/; Max-Age=3600; HttpOnly" {
+		t.Errorf("got Set-Cookie %q", got)
+	}
+	for i := 0; i < 3; i++ {
+		w := serve(first, "")
+		if variant != first || w.Header().Get("Set-Cookie") != "" {
+			t.Errorf("got variant %q and Set-Cookie %q, wanted sticky %q",
+				variant, w.Header().Get("Set-Cookie"), first)
+		}
+	}
+
+	atomic.StoreInt32(&bUp, 0)
+	w = serve("b", "")
+	if variant != "a" || !strings.HasPrefix(w.Header().Get("Set-Cookie"), "variant=a;") {
+		t.Errorf("got variant %q and Set-Cookie %q with b unhealthy", variant, w.Header().Get("Set-Cookie"))
+	}
+	atomic.StoreInt32(&bUp, 1)
+
+	serve("", "user-1")
+	byHeader := variant
+	for i := 0; i < 3; i++ {
+		if serve("", "user-1"); variant != byHeader {
+			t.Errorf("got variant %q, wanted %q for the same user", variant, byHeader)
+		}
+	}
+}
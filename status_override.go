@@ -0,0 +1,74 @@
+package treemux
+
+import (
+	"net/http"
+)
+
+// MetaStatusMap is the route metadata key that maps response status codes to the codes
+// sent to the client, e.g. map[int]int{404: 502} to report missing upstream resources
+// as gateway errors. The value must be a map[int]int.
+const MetaStatusMap = "status_map"
+
+// StatusHook is called before the response header is written and returns the status code
+// sent to the client. It can also change the header.
+type StatusHook func(req Request, statusCode int, header http.Header) int
+
+// StatusOverride returns a middleware that rewrites response status codes before the header
+// is written, using the MetaStatusMap route metadata and then the hook, which can be nil.
+//
+//	router.Use(treemux.StatusOverride(nil))
+//	router.GET("/proxy/*path", proxy).Meta(treemux.MetaStatusMap, map[int]int{404: 502})
+func StatusOverride(hook StatusHook) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			v, _ := req.RouteInfo().Value(MetaStatusMap)
+			statusMap, _ := v.(map[int]int)
+			if statusMap == nil && hook == nil {
+				return next(w, req)
+			}
+
+			return next(&statusHookWriter{
+				ResponseWriter: NewResponseWriter(w),
+				req:            req,
+				statusMap:      statusMap,
+				hook:           hook,
+			}, req)
+		}
+	}
+}
+
+type statusHookWriter struct {
+	*ResponseWriter
+
+	req       Request
+	statusMap map[int]int
+	hook      StatusHook
+}
+
+func (w *statusHookWriter) WriteHeader(statusCode int) {
+	if w.WroteHeader() {
+		w.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	if code, ok := w.statusMap[statusCode]; ok {
+		statusCode = code
+	}
+	if w.hook != nil {
+		statusCode = w.hook(w.req, statusCode, w.Header())
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *statusHookWriter) Write(b []byte) (int, error) {
+	if !w.WroteHeader() {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusHookWriter) Flush() {
+	if !w.WroteHeader() {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.ResponseWriter.Flush()
+}
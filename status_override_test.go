@@ -0,0 +1,45 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatusOverride(t *testing.T) {
+	router := New()
+	router.Use(StatusOverride(func(req Request, statusCode int, header http.Header) int {
+		if statusCode >= 500 {
+			header.Set("Retry-After", "1")
+		}
+		return statusCode
+	}))
+	notFound := func(w http.ResponseWriter, req Request) error {
+		http.NotFound(w, req.Request)
+		return nil
+	}
+	router.GET("/proxy/*path", notFound).Meta(MetaStatusMap, map[int]int{404: 502})
+	router.GET("/local", notFound)
+	router.GET("/ok", func(w http.ResponseWriter, req Request) error {
+		_, err := w.Write([]byte("ok"))
+		return err
+	}).Meta(MetaStatusMap, map[int]int{200: 203})
+
+	tests := []struct {
+		path       string
+		code       int
+		retryAfter string
+	}{
+		{"/proxy/users", http.StatusBadGateway, "1"},
+		{"/local", http.StatusNotFound, ""},
+		{"/ok", http.StatusNonAuthoritativeInfo, ""},
+	}
+	for _, test := range tests {
+		r, _ := newRequest("GET", test.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != test.code || w.Header().Get("Retry-After") != test.retryAfter {
+			t.Errorf("%s: got %d %v", test.path, w.Code, w.Header())
+		}
+	}
+}
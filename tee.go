@@ -0,0 +1,133 @@
+package treemux
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// TeeRecord is a copy of a request body delivered to the BodyTee sink.
+type TeeRecord struct {
+	Method string
+	Route  string
+	Header http.Header
+	Body   []byte
+	// Truncated is true if the body is larger than TeeConfig.MaxBodySize.
+	Truncated bool
+}
+
+// TeeConfig configures a BodyTee.
+type TeeConfig struct {
+	// Sink receives the copies in a background goroutine, one at a time.
+	Sink func(rec *TeeRecord)
+	// MaxBodySize limits the size of the copied body. The default is 64KB.
+	MaxBodySize int64
+	// QueueSize is the number of copies waiting to be delivered. When the queue is full,
+	// new copies are dropped so a slow sink can't slow down the handlers. The default is 100.
+	QueueSize int
+	// OnDrop is called with the copies dropped because the queue was full.
+	OnDrop func(rec *TeeRecord)
+}
+
+// BodyTee copies request bodies into a sink, e.g. a queue or an audit store,
+// while handlers consume them normally. Only the bytes read by the handler are copied.
+type BodyTee struct {
+	cfg   TeeConfig
+	queue chan *TeeRecord
+	done  chan struct{}
+	once  sync.Once
+}
+
+// NewBodyTee starts a BodyTee. Close must be called to stop it.
+func NewBodyTee(cfg TeeConfig) *BodyTee {
+	if cfg.MaxBodySize == 0 {
+		cfg.MaxBodySize = 64 << 10
+	}
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = 100
+	}
+
+	t := &BodyTee{
+		cfg:   cfg,
+		queue: make(chan *TeeRecord, cfg.QueueSize),
+		done:  make(chan struct{}),
+	}
+	go t.deliver()
+	return t
+}
+
+func (t *BodyTee) deliver() {
+	defer close(t.done)
+	for rec := range t.queue {
+		t.cfg.Sink(rec)
+	}
+}
+
+// Close delivers the queued copies and stops the tee. The middleware must not
+// be used after Close.
+func (t *BodyTee) Close() {
+	t.once.Do(func() {
+		close(t.queue)
+	})
+	<-t.done
+}
+
+// Middleware tees the request body.
+func (t *BodyTee) Middleware(next HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, req Request) error {
+		if req.Body == nil || req.Body == http.NoBody {
+			return next(w, req)
+		}
+
+		tr := &teeReader{
+			ReadCloser: req.Body,
+			limit:      t.cfg.MaxBodySize,
+		}
+		r := new(http.Request)
+		*r = *req.Request
+		r.Body = tr
+		req.Request = r
+
+		err := next(w, req)
+
+		rec := &TeeRecord{
+			Method:    req.Method,
+			Route:     req.Route(),
+			Header:    req.Header,
+			Body:      tr.buf.Bytes(),
+			Truncated: tr.truncated,
+		}
+		select {
+		case t.queue <- rec:
+		default:
+			if t.cfg.OnDrop != nil {
+				t.cfg.OnDrop(rec)
+			}
+		}
+
+		return err
+	}
+}
+
+type teeReader struct {
+	io.ReadCloser
+
+	limit     int64
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (r *teeReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	if n > 0 {
+		room := r.limit - int64(r.buf.Len())
+		if int64(n) > room {
+			r.buf.Write(b[:room])
+			r.truncated = true
+		} else {
+			r.buf.Write(b[:n])
+		}
+	}
+	return n, err
+}
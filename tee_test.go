@@ -0,0 +1,63 @@
+package treemux
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyTee(t *testing.T) {
+	var records []*TeeRecord
+	var dropped int
+	block := make(chan struct{})
+	started := make(chan struct{}, 10)
+
+	tee := NewBodyTee(TeeConfig{
+		Sink: func(rec *TeeRecord) {
+			started <- struct{}{}
+			<-block
+			records = append(records, rec)
+		},
+		MaxBodySize: 5,
+		QueueSize:   2,
+		OnDrop: func(rec *TeeRecord) {
+			dropped++
+		},
+	})
+
+	var bodies []string
+	router := New()
+	router.Use(tee.Middleware)
+	router.POST("/events", func(w http.ResponseWriter, req Request) error {
+		b, err := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, string(b))
+		return err
+	})
+
+	// The sink is blocked on the first record, so two more fit into the queue
+	// and the last one is dropped.
+	for i, body := range []string{"abc", "hello world", "x", "dropped"} {
+		r, _ := newRequest("POST", "/events", strings.NewReader(body))
+		router.ServeHTTP(httptest.NewRecorder(), r)
+		if i == 0 {
+			<-started
+		}
+	}
+	close(block)
+	tee.Close()
+
+	if strings.Join(bodies, ",") != "abc,hello world,x,dropped" {
+		t.Errorf("handler got %q", bodies)
+	}
+	if dropped != 1 || len(records) != 3 {
+		t.Fatalf("got %d records, %d dropped", len(records), dropped)
+	}
+	if string(records[0].Body) != "abc" || records[0].Truncated || records[0].Route != "/events" {
+		t.Errorf("got %+v", records[0])
+	}
+	if string(records[1].Body) != "hello" || !records[1].Truncated {
+		t.Errorf("got %+v", records[1])
+	}
+}
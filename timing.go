@@ -0,0 +1,54 @@
+package treemux
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type timingKey struct {
+	name string
+}
+
+// timedMiddleware wraps a named middleware so the time spent in it is reported to
+// TreeMux.MiddlewareTimer. To exclude the time spent in the rest of the chain, the next
+// handler is wrapped too and its duration is passed back using the request context.
+func timedMiddleware(mux *TreeMux, name string, fn MiddlewareFunc) MiddlewareFunc {
+	key := &timingKey{name: name}
+	return func(next HandlerFunc) HandlerFunc {
+		inner := func(w http.ResponseWriter, req Request) error {
+			elapsed, _ := req.Context().Value(key).(*time.Duration)
+			if elapsed == nil {
+				return next(w, req)
+			}
+			start := mux.clock()
+			err := next(w, req)
+			*elapsed += mux.clock().Sub(start)
+			return err
+		}
+		handler := fn(inner)
+
+		return func(w http.ResponseWriter, req Request) error {
+			timer := mux.MiddlewareTimer
+			if timer == nil {
+				return handler(w, req)
+			}
+
+			elapsed := new(time.Duration)
+			req = req.WithContext(context.WithValue(req.Context(), key, elapsed))
+
+			start := mux.clock()
+			err := handler(w, req)
+			timer(req, name, mux.clock().Sub(start)-*elapsed)
+
+			return err
+		}
+	}
+}
+
+func (t *TreeMux) clock() time.Time {
+	if t.now != nil {
+		return t.now()
+	}
+	return time.Now()
+}
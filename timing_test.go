@@ -0,0 +1,59 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMiddlewareTimer(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	sleepy := func(d time.Duration) MiddlewareFunc {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(w http.ResponseWriter, req Request) error {
+				now = now.Add(d)
+				return next(w, req)
+			}
+		}
+	}
+
+	timings := make(map[string]time.Duration)
+	var order []string
+
+	router := New()
+	router.now = func() time.Time { return now }
+	router.MiddlewareTimer = func(req Request, name string, d time.Duration) {
+		timings[name] = d
+		order = append(order, name)
+	}
+	router.UseNamed("auth", sleepy(20*time.Millisecond))
+	router.Use(sleepy(5 * time.Millisecond))
+	router.UseNamed("ratelimit", sleepy(time.Millisecond))
+
+	var info *RouteInfo
+	router.GET("/slow", func(w http.ResponseWriter, req Request) error {
+		info = req.RouteInfo()
+		now = now.Add(100 * time.Millisecond)
+		return nil
+	})
+
+	r, _ := newRequest("GET", "/slow", nil)
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	if !reflect.DeepEqual(info.Middlewares, []string{"auth", "ratelimit"}) {
+		t.Errorf("got middlewares %v", info.Middlewares)
+	}
+	// Inner middlewares report first.
+	if !reflect.DeepEqual(order, []string{"ratelimit", "auth"}) {
+		t.Errorf("got timings order %v", order)
+	}
+	wanted := map[string]time.Duration{
+		"auth":      20 * time.Millisecond,
+		"ratelimit": time.Millisecond,
+	}
+	if !reflect.DeepEqual(timings, wanted) {
+		t.Errorf("got timings %v, wanted %v", timings, wanted)
+	}
+}
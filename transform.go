@@ -0,0 +1,128 @@
+package treemux
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+)
+
+// BufferedResponse is a complete response produced by a handler before it is sent to the client.
+type BufferedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// ResponseTransformer rewrites buffered responses, e.g. to redact fields,
+// wrap responses in an envelope, or convert the case of JSON keys.
+type ResponseTransformer interface {
+	Transform(req Request, resp *BufferedResponse) error
+}
+
+// ResponseTransformerFunc is an adapter to allow the use of ordinary functions
+// as ResponseTransformer.
+type ResponseTransformerFunc func(req Request, resp *BufferedResponse) error
+
+func (fn ResponseTransformerFunc) Transform(req Request, resp *BufferedResponse) error {
+	return fn(req, resp)
+}
+
+// Transform returns a middleware that buffers the response and passes it through
+// the transformers in order before sending it to the client. If the handler or
+// a transformer returns an error, the error is returned without writing the buffered
+// response so the router's ErrorHandler can respond instead.
+//
+//	api := router.NewGroup("/api")
+//	api.Use(treemux.Transform(envelope))
+func Transform(transformers ...ResponseTransformer) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			bw := newBufferedWriter(w)
+			if err := next(bw, req); err != nil {
+				return err
+			}
+
+			resp := bw.response()
+			for _, t := range transformers {
+				if err := t.Transform(req, resp); err != nil {
+					return err
+				}
+			}
+
+			return writeBufferedResponse(w, resp)
+		}
+	}
+}
+
+// bufferedWriter captures the response in memory.
+type bufferedWriter struct {
+	http.ResponseWriter
+
+	header     http.Header
+	statusCode int
+	buf        bytes.Buffer
+}
+
+var _ http.ResponseWriter = (*bufferedWriter)(nil)
+
+func newBufferedWriter(w http.ResponseWriter) *bufferedWriter {
+	header := make(http.Header, len(w.Header()))
+	for k, v := range w.Header() {
+		header[k] = v
+	}
+	return &bufferedWriter{
+		ResponseWriter: w,
+		header:         header,
+	}
+}
+
+func (w *bufferedWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	return w.buf.Write(b)
+}
+
+// Flush is a no-op because the response is buffered.
+func (w *bufferedWriter) Flush() {}
+
+func (w *bufferedWriter) response() *BufferedResponse {
+	statusCode := w.statusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	return &BufferedResponse{
+		StatusCode: statusCode,
+		Header:     w.header,
+		Body:       w.buf.Bytes(),
+	}
+}
+
+func writeBufferedResponse(w http.ResponseWriter, resp *BufferedResponse) error {
+	h := w.Header()
+	for k := range h {
+		if _, ok := resp.Header[k]; !ok {
+			delete(h, k)
+		}
+	}
+	for k, v := range resp.Header {
+		h[k] = v
+	}
+	if h.Get("Content-Length") != "" {
+		h.Set("Content-Length", strconv.Itoa(len(resp.Body)))
+	}
+
+	w.WriteHeader(resp.StatusCode)
+	_, err := w.Write(resp.Body)
+	return err
+}
@@ -0,0 +1,64 @@
+package treemux
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTransform(t *testing.T) {
+	envelope := ResponseTransformerFunc(func(req Request, resp *BufferedResponse) error {
+		resp.Body = append(append([]byte(`{"data":`), resp.Body...), '}')
+		return nil
+	})
+	status := ResponseTransformerFunc(func(req Request, resp *BufferedResponse) error {
+		if req.Param("id") == "fail" {
+			return errors.New("transform failed")
+		}
+		resp.Header.Set("X-Status", http.StatusText(resp.StatusCode))
+		resp.Header.Del("X-Internal")
+		return nil
+	})
+
+	var handled error
+	router := New()
+	router.ErrorHandler = func(w http.ResponseWriter, req Request, err error) {
+		handled = err
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	router.Use(func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			w.Header().Set("X-Outer", "1")
+			return next(w, req)
+		}
+	})
+	router.Use(Transform(envelope, status))
+	router.GET("/users/:id", func(w http.ResponseWriter, req Request) error {
+		w.Header().Set("X-Internal", "1")
+		w.Header().Set("Content-Length", "10")
+		w.WriteHeader(http.StatusCreated)
+		_, err := w.Write([]byte(`{"id":"1"}`))
+		return err
+	})
+
+	r, _ := newRequest("GET", "/users/1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusCreated || w.Body.String() != `{"data":{"id":"1"}}` {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+	h := w.Header()
+	if h.Get("X-Status") != "Created" || h.Get("X-Outer") != "1" || h.Get("X-Internal") != "" ||
+		h.Get("Content-Length") != "19" {
+		t.Errorf("unexpected headers %v", h)
+	}
+
+	r, _ = newRequest("GET", "/users/fail", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusInternalServerError || handled == nil || w.Body.Len() != 0 {
+		t.Errorf("got %d %q, error %v", w.Code, w.Body.String(), handled)
+	}
+}
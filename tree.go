@@ -4,9 +4,14 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 )
 
+// anyMethod is the method of the handlers that handle any method without a handler
+// of its own, see Group.HandlePattern.
+const anyMethod = "*"
+
 type handlerMap struct {
 	get     HandlerFunc
 	post    HandlerFunc
@@ -18,16 +23,70 @@ type handlerMap struct {
 
 	// If true, the head handler was set implicitly, so let it also be set explicitly.
 	implicitHead bool
+	// If true, the options handler was set implicitly by TreeMux.AutoOptions.
+	implicitOptions bool
 
-	m map[string]HandlerFunc
+	// m contains the handlers of nonstandard methods, which have no field.
+	m      map[string]HandlerFunc
+	routes map[string]*RouteInfo
 }
 
 func newHandlerMap() *handlerMap {
 	return new(handlerMap)
 }
 
+// Map returns a new map of all handlers keyed by method.
 func (h *handlerMap) Map() map[string]HandlerFunc {
-	return h.m
+	m := make(map[string]HandlerFunc, h.Len())
+	h.each(func(method string, handler HandlerFunc) {
+		m[method] = handler
+	})
+	return m
+}
+
+// Methods returns the sorted methods that have a handler.
+func (h *handlerMap) Methods() []string {
+	methods := make([]string, 0, h.Len())
+	h.each(func(method string, handler HandlerFunc) {
+		methods = append(methods, method)
+	})
+	sort.Strings(methods)
+	return methods
+}
+
+// Len returns the number of methods that have a handler. It is safe to call on a nil
+// handlerMap.
+func (h *handlerMap) Len() int {
+	if h == nil {
+		return 0
+	}
+	n := 0
+	h.each(func(string, HandlerFunc) {
+		n++
+	})
+	return n
+}
+
+func (h *handlerMap) each(fn func(method string, handler HandlerFunc)) {
+	for _, f := range [...]struct {
+		method  string
+		handler HandlerFunc
+	}{
+		{http.MethodGet, h.get},
+		{http.MethodPost, h.post},
+		{http.MethodPut, h.put},
+		{http.MethodDelete, h.delete},
+		{http.MethodHead, h.head},
+		{http.MethodOptions, h.options},
+		{http.MethodPatch, h.patch},
+	} {
+		if f.handler != nil {
+			fn(f.method, f.handler)
+		}
+	}
+	for method, handler := range h.m {
+		fn(method, handler)
+	}
 }
 
 func (h *handlerMap) Get(name string) HandlerFunc {
@@ -51,6 +110,14 @@ func (h *handlerMap) Get(name string) HandlerFunc {
 	}
 }
 
+// Lookup returns the handler of the method, falling back to the handler of any method.
+func (h *handlerMap) Lookup(name string) HandlerFunc {
+	if handler := h.Get(name); handler != nil {
+		return handler
+	}
+	return h.m[anyMethod]
+}
+
 func (h *handlerMap) Set(name string, handler HandlerFunc) {
 	switch name {
 	case http.MethodGet:
@@ -67,12 +134,51 @@ func (h *handlerMap) Set(name string, handler HandlerFunc) {
 		h.options = handler
 	case http.MethodPatch:
 		h.patch = handler
+	default:
+		if h.m == nil {
+			h.m = make(map[string]HandlerFunc)
+		}
+		h.m[name] = handler
+	}
+}
+
+// isImplicit reports whether the handler for the method was added implicitly
+// and can be replaced by an explicit one.
+func (h *handlerMap) isImplicit(name string) bool {
+	switch name {
+	case http.MethodHead:
+		return h.implicitHead
+	case http.MethodOptions:
+		return h.implicitOptions
+	default:
+		return false
 	}
+}
 
-	if h.m == nil {
-		h.m = make(map[string]HandlerFunc)
+// Route returns the route registered for the method, if any.
+func (h *handlerMap) Route(name string) *RouteInfo {
+	if route, ok := h.routes[name]; ok {
+		return route
+	}
+	return h.routes[anyMethod]
+}
+
+// anyRoute returns one of the registered routes. It is safe to call on a nil handlerMap.
+func (h *handlerMap) anyRoute() *RouteInfo {
+	if h == nil {
+		return nil
+	}
+	for _, route := range h.routes {
+		return route
 	}
-	h.m[name] = handler
+	return nil
+}
+
+func (h *handlerMap) SetRoute(name string, route *RouteInfo) {
+	if h.routes == nil {
+		h.routes = make(map[string]*RouteInfo)
+	}
+	h.routes[name] = route
 }
 
 type node struct {
@@ -84,6 +190,9 @@ type node struct {
 	// The list of static children to check.
 	staticIndices []byte
 	staticChild   []*node
+	// staticTable maps the first byte of the static children to their index plus one
+	// for nodes with at least wideNodeChildren children, which are too slow to scan.
+	staticTable *[256]uint16
 
 	// If none of the above match, check the wildcard children
 	wildcardChild *node
@@ -101,6 +210,38 @@ type node struct {
 
 	// The names of the parameters to apply.
 	leafWildcardNames []string
+
+	// staticRoutes is only set on the root node. It maps paths without the leading
+	// slash to the nodes of routes that have no params, so lookups of such routes
+	// don't need to walk the tree.
+	staticRoutes map[string]*node
+	// arena is only set on the root node when TreeMux.NodeArenaSize is set. The nodes
+	// added to the tree are allocated from it.
+	arena *nodeArena
+}
+
+// addStaticRoute registers the node of the route on the root node if the route
+// has no params or escapes.
+func (n *node) addStaticRoute(route string, leaf *node) {
+	for _, seg := range strings.Split(route, "/") {
+		if seg != "" && (seg[0] == ':' || seg[0] == '*' || seg[0] == '\\') {
+			return
+		}
+	}
+	if n.staticRoutes == nil {
+		n.staticRoutes = make(map[string]*node)
+	}
+	n.staticRoutes[route[1:]] = leaf
+}
+
+// lookupStatic returns the handler of the static route with the path.
+func (n *node) lookupStatic(method, path string) (*node, HandlerFunc) {
+	if leaf, ok := n.staticRoutes[path]; ok {
+		if handler := leaf.handlerMap.Lookup(method); handler != nil {
+			return leaf, handler
+		}
+	}
+	return nil, nil
 }
 
 func (n *node) paramName(i int) string {
@@ -111,25 +252,106 @@ func (n *node) sortStaticChild(i int) {
 	for i > 0 && n.staticChild[i].priority > n.staticChild[i-1].priority {
 		n.staticChild[i], n.staticChild[i-1] = n.staticChild[i-1], n.staticChild[i]
 		n.staticIndices[i], n.staticIndices[i-1] = n.staticIndices[i-1], n.staticIndices[i]
+		if n.staticTable != nil {
+			n.staticTable[n.staticIndices[i]] = uint16(i + 1)
+			n.staticTable[n.staticIndices[i-1]] = uint16(i)
+		}
 		i -= 1
 	}
 }
 
-func (n *node) setHandler(verb string, handler HandlerFunc, implicitHead bool) {
+// sortTree sorts the static children of the node and its descendants by priority,
+// like sortStaticChild does for every added route.
+func (n *node) sortTree() {
+	sort.Stable(byPriority{n})
+	if n.staticTable != nil {
+		for i, index := range n.staticIndices {
+			n.staticTable[index] = uint16(i + 1)
+		}
+	}
+	for _, child := range n.staticChild {
+		child.sortTree()
+	}
+	if n.wildcardChild != nil {
+		n.wildcardChild.sortTree()
+	}
+}
+
+// byPriority sorts the static children of the node by descending priority.
+type byPriority struct{ n *node }
+
+func (s byPriority) Len() int { return len(s.n.staticChild) }
+
+func (s byPriority) Less(i, j int) bool {
+	return s.n.staticChild[i].priority > s.n.staticChild[j].priority
+}
+
+func (s byPriority) Swap(i, j int) {
+	s.n.staticChild[i], s.n.staticChild[j] = s.n.staticChild[j], s.n.staticChild[i]
+	s.n.staticIndices[i], s.n.staticIndices[j] = s.n.staticIndices[j], s.n.staticIndices[i]
+}
+
+// wideNodeChildren is the number of static children from which a node indexes them
+// with staticTable instead of scanning staticIndices.
+const wideNodeChildren = 16
+
+// staticChildIndex returns the index of the static child starting with c or -1.
+func (n *node) staticChildIndex(c byte) int {
+	if n.staticTable != nil {
+		return int(n.staticTable[c]) - 1
+	}
+	for i, index := range n.staticIndices {
+		if index == c {
+			return i
+		}
+	}
+	return -1
+}
+
+func (n *node) addStaticChild(c byte, child *node) {
+	n.staticIndices = append(n.staticIndices, c)
+	n.staticChild = append(n.staticChild, child)
+
+	switch {
+	case n.staticTable != nil:
+		n.staticTable[c] = uint16(len(n.staticIndices))
+	case len(n.staticIndices) >= wideNodeChildren:
+		n.staticTable = new([256]uint16)
+		for i, index := range n.staticIndices {
+			n.staticTable[index] = uint16(i + 1)
+		}
+	}
+}
+
+func (n *node) setHandler(verb string, handler HandlerFunc, implicit bool) {
 	if n.handlerMap == nil {
 		n.handlerMap = newHandlerMap()
 	}
-	if h := n.handlerMap.Get(verb); h != nil &&
-		(verb != http.MethodHead || !n.handlerMap.implicitHead) {
-		panic(fmt.Sprintf("%s already handles %s", n.path, verb))
+	if h := n.handlerMap.Get(verb); h != nil && !n.handlerMap.isImplicit(verb) {
+		panic(fmt.Sprintf("%s already handles %s%s", n.path, verb, registeredAt(n.handlerMap.Route(verb))))
 	}
 	n.handlerMap.Set(verb, handler)
-	if verb == http.MethodHead {
-		n.handlerMap.implicitHead = implicitHead
+	switch verb {
+	case http.MethodHead:
+		n.handlerMap.implicitHead = implicit
+	case http.MethodOptions:
+		n.handlerMap.implicitOptions = implicit
 	}
 }
 
+// treeBuilder carries the registration options into the recursive addPath calls.
+type treeBuilder struct {
+	arena *nodeArena
+	// deferSort skips sorting the static children by priority. The tree must be
+	// sorted with sortTree afterwards.
+	deferSort bool
+}
+
 func (n *node) addPath(path string, wildcards []string, inStaticToken bool) *node {
+	return n.addPathWith(treeBuilder{arena: n.arena}, path, wildcards, inStaticToken)
+}
+
+func (n *node) addPathWith(b treeBuilder, path string, wildcards []string, inStaticToken bool) *node {
 	leaf := len(path) == 0
 	if leaf {
 		if wildcards != nil {
@@ -178,7 +400,9 @@ func (n *node) addPath(path string, wildcards []string, inStaticToken bool) *nod
 		// Token starts with a *, so it's a catch-all
 		thisToken = thisToken[1:]
 		if n.catchAllChild == nil {
-			n.catchAllChild = &node{path: thisToken, isCatchAll: true}
+			n.catchAllChild = b.arena.newNode()
+			n.catchAllChild.path = thisToken
+			n.catchAllChild.isCatchAll = true
 		}
 
 		if path[1:] != n.catchAllChild.path {
@@ -211,10 +435,11 @@ func (n *node) addPath(path string, wildcards []string, inStaticToken bool) *nod
 		}
 
 		if n.wildcardChild == nil {
-			n.wildcardChild = &node{path: "wildcard"}
+			n.wildcardChild = b.arena.newNode()
+			n.wildcardChild.path = "wildcard"
 		}
 
-		return n.wildcardChild.addPath(remainingPath, wildcards, false)
+		return n.wildcardChild.addPathWith(b, remainingPath, wildcards, false)
 	}
 
 	// if strings.ContainsAny(thisToken, ":*") {
@@ -237,36 +462,30 @@ func (n *node) addPath(path string, wildcards []string, inStaticToken bool) *nod
 	inStaticToken = (c != '/')
 
 	// Do we have an existing node that starts with the same letter?
-	for i, index := range n.staticIndices {
-		if c == index {
-			// Yes. Split it based on the common prefix of the existing
-			// node and the new one.
-			child, prefixSplit := n.splitCommonPrefix(i, thisToken)
+	if i := n.staticChildIndex(c); i != -1 {
+		// Yes. Split it based on the common prefix of the existing
+		// node and the new one.
+		child, prefixSplit := n.splitCommonPrefix(b.arena, i, thisToken)
 
-			child.priority++
+		child.priority++
+		if !b.deferSort {
 			n.sortStaticChild(i)
-			if unescaped {
-				// Account for the removed backslash.
-				prefixSplit++
-			}
-			return child.addPath(path[prefixSplit:], wildcards, inStaticToken)
 		}
+		if unescaped {
+			// Account for the removed backslash.
+			prefixSplit++
+		}
+		return child.addPathWith(b, path[prefixSplit:], wildcards, inStaticToken)
 	}
 
 	// No existing node starting with this letter, so create it.
-	child := &node{path: thisToken}
-
-	if n.staticIndices == nil {
-		n.staticIndices = []byte{c}
-		n.staticChild = []*node{child}
-	} else {
-		n.staticIndices = append(n.staticIndices, c)
-		n.staticChild = append(n.staticChild, child)
-	}
-	return child.addPath(remainingPath, wildcards, inStaticToken)
+	child := b.arena.newNode()
+	child.path = thisToken
+	n.addStaticChild(c, child)
+	return child.addPathWith(b, remainingPath, wildcards, inStaticToken)
 }
 
-func (n *node) splitCommonPrefix(existingNodeIndex int, path string) (*node, int) {
+func (n *node) splitCommonPrefix(a *nodeArena, existingNodeIndex int, path string) (*node, int) {
 	childNode := n.staticChild[existingNodeIndex]
 
 	if strings.HasPrefix(path, childNode.path) {
@@ -294,13 +513,12 @@ func (n *node) splitCommonPrefix(existingNodeIndex int, path string) (*node, int
 
 	// Create a new intermediary node in the place of the existing node, with
 	// the existing node as a child.
-	newNode := &node{
-		path:     commonPrefix,
-		priority: childNode.priority,
-		// Index is the first letter of the non-common part of the path.
-		staticIndices: []byte{childNode.path[0]},
-		staticChild:   []*node{childNode},
-	}
+	newNode := a.newNode()
+	newNode.path = commonPrefix
+	newNode.priority = childNode.priority
+	// Index is the first letter of the non-common part of the path.
+	newNode.staticIndices = []byte{childNode.path[0]}
+	newNode.staticChild = []*node{childNode}
 	n.staticChild[existingNodeIndex] = newNode
 
 	return newNode, i
@@ -315,20 +533,16 @@ func (n *node) search(method, path string) (found *node, handler HandlerFunc, pa
 		if n.handlerMap == nil {
 			return nil, nil, nil
 		}
-		return n, n.handlerMap.Get(method), nil
+		return n, n.handlerMap.Lookup(method), nil
 	}
 
 	// First see if this matches a static token.
-	firstChar := path[0]
-	for i, staticIndex := range n.staticIndices {
-		if staticIndex == firstChar {
-			child := n.staticChild[i]
-			childPathLen := len(child.path)
-			if pathLen >= childPathLen && child.path == path[:childPathLen] {
-				nextPath := path[childPathLen:]
-				found, handler, params = child.search(method, nextPath)
-			}
-			break
+	if i := n.staticChildIndex(path[0]); i != -1 {
+		child := n.staticChild[i]
+		childPathLen := len(child.path)
+		if pathLen >= childPathLen && child.path == path[:childPathLen] {
+			nextPath := path[childPathLen:]
+			found, handler, params = child.search(method, nextPath)
 		}
 	}
 
@@ -357,10 +571,10 @@ func (n *node) search(method, path string) (found *node, handler HandlerFunc, pa
 				}
 
 				if wcParams == nil {
-					wcParams = []Param{{
+					wcParams = newParams(wcNode, Param{
 						Name:  wcNode.paramName(0),
 						Value: unescaped,
-					}}
+					})
 				} else {
 					wcParams = append(wcParams, Param{
 						Name:  wcNode.paramName(len(wcParams)),
@@ -386,7 +600,7 @@ func (n *node) search(method, path string) (found *node, handler HandlerFunc, pa
 	if catchAllChild != nil {
 		// Hit the catchall, so just assign the whole remaining path if it
 		// has a matching handler.
-		handler = catchAllChild.handlerMap.Get(method)
+		handler = catchAllChild.handlerMap.Lookup(method)
 		// Found a handler, or we found a catchall node without a handler.
 		// Either way, return it since there's nothing left to check after this.
 		if handler != nil || found == nil {
@@ -395,10 +609,10 @@ func (n *node) search(method, path string) (found *node, handler HandlerFunc, pa
 				unescaped = path
 			}
 
-			return catchAllChild, handler, []Param{{
+			return catchAllChild, handler, newParams(catchAllChild, Param{
 				Name:  catchAllChild.paramName(0),
 				Value: unescaped,
-			}}
+			})
 		}
 
 	}
@@ -406,8 +620,56 @@ func (n *node) search(method, path string) (found *node, handler HandlerFunc, pa
 	return found, handler, params
 }
 
+// eachMatch calls fn for every route node matching the path in the order search checks
+// them: the static match, then the wildcard and catch-all matches.
+func (n *node) eachMatch(path string, fn func(n *node)) {
+	if len(path) == 0 {
+		if n.handlerMap != nil {
+			fn(n)
+		}
+		return
+	}
+
+	if i := n.staticChildIndex(path[0]); i != -1 {
+		child := n.staticChild[i]
+		if strings.HasPrefix(path, child.path) {
+			child.eachMatch(path[len(child.path):], fn)
+		}
+	}
+
+	if n.wildcardChild != nil {
+		nextSlash := strings.IndexByte(path, '/')
+		if nextSlash < 0 {
+			nextSlash = len(path)
+		}
+		if nextSlash > 0 {
+			n.wildcardChild.eachMatch(path[nextSlash:], fn)
+		}
+	}
+
+	if n.catchAllChild != nil && n.catchAllChild.handlerMap != nil {
+		fn(n.catchAllChild)
+	}
+}
+
+// newParams returns the params found at the leaf node with the capacity for all params
+// of the route, so the params of the outer segments are appended without growing it.
+func newParams(leaf *node, p Param) []Param {
+	size := len(leaf.leafWildcardNames)
+	if size == 0 {
+		size = 1
+	}
+	params := make([]Param, 1, size)
+	params[0] = p
+	return params
+}
+
 func (n *node) dumpTree(prefix, nodeType string) string {
-	line := fmt.Sprintf("%s %02d %s%s [%d] %v wildcards %v\n", prefix, n.priority, nodeType, n.path,
+	path := n.path
+	if nodeType == "" {
+		path = escapeRouteLiteral(path)
+	}
+	line := fmt.Sprintf("%s %02d %s%s [%d] %v wildcards %v\n", prefix, n.priority, nodeType, path,
 		len(n.staticChild), n.handlerMap, n.leafWildcardNames)
 	prefix += "  "
 	for _, node := range n.staticChild {
@@ -421,3 +683,17 @@ func (n *node) dumpTree(prefix, nodeType string) string {
 	}
 	return line
 }
+
+// walk calls fn for the node and all its descendants. Depth is the distance from the root.
+func (n *node) walk(depth int, fn func(n *node, depth int)) {
+	fn(n, depth)
+	for _, child := range n.staticChild {
+		child.walk(depth+1, fn)
+	}
+	if n.wildcardChild != nil {
+		n.wildcardChild.walk(depth+1, fn)
+	}
+	if n.catchAllChild != nil {
+		n.catchAllChild.walk(depth+1, fn)
+	}
+}
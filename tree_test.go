@@ -2,6 +2,8 @@ package treemux
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -375,3 +377,141 @@ func BenchmarkTreeLongParams(b *testing.B) {
 		tree.search("GET", "abcdefghijklmnop/aaaabbbbccccddddeeeeffffgggg/hijkl")
 	}
 }
+
+// BenchmarkTreeLargeTable reports the memory used by a table of 10000 routes
+// with two methods each in B/op.
+func BenchmarkTreeLargeTable(b *testing.B) {
+	b.ReportAllocs()
+	paths := make([]string, 10000)
+	for i := range paths {
+		paths[i] = "/api/v1/resource" + strconv.Itoa(i) + "/:id"
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		router := New()
+		for _, path := range paths {
+			router.GET(path, dummyHandler)
+			router.PUT(path, dummyHandler)
+		}
+	}
+}
+
+func BenchmarkTreeLargeTableArena(b *testing.B) {
+	b.ReportAllocs()
+	paths := make([]string, 10000)
+	for i := range paths {
+		paths[i] = "/api/v1/resource" + strconv.Itoa(i) + "/:id"
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		router := New()
+		router.NodeArenaSize = 1024
+		for _, path := range paths {
+			router.GET(path, dummyHandler)
+			router.PUT(path, dummyHandler)
+		}
+	}
+}
+
+func TestNodeArena(t *testing.T) {
+	router := New()
+	router.NodeArenaSize = 4
+	router.SafeAddRoutesWhileRunning = true
+	for i := 0; i < 10; i++ {
+		router.GET("/resource"+strconv.Itoa(i)+"/:id", simpleHandler)
+	}
+	if router.root.arena == nil {
+		t.Fatal("the tree has no arena")
+	}
+
+	lookup := func(path string) bool {
+		r, _ := newRequest("GET", path, nil)
+		_, found := router.Lookup(nil, r)
+		return found
+	}
+	for i := 0; i < 10; i++ {
+		if !lookup("/resource" + strconv.Itoa(i) + "/1") {
+			t.Errorf("resource%d was not found", i)
+		}
+	}
+
+	old := router.root.arena
+	err := router.Reload(func(g *Group) {
+		g.GET("/users/:id", simpleHandler)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if router.root.arena == nil || router.root.arena == old {
+		t.Error("reload did not allocate a new arena")
+	}
+	if !lookup("/users/1") || lookup("/resource1/1") {
+		t.Error("reloaded table is not served")
+	}
+}
+
+func TestHandlerMapMethods(t *testing.T) {
+	h := newHandlerMap()
+	for _, method := range []string{"PUT", "GET", "PROPFIND"} {
+		h.Set(method, dummyHandler)
+	}
+
+	if len(h.m) != 1 || h.m["PROPFIND"] == nil {
+		t.Errorf("got nonstandard handlers %v, wanted only PROPFIND", h.m)
+	}
+	if got := strings.Join(h.Methods(), " "); got != "GET PROPFIND PUT" {
+		t.Errorf("got methods %q", got)
+	}
+	if h.Len() != 3 || len(h.Map()) != 3 {
+		t.Errorf("got %d methods and a map of %d", h.Len(), len(h.Map()))
+	}
+	if h.Get("PROPFIND") == nil || h.Get("POST") != nil {
+		t.Error("unexpected handlers")
+	}
+}
+
+// BenchmarkTreeWideNode looks up a route under a node with 62 static children,
+// like the top level of the GitHub API.
+func BenchmarkTreeWideNode(b *testing.B) {
+	const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	router := New()
+	for i := 0; i < len(chars); i++ {
+		router.GET("/"+chars[i:i+1]+"resource/:id", dummyHandler)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		router.root.search("GET", "9resource/1")
+	}
+}
+
+func TestTreeWideNode(t *testing.T) {
+	const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	tree := &node{path: "/"}
+	for i := 0; i < len(chars); i++ {
+		addPath(t, tree, "/"+chars[i:i+1]+"x/:id")
+		// Raise the priority of later children so they are moved to the front.
+		for j := 0; j < i%3; j++ {
+			addPath(t, tree, "/"+chars[i:i+1]+"y"+strconv.Itoa(j))
+		}
+	}
+	if tree.staticTable == nil {
+		t.Fatal("wide node has no static table")
+	}
+	for i, c := range tree.staticIndices {
+		if int(tree.staticTable[c]) != i+1 {
+			t.Errorf("static table maps %q to %d, wanted %d", c, tree.staticTable[c], i+1)
+		}
+	}
+
+	for i := 0; i < len(chars); i++ {
+		testPath(t, tree, "/"+chars[i:i+1]+"x/1", "/"+chars[i:i+1]+"x/:id", map[string]string{"id": "1"})
+		if i%3 == 2 {
+			testPath(t, tree, "/"+chars[i:i+1]+"y1", "/"+chars[i:i+1]+"y1", nil)
+		}
+	}
+	testPath(t, tree, "/!x/1", "", nil)
+}
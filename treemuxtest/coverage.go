@@ -0,0 +1,98 @@
+package treemuxtest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/vmihailenco/treemux"
+)
+
+var coverage = struct {
+	sync.Mutex
+	hits map[*treemux.TreeMux]map[*treemux.RouteInfo]int
+}{
+	hits: make(map[*treemux.TreeMux]map[*treemux.RouteInfo]int),
+}
+
+// Cover returns a handler that serves requests with the router and records the routes
+// that were hit, so RouteCoverage can report routes not exercised by the tests.
+// Use it in place of the router in tests:
+//
+//	srv := httptest.NewServer(treemuxtest.Cover(router))
+func Cover(mux *treemux.TreeMux) http.Handler {
+	coverage.Lock()
+	if coverage.hits[mux] == nil {
+		coverage.hits[mux] = make(map[*treemux.RouteInfo]int)
+	}
+	coverage.Unlock()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lr, _ := mux.Lookup(w, r)
+		if info := lr.RouteInfo(); info != nil {
+			coverage.Lock()
+			coverage.hits[mux][info]++
+			coverage.Unlock()
+		}
+		mux.ServeLookupResult(w, r, lr)
+	})
+}
+
+// RouteCoverage returns the fraction of the registered routes that served requests
+// through Cover and reports an error listing the uncovered routes if the fraction
+// is below the threshold, e.g. 0.8. It is usually called at the end of an integration
+// test; use CheckRouteCoverage in TestMain.
+func RouteCoverage(t TestingT, mux *treemux.TreeMux, threshold float64) float64 {
+	t.Helper()
+
+	covered, err := CheckRouteCoverage(mux, threshold)
+	if err != nil {
+		t.Errorf("%s", err)
+	}
+	return covered
+}
+
+// CheckRouteCoverage is like RouteCoverage, but returns the error, so it can be called
+// from TestMain, which has no TestingT:
+//
+//	func TestMain(m *testing.M) {
+//		code := m.Run()
+//		if _, err := treemuxtest.CheckRouteCoverage(router, 0.8); err != nil && code == 0 {
+//			fmt.Println(err)
+//			code = 1
+//		}
+//		os.Exit(code)
+//	}
+func CheckRouteCoverage(mux *treemux.TreeMux, threshold float64) (float64, error) {
+	routes := mux.Routes()
+	if len(routes) == 0 {
+		return 1, nil
+	}
+
+	coverage.Lock()
+	hits := coverage.hits[mux]
+	var uncovered []string
+	for _, info := range routes {
+		if hits[info] == 0 {
+			uncovered = append(uncovered, info.Method+" "+info.Route)
+		}
+	}
+	coverage.Unlock()
+
+	covered := float64(len(routes)-len(uncovered)) / float64(len(routes))
+	if covered < threshold {
+		return covered, fmt.Errorf("route coverage %.1f%% is below %.1f%%, uncovered routes:\n\t%s",
+			100*covered, 100*threshold, strings.Join(uncovered, "\n\t"))
+	}
+	return covered, nil
+}
+
+// ResetCoverage forgets the routes recorded for the router.
+func ResetCoverage(mux *treemux.TreeMux) {
+	coverage.Lock()
+	defer coverage.Unlock()
+	if coverage.hits[mux] != nil {
+		coverage.hits[mux] = make(map[*treemux.RouteInfo]int)
+	}
+}
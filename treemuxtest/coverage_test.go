@@ -0,0 +1,52 @@
+package treemuxtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vmihailenco/treemux"
+)
+
+func TestRouteCoverage(t *testing.T) {
+	handler := func(w http.ResponseWriter, req treemux.Request) error { return nil }
+	router := treemux.New()
+	router.GET("/users", handler)
+	router.GET("/users/:id", handler)
+	router.POST("/users", handler)
+	router.DELETE("/users/:id", handler)
+
+	h := Cover(router)
+	for _, req := range []struct{ method, path string }{
+		{"GET", "/users/1"},
+		{"HEAD", "/users"},
+		{"POST", "/users"},
+		{"PUT", "/users"},
+	} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	rt := new(recordingT)
+	if covered := RouteCoverage(rt, router, 0.75); covered != 0.75 {
+		t.Errorf("got coverage %v, wanted 0.75", covered)
+	}
+	if len(rt.errors) != 0 {
+		t.Fatalf("got errors %q", rt.errors)
+	}
+
+	RouteCoverage(rt, router, 0.9)
+	if len(rt.errors) != 1 || !strings.Contains(rt.errors[0], "DELETE /users/:id") {
+		t.Fatalf("got errors %q", rt.errors)
+	}
+
+	if covered, err := CheckRouteCoverage(router, 0.9); covered != 0.75 || err == nil ||
+		!strings.Contains(err.Error(), "DELETE /users/:id") {
+		t.Errorf("got %v %v", covered, err)
+	}
+
+	ResetCoverage(router)
+	if covered := RouteCoverage(rt, router, 0); covered != 0 {
+		t.Errorf("got coverage %v after reset", covered)
+	}
+}
@@ -0,0 +1,38 @@
+package treemuxtest
+
+import (
+	"net/http/httptest"
+	"strings"
+
+	"github.com/vmihailenco/treemux"
+)
+
+// AssertMiddleware checks that the route serving the method and path is wrapped with
+// the named middlewares in the given order, from the outermost to the innermost.
+// Middlewares must be added with Group.UseNamed. Other middlewares may appear between
+// the expected ones. It turns security review into a test:
+//
+//	treemuxtest.AssertMiddleware(t, router, "GET", "/admin", "auth", "audit")
+func AssertMiddleware(t TestingT, mux *treemux.TreeMux, method, path string, names ...string) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, nil)
+	lr, _ := mux.Lookup(httptest.NewRecorder(), req)
+	info := lr.RouteInfo()
+	if info == nil {
+		t.Errorf("%s %s: route not found (status %d)", method, path, lr.StatusCode)
+		return
+	}
+
+	chain := info.Middlewares
+	i := 0
+	for _, name := range chain {
+		if i < len(names) && name == names[i] {
+			i++
+		}
+	}
+	if i < len(names) {
+		t.Errorf("%s %s (route %s): middleware %q is missing or out of order in chain [%s]",
+			method, path, info.Route, names[i], strings.Join(chain, ", "))
+	}
+}
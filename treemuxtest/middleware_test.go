@@ -0,0 +1,46 @@
+package treemuxtest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/vmihailenco/treemux"
+)
+
+func TestAssertMiddleware(t *testing.T) {
+	noop := func(next treemux.HandlerFunc) treemux.HandlerFunc { return next }
+	handler := func(w http.ResponseWriter, req treemux.Request) error { return nil }
+
+	router := treemux.New()
+	router.UseNamed("recover", noop)
+	admin := router.NewGroup("/admin")
+	admin.UseNamed("auth", noop)
+	admin.Use(noop)
+	admin.UseNamed("audit", noop)
+	admin.GET("", handler)
+	router.GET("/public", handler)
+
+	rt := new(recordingT)
+	AssertMiddleware(rt, router, "GET", "/admin", "auth", "audit")
+	AssertMiddleware(rt, router, "GET", "/admin", "recover", "audit")
+	if len(rt.errors) != 0 {
+		t.Fatalf("got errors %q", rt.errors)
+	}
+
+	AssertMiddleware(rt, router, "GET", "/admin", "audit", "auth")
+	AssertMiddleware(rt, router, "GET", "/public", "auth")
+	AssertMiddleware(rt, router, "GET", "/missing", "auth")
+	if len(rt.errors) != 3 {
+		t.Fatalf("got errors %q", rt.errors)
+	}
+	for i, want := range []string{
+		`middleware "auth" is missing or out of order in chain [recover, auth, audit]`,
+		`middleware "auth" is missing or out of order in chain [recover]`,
+		"route not found (status 404)",
+	} {
+		if !strings.Contains(rt.errors[i], want) {
+			t.Errorf("got error %q, wanted %q", rt.errors[i], want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Package treemuxtest provides utilities for testing treemux routers.
+package treemuxtest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/vmihailenco/treemux"
+)
+
+// TestingT is the subset of testing.TB used by the helpers.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// ReadExchanges reads exchanges encoded as JSON lines, e.g. saved by a treemux.CaptureStore
+// that writes json.Marshal(ex) followed by a newline.
+func ReadExchanges(r io.Reader) ([]*treemux.Exchange, error) {
+	var exchanges []*treemux.Exchange
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for {
+		ex := new(treemux.Exchange)
+		if err := dec.Decode(ex); err != nil {
+			if err == io.EOF {
+				return exchanges, nil
+			}
+			return nil, err
+		}
+		exchanges = append(exchanges, ex)
+	}
+}
+
+// Replay sends the recorded requests to the handler and reports responses
+// that have a different status code or body than the recorded ones.
+func Replay(t TestingT, handler http.Handler, exchanges ...*treemux.Exchange) {
+	t.Helper()
+
+	for _, ex := range exchanges {
+		req := httptest.NewRequest(ex.Method, ex.RequestURI, bytes.NewReader(ex.Body))
+		for name, values := range ex.Header {
+			req.Header[name] = values
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != ex.StatusCode {
+			t.Errorf("%s %s (route %s): got status %d, wanted %d",
+				ex.Method, ex.RequestURI, ex.Route, w.Code, ex.StatusCode)
+			continue
+		}
+		if !bytes.Equal(w.Body.Bytes(), ex.ResponseBody) {
+			t.Errorf("%s %s (route %s): got body %q, wanted %q",
+				ex.Method, ex.RequestURI, ex.Route, w.Body.Bytes(), ex.ResponseBody)
+		}
+	}
+}
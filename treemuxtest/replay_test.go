@@ -0,0 +1,50 @@
+package treemuxtest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/vmihailenco/treemux"
+)
+
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestReplay(t *testing.T) {
+	router := treemux.New()
+	router.GET("/users/:id", func(w http.ResponseWriter, req treemux.Request) error {
+		_, err := w.Write([]byte("user " + req.Param("id")))
+		return err
+	})
+
+	exchanges, err := ReadExchanges(strings.NewReader(`
+{"method": "GET", "route": "/users/:id", "request_uri": "/users/1", "status_code": 200, "response_body": "dXNlciAx"}
+{"method": "GET", "route": "/users/:id", "request_uri": "/users/2", "status_code": 200, "response_body": "dXNlciAx"}
+{"method": "GET", "route": "/missing", "request_uri": "/missing", "status_code": 200}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rt := new(recordingT)
+	Replay(rt, router, exchanges...)
+
+	if len(rt.errors) != 2 {
+		t.Fatalf("got errors %q", rt.errors)
+	}
+	if !strings.Contains(rt.errors[0], `got body "user 2"`) {
+		t.Errorf("unexpected error %q", rt.errors[0])
+	}
+	if !strings.Contains(rt.errors[1], "got status 404") {
+		t.Errorf("unexpected error %q", rt.errors[1])
+	}
+}
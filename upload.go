@@ -0,0 +1,239 @@
+package treemux
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// TusVersion is the version of the tus resumable upload protocol implemented by MountUploads.
+const TusVersion = "1.0.0"
+
+// ErrUploadNotFound is returned by UploadStore when the upload does not exist.
+var ErrUploadNotFound = errors.New("treemux: upload not found")
+
+// UploadStore stores resumable uploads.
+type UploadStore interface {
+	// Create creates a new upload of the given length and returns its id.
+	Create(ctx context.Context, length int64) (id string, err error)
+	// Offset returns the number of bytes received so far and the upload length.
+	Offset(ctx context.Context, id string) (offset, length int64, err error)
+	// Append appends the data at the offset, which is the current upload offset,
+	// and returns the number of bytes written. Partially written data must be kept
+	// so the client can resume the upload.
+	Append(ctx context.Context, id string, offset int64, r io.Reader) (int64, error)
+}
+
+// UploadConfig configures resumable uploads.
+type UploadConfig struct {
+	Store UploadStore
+	// MaxSize is the maximum upload length. Zero means no limit.
+	MaxSize int64
+	// OnComplete is called when all bytes of the upload have been received.
+	// An error fails the request that completed the upload.
+	OnComplete func(req Request, id string) error
+}
+
+// MountUploads registers endpoints for resumable uploads following the core tus protocol:
+//
+//	POST  <path>      creates an upload with the length from the Upload-Length header
+//	HEAD  <path>/:id  returns the current Upload-Offset
+//	PATCH <path>/:id  appends application/offset+octet-stream data at Upload-Offset
+//
+// Interrupted uploads are resumed by asking for the offset with HEAD and
+// sending the remaining bytes with PATCH.
+func (g *Group) MountUploads(path string, cfg UploadConfig) {
+	u := &uploads{cfg: cfg}
+	g.POST(path, u.create)
+	g.HEAD(path+"/:id", u.offset)
+	g.PATCH(path+"/:id", u.patch)
+}
+
+type uploads struct {
+	cfg UploadConfig
+}
+
+func (u *uploads) checkVersion(w http.ResponseWriter, req Request) bool {
+	w.Header().Set("Tus-Resumable", TusVersion)
+	if v := req.Header.Get("Tus-Resumable"); v != "" && v != TusVersion {
+		w.Header().Set("Tus-Version", TusVersion)
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return false
+	}
+	return true
+}
+
+func (u *uploads) create(w http.ResponseWriter, req Request) error {
+	if !u.checkVersion(w, req) {
+		return nil
+	}
+
+	length, err := strconv.ParseInt(req.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		http.Error(w, "invalid Upload-Length", http.StatusBadRequest)
+		return nil
+	}
+	if u.cfg.MaxSize > 0 && length > u.cfg.MaxSize {
+		w.Header().Set("Tus-Max-Size", strconv.FormatInt(u.cfg.MaxSize, 10))
+		http.Error(w, ErrBodyTooLarge.Error(), http.StatusRequestEntityTooLarge)
+		return nil
+	}
+
+	id, err := u.cfg.Store.Create(req.Context(), length)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", req.URL.Path+"/"+id)
+	w.Header().Set("Upload-Offset", "0")
+	w.WriteHeader(http.StatusCreated)
+
+	if length == 0 && u.cfg.OnComplete != nil {
+		return u.cfg.OnComplete(req, id)
+	}
+	return nil
+}
+
+func (u *uploads) offset(w http.ResponseWriter, req Request) error {
+	if !u.checkVersion(w, req) {
+		return nil
+	}
+
+	offset, length, err := u.cfg.Store.Offset(req.Context(), req.Param("id"))
+	if err == ErrUploadNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	h := w.Header()
+	h.Set("Upload-Offset", strconv.FormatInt(offset, 10))
+	h.Set("Upload-Length", strconv.FormatInt(length, 10))
+	h.Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (u *uploads) patch(w http.ResponseWriter, req Request) error {
+	if !u.checkVersion(w, req) {
+		return nil
+	}
+	if req.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return nil
+	}
+
+	id := req.Param("id")
+	offset, length, err := u.cfg.Store.Offset(req.Context(), id)
+	if err == ErrUploadNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	clientOffset, err := strconv.ParseInt(req.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid Upload-Offset", http.StatusBadRequest)
+		return nil
+	}
+	if clientOffset != offset {
+		w.Header().Set("Upload-Offset", strconv.FormatInt(offset, 10))
+		w.WriteHeader(http.StatusConflict)
+		return nil
+	}
+
+	// Don't accept more bytes than the declared length.
+	n, err := u.cfg.Store.Append(req.Context(), id, offset, io.LimitReader(req.Body, length-offset))
+	offset += n
+	w.Header().Set("Upload-Offset", strconv.FormatInt(offset, 10))
+	if err != nil {
+		return err
+	}
+
+	if offset == length && u.cfg.OnComplete != nil {
+		if err := u.cfg.OnComplete(req, id); err != nil {
+			return err
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// MemoryUploadStore is an UploadStore that keeps uploads in memory.
+// It is meant for tests and development.
+type MemoryUploadStore struct {
+	mu      sync.Mutex
+	uploads map[string]*memoryUpload
+}
+
+type memoryUpload struct {
+	length int64
+	data   bytes.Buffer
+}
+
+var _ UploadStore = (*MemoryUploadStore)(nil)
+
+func NewMemoryUploadStore() *MemoryUploadStore {
+	return &MemoryUploadStore{
+		uploads: make(map[string]*memoryUpload),
+	}
+}
+
+func (s *MemoryUploadStore) Create(ctx context.Context, length int64) (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(b[:])
+
+	s.mu.Lock()
+	s.uploads[id] = &memoryUpload{length: length}
+	s.mu.Unlock()
+	return id, nil
+}
+
+func (s *MemoryUploadStore) Offset(ctx context.Context, id string) (int64, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	upload, ok := s.uploads[id]
+	if !ok {
+		return 0, 0, ErrUploadNotFound
+	}
+	return int64(upload.data.Len()), upload.length, nil
+}
+
+func (s *MemoryUploadStore) Append(ctx context.Context, id string, offset int64, r io.Reader) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	upload, ok := s.uploads[id]
+	if !ok {
+		return 0, ErrUploadNotFound
+	}
+	if offset != int64(upload.data.Len()) {
+		return 0, errors.New("treemux: upload offset mismatch")
+	}
+	return upload.data.ReadFrom(r)
+}
+
+// Bytes returns the data received so far.
+func (s *MemoryUploadStore) Bytes(id string) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if upload, ok := s.uploads[id]; ok {
+		return upload.data.Bytes()
+	}
+	return nil
+}
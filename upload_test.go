@@ -0,0 +1,98 @@
+package treemux
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type failingReader struct {
+	r io.Reader
+}
+
+func (r *failingReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	if err == io.EOF {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+func TestMountUploads(t *testing.T) {
+	store := NewMemoryUploadStore()
+	var completed string
+
+	router := New()
+	router.ErrorHandler = func(w http.ResponseWriter, req Request, err error) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	router.NewGroup("/api").MountUploads("/files", UploadConfig{
+		Store:   store,
+		MaxSize: 100,
+		OnComplete: func(req Request, id string) error {
+			completed = id
+			return nil
+		},
+	})
+
+	serve := func(method, path string, body io.Reader, headers map[string]string) *httptest.ResponseRecorder {
+		r, _ := newRequest(method, path, body)
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := serve("POST", "/api/files", nil, map[string]string{"Upload-Length": "1000"}); w.Code != 413 {
+		t.Errorf("got %d for a large upload", w.Code)
+	}
+
+	w := serve("POST", "/api/files", nil, map[string]string{"Upload-Length": "11", "Tus-Resumable": "1.0.0"})
+	location := w.Header().Get("Location")
+	if w.Code != http.StatusCreated || !strings.HasPrefix(location, "/api/files/") {
+		t.Fatalf("got %d %v", w.Code, w.Header())
+	}
+	id := strings.TrimPrefix(location, "/api/files/")
+
+	patch := func(offset string, body io.Reader) *httptest.ResponseRecorder {
+		return serve("PATCH", location, body, map[string]string{
+			"Content-Type":  "application/offset+octet-stream",
+			"Upload-Offset": offset,
+		})
+	}
+
+	// The connection drops after some bytes were received.
+	w = patch("0", &failingReader{strings.NewReader("hello")})
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("got %d for an interrupted upload", w.Code)
+	}
+
+	w = serve("HEAD", location, nil, nil)
+	if w.Code != http.StatusOK || w.Header().Get("Upload-Offset") != "5" || w.Header().Get("Upload-Length") != "11" {
+		t.Errorf("HEAD: got %d %v", w.Code, w.Header())
+	}
+
+	if w := patch("0", strings.NewReader("hello world")); w.Code != http.StatusConflict {
+		t.Errorf("got %d for a wrong offset", w.Code)
+	}
+
+	w = patch("5", strings.NewReader(" world and more"))
+	if w.Code != http.StatusNoContent || w.Header().Get("Upload-Offset") != "11" {
+		t.Errorf("PATCH: got %d %v", w.Code, w.Header())
+	}
+	if string(store.Bytes(id)) != "hello world" || completed != id {
+		t.Errorf("got %q, completed %q", store.Bytes(id), completed)
+	}
+
+	if w := serve("HEAD", "/api/files/missing", nil, nil); w.Code != http.StatusNotFound {
+		t.Errorf("got %d for a missing upload", w.Code)
+	}
+	if w := serve("HEAD", location, nil, map[string]string{"Tus-Resumable": "0.2.0"}); w.Code != 412 {
+		t.Errorf("got %d for an unsupported version", w.Code)
+	}
+}
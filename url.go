@@ -0,0 +1,72 @@
+package treemux
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Name names the route so URLs can be generated for it with TreeMux.URL.
+// Names must be unique within the router.
+//
+//	router.GET("/users/:id", getUser).Name("user")
+//	url, err := router.URL("user", map[string]string{"id": "42"}) // "/users/42"
+func (r *Route) Name(name string) *Route {
+	t := r.mux
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	names := r.names
+	if names == nil {
+		if t.routeNames == nil {
+			t.routeNames = make(map[string]*RouteInfo)
+		}
+		names = t.routeNames
+	}
+
+	if other, ok := names[name]; ok && other != r.info {
+		panic(fmt.Sprintf("route name %q is already used by %s %s", name, other.Method, other.Route))
+	}
+	if r.info.Name != "" {
+		delete(names, r.info.Name)
+	}
+	r.info.Name = name
+	names[name] = r.info
+	return r
+}
+
+// URL returns the path of the route named with Route.Name with the params substituted.
+// Values are escaped. The catch-all param can contain slashes, so it is escaped
+// segment by segment.
+// Values of typed params must be valid for the type, otherwise a *ParamError is returned.
+func (t *TreeMux) URL(routeName string, params map[string]string) (string, error) {
+	t.mutex.RLock()
+	info, ok := t.routeNames[routeName]
+	t.mutex.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("treemux: route %q is not found", routeName)
+	}
+
+	ps := make(Params, 0, len(params))
+	for name, value := range params {
+		ps = append(ps, Param{Name: name, Value: value})
+	}
+
+	for name, typ := range info.ParamTypes {
+		value, ok := params[name]
+		if !ok {
+			continue
+		}
+		if _, err := typ.Decode(value); err != nil {
+			return "", &ParamError{Param: name, Value: value, Type: typ, Err: err}
+		}
+	}
+
+	path, err := buildPath(info.Route, ps)
+	if err != nil {
+		return "", err
+	}
+	if info.trailingSlash && !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+	return path, nil
+}
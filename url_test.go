@@ -0,0 +1,77 @@
+package treemux
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestURL(t *testing.T) {
+	router := New()
+	router.GET("/users/:id", simpleHandler).Name("user")
+	router.GET("/posts/:id|int/", simpleHandler).Name("post")
+	router.GET("/files/*path", simpleHandler).Name("file")
+
+	tests := []struct {
+		name   string
+		params map[string]string
+		want   string
+	}{
+		{"user", map[string]string{"id": "a b"}, "/users/a%20b"},
+		{"post", map[string]string{"id": "42"}, "/posts/42/"},
+		{"file", map[string]string{"path": "/a/b.txt"}, "/files/a/b.txt"},
+		{"file", map[string]string{"path": "a b/c?d#e%f.txt"}, "/files/a%20b/c%3Fd%23e%25f.txt"},
+	}
+	for _, test := range tests {
+		got, err := router.URL(test.name, test.params)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		} else if got != test.want {
+			t.Errorf("%s: got %q, wanted %q", test.name, got, test.want)
+		}
+	}
+
+	if _, err := router.URL("missing", nil); err == nil {
+		t.Error("expected an error for an unknown route")
+	}
+	if _, err := router.URL("user", nil); err == nil {
+		t.Error("expected an error for a missing param")
+	}
+	var paramErr *ParamError
+	if _, err := router.URL("post", map[string]string{"id": "abc"}); !errors.As(err, &paramErr) {
+		t.Errorf("got %v, wanted a *ParamError", err)
+	}
+}
+
+func TestRouteNameDuplicate(t *testing.T) {
+	router := New()
+	router.GET("/a", simpleHandler).Name("route")
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a duplicate route name")
+		}
+	}()
+	router.GET("/b", simpleHandler).Name("route")
+}
+
+func TestRequestRouteName(t *testing.T) {
+	var names []string
+	handler := func(w http.ResponseWriter, req Request) error {
+		names = append(names, req.RouteName())
+		return nil
+	}
+	router := New()
+	router.GET("/named", handler).Name("named")
+	router.GET("/unnamed", handler)
+
+	for _, path := range []string{"/named", "/unnamed"} {
+		r, _ := newRequest("GET", path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	if !reflect.DeepEqual(names, []string{"named", ""}) {
+		t.Errorf("got %q", names)
+	}
+}
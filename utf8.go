@@ -0,0 +1,49 @@
+package treemux
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// UTF8Policy controls how the router handles paths with invalid UTF-8, including
+// over-long encodings and surrogates, after percent-decoding.
+type UTF8Policy int
+
+const (
+	// AllowInvalidUTF8 matches such paths as is. This is the default.
+	AllowInvalidUTF8 UTF8Policy = iota
+	// RejectInvalidUTF8 responds with 400 Bad Request before matching.
+	RejectInvalidUTF8
+	// ReplaceInvalidUTF8 replaces invalid sequences with U+FFFD before matching,
+	// so handlers and params never see invalid UTF-8.
+	ReplaceInvalidUTF8
+)
+
+// checkUTF8 applies the UTF-8 policy to the request path. It returns the request to serve
+// or nil if the request must be rejected.
+func (t *TreeMux) checkUTF8(r *http.Request) *http.Request {
+	if t.PathUTF8 == AllowInvalidUTF8 || utf8.ValidString(r.URL.Path) {
+		return r
+	}
+	if t.PathUTF8 == RejectInvalidUTF8 {
+		return nil
+	}
+
+	u := *r.URL
+	u.Path = strings.ToValidUTF8(u.Path, "\uFFFD")
+	u.RawPath = ""
+
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = &u
+	if r.RequestURI != "" {
+		r2.RequestURI = u.RequestURI()
+	}
+	return r2
+}
+
+func badPathHandler(w http.ResponseWriter, req Request) error {
+	http.Error(w, "invalid UTF-8 in path", http.StatusBadRequest)
+	return nil
+}
@@ -0,0 +1,55 @@
+package treemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPathUTF8(t *testing.T) {
+	var param, path string
+	handler := func(w http.ResponseWriter, req Request) error {
+		param, path = req.Param("name"), req.URL.Path
+		return nil
+	}
+
+	tests := []struct {
+		policy UTF8Policy
+		code   int
+		param  string
+	}{
+		{AllowInvalidUTF8, http.StatusOK, "a\xffb"},
+		{RejectInvalidUTF8, http.StatusBadRequest, ""},
+		{ReplaceInvalidUTF8, http.StatusOK, "a�b"},
+	}
+	for _, test := range tests {
+		for _, source := range []PathSource{RequestURI, URLPath} {
+			router := New()
+			router.PathSource = source
+			router.PathUTF8 = test.policy
+			router.GET("/users/:name", handler)
+
+			param, path = "", ""
+			r, _ := newRequest("GET", "/users/a%FFb", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if w.Code != test.code || param != test.param {
+				t.Errorf("policy %d source %d: got %d param %q", test.policy, source, w.Code, param)
+			}
+			if test.policy == ReplaceInvalidUTF8 && path != "/users/a�b" {
+				t.Errorf("got path %q", path)
+			}
+		}
+	}
+
+	router := New()
+	router.PathUTF8 = RejectInvalidUTF8
+	router.GET("/users/:name", handler)
+	r, _ := newRequest("GET", "/users/%C0%AF", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("got %d for an over-long encoding", w.Code)
+	}
+}
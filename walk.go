@@ -0,0 +1,62 @@
+package treemux
+
+// RouteNode describes a path registered in the routing tree, which can have
+// handlers for several methods.
+type RouteNode struct {
+	// Host is the host of the routing tree, empty for the default tree.
+	Host string
+	// Pattern is the route template, e.g. "/users/:id".
+	Pattern string
+	// Methods contains the sorted methods handled by the route, including implicit
+	// HEAD and OPTIONS handlers.
+	Methods []string
+	// Wildcards contains the names of the params in path order.
+	Wildcards []string
+	// AddSlash reports whether the route was registered with a trailing slash.
+	AddSlash bool
+	// Routes maps methods to the registered routes, which carry the metadata.
+	// Implicit OPTIONS handlers have no route.
+	Routes map[string]*RouteInfo
+}
+
+// Walk calls fn for every path registered in the default tree and the host trees
+// until fn returns false. Paths are visited in the order of the trees, which is
+// not sorted. The tree is not locked while fn is called, so fn can register routes,
+// but they may not be visited.
+func (t *TreeMux) Walk(fn func(route RouteNode) bool) {
+	t.mutex.RLock()
+	hosts := make(map[*node]string, len(t.hosts))
+	for host, root := range t.hosts {
+		hosts[root] = host
+	}
+
+	var routes []RouteNode
+	for _, root := range t.trees() {
+		host := hosts[root]
+		root.walk(0, func(n *node, depth int) {
+			if n.handlerMap.Len() == 0 {
+				return
+			}
+
+			route := RouteNode{
+				Host:      host,
+				Pattern:   n.route,
+				Wildcards: append([]string(nil), n.leafWildcardNames...),
+				AddSlash:  n.addSlash,
+				Methods:   n.handlerMap.Methods(),
+				Routes:    make(map[string]*RouteInfo, len(n.handlerMap.routes)),
+			}
+			for method, info := range n.handlerMap.routes {
+				route.Routes[method] = info
+			}
+			routes = append(routes, route)
+		})
+	}
+	t.mutex.RUnlock()
+
+	for _, route := range routes {
+		if !fn(route) {
+			return
+		}
+	}
+}
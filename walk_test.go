@@ -0,0 +1,63 @@
+package treemux
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	router := New()
+	router.GET("/", simpleHandler)
+	router.GET("/users/:id/posts/:post", simpleHandler).Meta("auth", true)
+	router.DELETE("/users/:id/posts/:post", simpleHandler)
+	router.GET("/files/*path", simpleHandler)
+	router.GET("/dir/", simpleHandler)
+	router.Host("api.example.com").POST("/items", simpleHandler)
+
+	routes := make(map[string]RouteNode)
+	router.Walk(func(route RouteNode) bool {
+		routes[route.Host+route.Pattern] = route
+		return true
+	})
+
+	var patterns []string
+	for pattern := range routes {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	wanted := []string{"/", "/dir", "/files/*path", "/users/:id/posts/:post", "api.example.com/items"}
+	if !reflect.DeepEqual(patterns, wanted) {
+		t.Fatalf("got patterns %v, wanted %v", patterns, wanted)
+	}
+
+	posts := routes["/users/:id/posts/:post"]
+	if !reflect.DeepEqual(posts.Methods, []string{"DELETE", "GET", "HEAD"}) {
+		t.Errorf("got methods %v", posts.Methods)
+	}
+	if !reflect.DeepEqual(posts.Wildcards, []string{"id", "post"}) {
+		t.Errorf("got wildcards %v", posts.Wildcards)
+	}
+	if auth, _ := posts.Routes["GET"].Bool("auth"); !auth {
+		t.Error("route metadata is missing")
+	}
+	if !reflect.DeepEqual(routes["/files/*path"].Wildcards, []string{"path"}) {
+		t.Errorf("got wildcards %v", routes["/files/*path"].Wildcards)
+	}
+	if !routes["/dir"].AddSlash || routes["/"].AddSlash {
+		t.Error("wrong AddSlash")
+	}
+	if items := routes["api.example.com/items"]; items.Host != "api.example.com" ||
+		!reflect.DeepEqual(items.Methods, []string{"POST"}) {
+		t.Errorf("got %+v", items)
+	}
+
+	var n int
+	router.Walk(func(route RouteNode) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Errorf("walk visited %d routes after returning false", n)
+	}
+}
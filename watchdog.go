@@ -0,0 +1,106 @@
+package treemux
+
+import (
+	"bytes"
+	"net/http"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// MetaSlowThreshold is the route metadata key that overrides the Watchdog threshold
+// for the route. The value must be a time.Duration.
+const MetaSlowThreshold = "slow_threshold"
+
+// SlowRequest describes a request that exceeded the watchdog threshold.
+type SlowRequest struct {
+	Method  string
+	Route   string
+	Path    string
+	Elapsed time.Duration
+	// Stack is the stack trace of the goroutine handling the request.
+	// It is empty if the stack could not be captured.
+	Stack []byte
+}
+
+// WatchdogConfig configures the Watchdog middleware.
+type WatchdogConfig struct {
+	// Threshold is the soft time limit for a request.
+	Threshold time.Duration
+	// OnSlow is called from a separate goroutine while the slow request is still running.
+	OnSlow func(req Request, slow *SlowRequest)
+}
+
+// Watchdog returns a middleware that calls OnSlow when a request takes longer than
+// the threshold. Unlike a timeout it does not interrupt the handler, so it helps to find
+// stuck handlers in production. The threshold can be changed per route with
+// the MetaSlowThreshold route metadata.
+func Watchdog(cfg WatchdogConfig) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			threshold := cfg.Threshold
+			if v, ok := req.RouteInfo().Value(MetaSlowThreshold); ok {
+				if d, ok := v.(time.Duration); ok {
+					threshold = d
+				}
+			}
+			if threshold <= 0 {
+				return next(w, req)
+			}
+
+			start := time.Now()
+			goid := currentGoroutineID()
+			timer := time.AfterFunc(threshold, func() {
+				cfg.OnSlow(req, &SlowRequest{
+					Method:  req.Method,
+					Route:   req.Route(),
+					Path:    req.URL.Path,
+					Elapsed: time.Since(start),
+					Stack:   goroutineStack(goid),
+				})
+			})
+			defer timer.Stop()
+
+			return next(w, req)
+		}
+	}
+}
+
+var goroutinePrefix = []byte("goroutine ")
+
+// currentGoroutineID parses the id of the current goroutine from its stack trace.
+func currentGoroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, goroutinePrefix)
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
+
+// goroutineStack returns the stack trace of the goroutine with the given id.
+func goroutineStack(id uint64) []byte {
+	if id == 0 {
+		return nil
+	}
+
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	header := []byte("goroutine " + strconv.FormatUint(id, 10) + " ")
+	for _, stack := range bytes.Split(buf, []byte("\n\n")) {
+		if bytes.HasPrefix(stack, header) {
+			return stack
+		}
+	}
+	return nil
+}
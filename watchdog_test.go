@@ -0,0 +1,51 @@
+package treemux
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWatchdog(t *testing.T) {
+	slowCh := make(chan *SlowRequest, 1)
+	release := make(chan struct{})
+
+	router := New()
+	router.Use(Watchdog(WatchdogConfig{
+		Threshold: time.Hour,
+		OnSlow: func(req Request, slow *SlowRequest) {
+			slowCh <- slow
+			close(release)
+		},
+	}))
+	router.GET("/fast", simpleHandler)
+	router.GET("/stuck", func(w http.ResponseWriter, req Request) error {
+		stuckHandler(release)
+		return nil
+	}).Meta(MetaSlowThreshold, 10*time.Millisecond)
+
+	r, _ := newRequest("GET", "/fast", nil)
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	r, _ = newRequest("GET", "/stuck", nil)
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	select {
+	case slow := <-slowCh:
+		if slow.Route != "/stuck" || slow.Elapsed < 10*time.Millisecond {
+			t.Errorf("unexpected slow request %+v", slow)
+		}
+		if !bytes.Contains(slow.Stack, []byte("stuckHandler")) {
+			t.Errorf("stack does not contain the handler:\n%s", slow.Stack)
+		}
+	default:
+		t.Fatal("OnSlow was not called")
+	}
+}
+
+//go:noinline
+func stuckHandler(release chan struct{}) {
+	<-release
+}
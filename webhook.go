@@ -0,0 +1,221 @@
+package treemux
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidSignature is returned by webhook verifiers when the signature is missing
+	// or does not match the request body.
+	ErrInvalidSignature = errors.New("treemux: invalid webhook signature")
+	// ErrWebhookExpired is returned when the webhook timestamp is outside of the replay window.
+	ErrWebhookExpired = errors.New("treemux: webhook timestamp is outside of the replay window")
+)
+
+// WebhookVerifier verifies the authenticity of a webhook request.
+type WebhookVerifier interface {
+	Verify(req Request, body []byte) error
+}
+
+// WebhookVerifierFunc is an adapter to allow the use of ordinary functions as WebhookVerifier.
+type WebhookVerifierFunc func(req Request, body []byte) error
+
+func (fn WebhookVerifierFunc) Verify(req Request, body []byte) error {
+	return fn(req, body)
+}
+
+// GitHubSignature returns a verifier for GitHub-style signatures: the hex-encoded
+// HMAC-SHA256 of the body in the X-Hub-Signature-256 header prefixed with "sha256=".
+func GitHubSignature(secret []byte) WebhookVerifier {
+	return WebhookVerifierFunc(func(req Request, body []byte) error {
+		sig := req.Header.Get("X-Hub-Signature-256")
+		if !strings.HasPrefix(sig, "sha256=") {
+			return ErrInvalidSignature
+		}
+		if !validHMAC(secret, body, sig[len("sha256="):]) {
+			return ErrInvalidSignature
+		}
+		return nil
+	})
+}
+
+// StripeSignature returns a verifier for Stripe-style signatures. The Stripe-Signature
+// header contains a unix timestamp and one or more HMAC-SHA256 signatures of the
+// "timestamp.body" payload, e.g. "t=1492774577,v1=5257a869...". Requests with timestamps
+// further than the tolerance from the current time, in the past or in the future, are
+// rejected with ErrWebhookExpired; zero tolerance disables the check.
+func StripeSignature(secret []byte, tolerance time.Duration) WebhookVerifier {
+	return WebhookVerifierFunc(func(req Request, body []byte) error {
+		var timestamp string
+		var sigs []string
+		for _, part := range strings.Split(req.Header.Get("Stripe-Signature"), ",") {
+			kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			switch kv[0] {
+			case "t":
+				timestamp = kv[1]
+			case "v1":
+				sigs = append(sigs, kv[1])
+			}
+		}
+
+		sec, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil || len(sigs) == 0 {
+			return ErrInvalidSignature
+		}
+
+		payload := make([]byte, 0, len(timestamp)+1+len(body))
+		payload = append(payload, timestamp...)
+		payload = append(payload, '.')
+		payload = append(payload, body...)
+
+		for _, sig := range sigs {
+			if validHMAC(secret, payload, sig) {
+				skew := time.Since(time.Unix(sec, 0))
+				if tolerance > 0 && (skew > tolerance || skew < -tolerance) {
+					return ErrWebhookExpired
+				}
+				return nil
+			}
+		}
+		return ErrInvalidSignature
+	})
+}
+
+func validHMAC(secret, payload []byte, sig string) bool {
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// WebhookConfig configures the Webhook middleware.
+type WebhookConfig struct {
+	// Verifier verifies the request signature. Unverified requests are rejected
+	// with 401 Unauthorized.
+	Verifier WebhookVerifier
+	// MaxBodySize limits the size of the webhook body. The default is 1MB.
+	MaxBodySize int64
+	// Async makes the middleware respond with 202 Accepted as soon as the webhook is verified
+	// and run the handler in a separate goroutine. The handler response is discarded and
+	// the request context is not canceled when the client disconnects.
+	Async bool
+	// OnError is called with errors returned by asynchronous handlers.
+	OnError func(req Request, err error)
+	// OnPanic is called with the value of a panic in an asynchronous handler.
+	// The default passes the value to the PanicHandler of the route with a writer
+	// that discards the response.
+	OnPanic func(req Request, recovered interface{})
+}
+
+type webhookBodyKey struct{}
+
+// WebhookBody returns the raw body of a webhook request captured by the Webhook middleware.
+// The request body can be read as usual too.
+func WebhookBody(req Request) []byte {
+	b, _ := req.Context().Value(webhookBodyKey{}).([]byte)
+	return b
+}
+
+// Webhook returns a middleware for webhook endpoints. It captures the raw request body,
+// verifies the signature, and optionally hands the webhook off for asynchronous processing.
+//
+//	hooks := router.NewGroup("/webhooks")
+//	hooks.Use(treemux.Webhook(treemux.WebhookConfig{
+//		Verifier: treemux.GitHubSignature(secret),
+//		Async:    true,
+//	}))
+//	hooks.POST("/github", handleGitHub)
+func Webhook(cfg WebhookConfig) MiddlewareFunc {
+	if cfg.MaxBodySize == 0 {
+		cfg.MaxBodySize = 1 << 20
+	}
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, req Request) error {
+			var body []byte
+			if req.Body != nil {
+				var err error
+				body, err = ioutil.ReadAll(io.LimitReader(req.Body, cfg.MaxBodySize+1))
+				if err != nil {
+					return err
+				}
+				if int64(len(body)) > cfg.MaxBodySize {
+					http.Error(w, ErrBodyTooLarge.Error(), http.StatusRequestEntityTooLarge)
+					return nil
+				}
+			}
+
+			if cfg.Verifier != nil {
+				if err := cfg.Verifier.Verify(req, body); err != nil {
+					http.Error(w, err.Error(), http.StatusUnauthorized)
+					return nil
+				}
+			}
+
+			ctx := req.Context()
+			if cfg.Async {
+				ctx = context.Background()
+			}
+			ctx = context.WithValue(ctx, webhookBodyKey{}, body)
+
+			r := req.Request.Clone(ctx)
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			req.Request = r
+			req = req.WithContext(ctx)
+
+			if !cfg.Async {
+				return next(w, req)
+			}
+
+			go func() {
+				w := newDiscardResponseWriter()
+				defer recoverAsyncWebhook(&cfg, w, req)
+				if err := next(w, req); err != nil && cfg.OnError != nil {
+					cfg.OnError(req, err)
+				}
+			}()
+			w.WriteHeader(http.StatusAccepted)
+			return nil
+		}
+	}
+}
+
+// recoverAsyncWebhook reports a panic of an asynchronous webhook handler, which would
+// otherwise crash the program since no server recovers it. Panics not handled by
+// OnPanic or a PanicHandler are passed to OnError.
+func recoverAsyncWebhook(cfg *WebhookConfig, w http.ResponseWriter, req Request) {
+	v := recover()
+	if v == nil || v == http.ErrAbortHandler {
+		return
+	}
+	if cfg.OnPanic != nil {
+		cfg.OnPanic(req, v)
+		return
+	}
+	if req.mux != nil {
+		if handler := req.mux.EffectiveHandlers(req.info).PanicHandler; handler != nil {
+			handler(w, req, v)
+			return
+		}
+	}
+	if cfg.OnError != nil {
+		cfg.OnError(req, fmt.Errorf("treemux: webhook handler panicked: %v", v))
+	}
+}
@@ -0,0 +1,121 @@
+package treemux
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testHMAC(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWebhookGitHub(t *testing.T) {
+	router := New()
+	router.Use(Webhook(WebhookConfig{Verifier: GitHubSignature([]byte("secret"))}))
+	router.POST("/hook", func(w http.ResponseWriter, req Request) error {
+		b, _ := ioutil.ReadAll(req.Body)
+		if string(b) != string(WebhookBody(req)) {
+			t.Errorf("body %q != raw body %q", b, WebhookBody(req))
+		}
+		_, err := w.Write(b)
+		return err
+	})
+
+	post := func(sig string) *httptest.ResponseRecorder {
+		r, _ := newRequest("POST", "/hook", strings.NewReader(`{"action":"opened"}`))
+		r.Header.Set("X-Hub-Signature-256", sig)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := post("sha256=" + testHMAC("secret", `{"action":"opened"}`))
+	if w.Code != http.StatusOK || w.Body.String() != `{"action":"opened"}` {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+	if w := post("sha256=" + testHMAC("wrong", `{"action":"opened"}`)); w.Code != http.StatusUnauthorized {
+		t.Errorf("got %d for invalid signature", w.Code)
+	}
+	if w := post(""); w.Code != http.StatusUnauthorized {
+		t.Errorf("got %d for missing signature", w.Code)
+	}
+}
+
+func TestWebhookStripeAsync(t *testing.T) {
+	done := make(chan string, 1)
+	router := New()
+	router.Use(Webhook(WebhookConfig{
+		Verifier: StripeSignature([]byte("secret"), 5*time.Minute),
+		Async:    true,
+	}))
+	router.POST("/hook", func(w http.ResponseWriter, req Request) error {
+		done <- string(WebhookBody(req))
+		return nil
+	})
+
+	post := func(ts time.Time) int {
+		timestamp := strconv.FormatInt(ts.Unix(), 10)
+		r, _ := newRequest("POST", "/hook", strings.NewReader("event"))
+		r.Header.Set("Stripe-Signature",
+			"t="+timestamp+",v1="+testHMAC("secret", timestamp+".event"))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := post(time.Now()); code != http.StatusAccepted {
+		t.Fatalf("got %d, wanted 202", code)
+	}
+	select {
+	case body := <-done:
+		if body != "event" {
+			t.Errorf("got body %q", body)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler was not called")
+	}
+
+	if code := post(time.Now().Add(-time.Hour)); code != http.StatusUnauthorized {
+		t.Errorf("got %d for an expired webhook", code)
+	}
+	if code := post(time.Now().Add(time.Hour)); code != http.StatusUnauthorized {
+		t.Errorf("got %d for a webhook from the future", code)
+	}
+}
+
+func TestWebhookAsyncPanic(t *testing.T) {
+	recovered := make(chan interface{}, 1)
+	router := New()
+	router.PanicHandler = func(w http.ResponseWriter, req Request, v interface{}) {
+		recovered <- v
+	}
+	router.Use(Webhook(WebhookConfig{Async: true}))
+	router.POST("/hook", func(w http.ResponseWriter, req Request) error {
+		panic("boom")
+	})
+
+	r, _ := newRequest("POST", "/hook", strings.NewReader("event"))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("got %d, wanted 202", w.Code)
+	}
+	select {
+	case v := <-recovered:
+		if v != "boom" {
+			t.Errorf("got %v, wanted boom", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("PanicHandler was not called")
+	}
+}